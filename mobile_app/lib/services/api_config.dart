@@ -11,6 +11,7 @@ class ApiConfig {
   // KYC endpoints
   static const String kycSubmitEndpoint = '/kyc/submit';
   static const String kycStatusEndpoint = '/kyc/status';
+  static const String kycDocumentTypesEndpoint = '/kyc/document-types';
   
   // Full URLs
   static String get registerUrl => '$authBaseUrl$registerEndpoint';
@@ -18,4 +19,5 @@ class ApiConfig {
   static String get profileUrl => '$authBaseUrl$profileEndpoint';
   static String get kycSubmitUrl => '$kycBaseUrl$kycSubmitEndpoint';
   static String get kycStatusUrl => '$kycBaseUrl$kycStatusEndpoint';
+  static String get kycDocumentTypesUrl => '$kycBaseUrl$kycDocumentTypesEndpoint';
 }
@@ -41,6 +41,21 @@ class KYCService {
     };
   }
 
+  /// Get the supported document types and the form fields each one needs,
+  /// so the KYC form can be built from the server's registry.
+  /// Returns: Map with 'success' (bool) and 'message' or 'data' (List)
+  Future<Map<String, dynamic>> getDocumentTypes() async {
+    try {
+      final response = await http.get(Uri.parse(ApiConfig.kycDocumentTypesUrl));
+      if (response.statusCode == 200) {
+        return {'success': true, 'data': jsonDecode(response.body) as List<dynamic>};
+      }
+      return {'success': false, 'message': 'Failed to load document types'};
+    } catch (e) {
+      return {'success': false, 'message': 'Network error: $e'};
+    }
+  }
+
   /// Get KYC status
   /// Returns: Map with 'success' (bool) and 'message' or 'data' (Map)
   Future<Map<String, dynamic>> getKYCStatus() async {
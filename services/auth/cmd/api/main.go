@@ -80,6 +80,12 @@ func main() {
 		profileRoutes.PUT("", profileHandler.UpdateProfile)
 	}
 
+	internalRoutes := r.Group("/internal")
+	internalRoutes.Use(middleware.ServiceTokenMiddleware(cfg.ServiceToken))
+	{
+		internalRoutes.GET("/users/:id", profileHandler.GetUser)
+	}
+
 	log.Printf("Server starting on port %s", cfg.Port)
 	if err := r.Run(":" + cfg.Port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
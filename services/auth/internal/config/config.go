@@ -9,10 +9,11 @@ import (
 
 // Config holds the application configuration.
 type Config struct {
-	Port      string
-	MongoURI  string
-	DBName    string
-	JWTSecret string
+	Port         string
+	MongoURI     string
+	DBName       string
+	JWTSecret    string
+	ServiceToken string
 }
 
 // LoadConfig loads configuration from environment variables.
@@ -23,10 +24,11 @@ func LoadConfig() (*Config, error) {
 	}
 
 	config := &Config{
-		Port:      getEnv("PORT", "8080"),
-		MongoURI:  getEnv("MONGO_URI", "mongodb://localhost:27017"),
-		DBName:    getEnv("DB_NAME", "auth_db"),
-		JWTSecret: getEnv("JWT_SECRET", "super-secret-key"),
+		Port:         getEnv("PORT", "8080"),
+		MongoURI:     getEnv("MONGO_URI", "mongodb://localhost:27017"),
+		DBName:       getEnv("DB_NAME", "auth_db"),
+		JWTSecret:    getEnv("JWT_SECRET", "super-secret-key"),
+		ServiceToken: getEnv("SERVICE_API_TOKEN", ""),
 	}
 
 	return config, nil
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"auth/internal/repository"
@@ -90,3 +91,28 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, user)
 }
+
+// GetUser returns any user's profile to another service.
+// @Summary Get User
+// @Description Retrieves a user's profile for service-to-service calls
+// @Tags internal
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.User
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /internal/users/{id} [get]
+func (h *ProfileHandler) GetUser(c *gin.Context) {
+	user, err := h.repo.GetUserByID(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, repository.ErrUserNotFound) || errors.Is(err, repository.ErrInvalidUserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -21,6 +21,10 @@ type User struct {
 	// It is not returned in JSON responses.
 	PasswordHash string `bson:"password_hash" json:"-"`
 
+	// PhoneVerified reports whether the user has confirmed a phone number.
+	// There is no verification flow yet, so it is only set by hand.
+	PhoneVerified bool `bson:"phone_verified" json:"phone_verified"`
+
 	// CreatedAt is the timestamp when the user was created.
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 
@@ -10,10 +10,13 @@ import (
 	"time"
 
 	"kyc/internal/config"
+	"kyc/internal/forensics"
 	"kyc/internal/handlers"
 	"kyc/internal/middleware"
 	"kyc/internal/repository"
 	"kyc/internal/services"
+	"kyc/internal/tiers"
+	"kyc/internal/uploads"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -46,17 +49,131 @@ func main() {
 	db := client.Database(cfg.DBName)
 	kycRepo := repository.NewKYCRepository(db)
 
+	imageHashRepo := repository.NewImageHashRepository(db)
+	accessLogRepo := repository.NewAccessLogRepository(db)
+	quarantineRepo := repository.NewQuarantineRepository(db)
+	screeningRepo := repository.NewScreeningRepository(db)
+
 	if err := kycRepo.EnsureIndices(ctx); err != nil {
 		log.Printf("Warning: Failed to ensure indices: %v", err)
 	}
+	if err := imageHashRepo.EnsureIndices(ctx); err != nil {
+		log.Printf("Warning: Failed to ensure image hash indices: %v", err)
+	}
+	if err := accessLogRepo.EnsureIndices(ctx); err != nil {
+		log.Printf("Warning: Failed to ensure access log indices: %v", err)
+	}
+	if err := quarantineRepo.EnsureIndices(ctx); err != nil {
+		log.Printf("Warning: Failed to ensure quarantine indices: %v", err)
+	}
+	if err := screeningRepo.EnsureIndices(ctx); err != nil {
+		log.Printf("Warning: Failed to ensure screening indices: %v", err)
+	}
+
+	var verdictCache *services.VerdictCache
+	if cfg.VerdictCacheTTL > 0 {
+		verdictCacheRepo := repository.NewVerdictCacheRepository(db, cfg.VerdictCacheTTL)
+		if err := verdictCacheRepo.EnsureIndices(ctx); err != nil {
+			log.Printf("Warning: Failed to ensure verdict cache indices: %v", err)
+		}
+		verdictCache = services.NewVerdictCache(verdictCacheRepo, cfg.VerdictCacheTTL)
+	}
 
 	authMiddleware := middleware.NewAuthMiddleware(cfg.AuthServiceURL)
 	// Verify Service
-	verifyService := services.NewVerificationService(cfg.HuggingFaceAPIKey, cfg.HuggingFaceModelURL, cfg.HuggingFaceModelID)
+	promptRegistry, err := services.LoadPrompts(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load prompts: %v", err)
+	}
+	verifyService, err := services.NewDocumentVerifier(cfg, promptRegistry, verdictCache)
+	if err != nil {
+		log.Fatalf("Failed to configure document verifier: %v", err)
+	}
+	log.Printf("Document verifier: %s", verifyService.Name())
 
-	kycHandler := handlers.NewKYCHandler(kycRepo, verifyService)
+	faceMatcher, err := services.NewFaceMatcher(cfg, promptRegistry)
+	if err != nil {
+		log.Fatalf("Failed to configure face matcher: %v", err)
+	}
+	faces := &services.FaceCheck{
+		Matcher:   faceMatcher,
+		Threshold: cfg.FaceMatchThreshold,
+		Required:  cfg.SelfieRequired,
+	}
+
+	malwareScanner, err := services.NewScanner(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure malware scanner: %v", err)
+	}
+	log.Printf("Malware scanner: %s", malwareScanner.Name())
+	malware := services.NewMalwareGuard(malwareScanner, cfg.QuarantineDir, quarantineRepo)
+
+	var analyzer *forensics.Analyzer
+	if cfg.ForensicsEnabled {
+		analyzer = &forensics.Analyzer{HighRisk: cfg.ForensicsHighRisk}
+	}
+
+	screener := services.NewScreener(kycRepo, screeningRepo, cfg.WatchlistDir, cfg.ScreeningThreshold)
+	if err := screener.Reload(); err != nil {
+		log.Fatalf("Failed to load watchlists: %v", err)
+	}
+	if screener.Entries() == 0 {
+		log.Printf("Warning: no watchlist entries in %s, sanctions screening is ineffective", cfg.WatchlistDir)
+	} else {
+		log.Printf("Watchlists: %d entries from %d files", screener.Entries(), len(screener.Lists()))
+	}
+
+	tierConfig, err := tiers.Load(cfg.TierConfig)
+	if err != nil {
+		log.Fatalf("Failed to load KYC tiers: %v", err)
+	}
+	tierService := services.NewTierService(kycRepo, services.NewAuthClient(cfg.AuthServiceURL, cfg.AuthServiceToken), tierConfig)
+
+	uploadPolicy := uploads.Policy{
+		MaxFileBytes: int64(cfg.UploadMaxFileMB) << 20,
+		MaxFiles:     cfg.UploadMaxFiles,
+		MaxPixels:    cfg.UploadMaxPixels,
+		MaxDimension: cfg.UploadMaxDimension,
+		AllowPDF:     cfg.UploadAllowPDF,
+	}
+
+	duplicates := services.NewDuplicateDetector(imageHashRepo)
+	if n, err := duplicates.RebuildBands(ctx); err != nil {
+		log.Printf("Warning: Failed to rebuild image hash bands: %v", err)
+	} else if n > 0 {
+		log.Printf("Rebuilt bands of %d image hashes", n)
+	}
+
+	resubmission := services.ResubmissionPolicy{
+		Cooldown:    cfg.ResubmitCooldown,
+		MaxAttempts: cfg.MaxKYCAttempts,
+	}
+
+	reviewQueue := services.NewReviewQueue(kycRepo, cfg.ReviewClaimLease, cfg.ReviewSLA)
+
+	if len(cfg.Reviewers) == 0 {
+		log.Printf("Warning: KYC_REVIEWERS is empty, nobody can use the admin routes")
+	}
+	documents, err := services.NewDocumentAccess(cfg.DocumentURLSecret, cfg.DocumentURLTTL, "uploads", accessLogRepo, cfg.DocumentWatermark)
+	if err != nil {
+		log.Fatalf("Failed to set up document access: %v", err)
+	}
+
+	kycHandler := handlers.NewKYCHandler(kycRepo, verifyService, duplicates, uploadPolicy, resubmission, reviewQueue, documents, services.VerifyLimits{
+		Concurrency: cfg.VerifyConcurrency,
+		Timeout:     cfg.VerifyTimeout,
+	}, faces, analyzer, malware, screener, tierService)
+
+	verdictCacheHandler := handlers.NewVerdictCacheHandler(verdictCache)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go services.NewExpirer(kycRepo, cfg.NeedsInfoTTL).Run(jobsCtx, cfg.ExpiryCheckInterval)
+	go services.NewJanitor(kycRepo, "uploads", cfg.OrphanUploadGrace).Run(jobsCtx, cfg.JanitorInterval)
+	go screener.Run(jobsCtx, cfg.ScreeningInterval)
 
 	r := gin.Default()
+	r.MaxMultipartMemory = 8 << 20 // Larger uploads are spooled to temp files
 
 	// Enable CORS
 	r.Use(cors.New(cors.Config{
@@ -70,8 +187,12 @@ func main() {
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
+	r.GET("/ready", handlers.Readiness(client, verifyService, malwareScanner))
 
-	// Public routes (none for now)
+	// Public routes
+	r.GET("/kyc/document-types", kycHandler.ListDocumentTypes)
+	r.GET("/kyc/tiers", kycHandler.ListTiers)
+	r.GET("/kyc/documents/:id/:index", kycHandler.ServeDocument) // Signed URL
 
 	// Protected routes
 	api := r.Group("/kyc")
@@ -79,15 +200,44 @@ func main() {
 	{
 		api.POST("/submit", kycHandler.SubmitKYC)
 		api.GET("/status", kycHandler.GetStatus)
+		api.GET("/tier", kycHandler.GetTier)
+		api.GET("/history", kycHandler.GetHistory)
+		api.POST("/messages", kycHandler.PostMessage)
+		api.POST("/supplement", kycHandler.SubmitSupplement)
+		api.POST("/appeal", kycHandler.Appeal)
 
-		// Admin routes (Protected by same auth for now, should have role check ideally)
+		// Admin routes
 		admin := api.Group("/admin")
+		admin.Use(middleware.RequireRole("reviewer", cfg.Reviewers))
 		{
+			admin.GET("/queue", kycHandler.AdminGetQueue)
 			admin.GET("/pending", kycHandler.AdminGetPending)
+			admin.POST("/queue/:id/claim", kycHandler.AdminClaim)
+			admin.POST("/queue/:id/release", kycHandler.AdminRelease)
+			admin.GET("/stats", kycHandler.AdminStats)
+			admin.GET("/fraud-review", kycHandler.AdminGetFraudReview)
+			admin.GET("/verdict-cache", verdictCacheHandler.Stats)
+			admin.DELETE("/verdict-cache", verdictCacheHandler.Invalidate)
 			admin.PUT("/verify/:id", kycHandler.AdminVerify)
+			admin.POST("/requests/:id/messages", kycHandler.AdminPostMessage)
+			admin.POST("/requests/:id/documents/:index/url", kycHandler.AdminDocumentURL)
+			admin.GET("/requests/:id/access-log", kycHandler.AdminAccessLog)
+			admin.GET("/quarantine", kycHandler.AdminQuarantine)
+			admin.GET("/screening/cases", kycHandler.AdminScreeningCases)
+			admin.POST("/screening/cases/:id/resolve", middleware.RequireRole("compliance", cfg.ComplianceOfficers), kycHandler.AdminResolveScreening)
+			admin.GET("/screening/lists", kycHandler.AdminWatchlists)
+			admin.POST("/screening/lists/reload", kycHandler.AdminReloadWatchlists)
+			admin.POST("/screening/rescreen", kycHandler.AdminRescreen)
 		}
 	}
 
+	// Service-to-service routes
+	internal := r.Group("/internal")
+	internal.Use(middleware.RequireServiceToken(cfg.ServiceToken))
+	{
+		internal.GET("/users/:user_id/kyc-tier", kycHandler.ServiceUserTier)
+	}
+
 	// Graceful Shutdown
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Irrelevant is the expected class of images that must be rejected.
+const Irrelevant = "IRRELEVANT"
+
+// answersFile holds canned model answers for the stub server, by sample path.
+const answersFile = "answers.json"
+
+// Sample is one labeled image of the dataset.
+type Sample struct {
+	Path     string // Relative to the dataset directory, with forward slashes
+	Expected string // Document type, or Irrelevant
+}
+
+// loadDataset lists the images in the class directories of dir. The name of
+// the directory an image is in is its expected class.
+func loadDataset(dir string) ([]Sample, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var samples []Sample
+	for _, class := range entries {
+		if !class.IsDir() || strings.HasPrefix(class.Name(), ".") {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, class.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			samples = append(samples, Sample{
+				Path:     class.Name() + "/" + f.Name(),
+				Expected: strings.ToUpper(class.Name()),
+			})
+		}
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no images in the class directories of %s", dir)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Path < samples[j].Path })
+	return samples, nil
+}
+
+// loadAnswers reads the canned answers of the dataset, if there are any.
+func loadAnswers(dir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, answersFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	answers := map[string]string{}
+	if err := json.Unmarshal(data, &answers); err != nil {
+		return nil, fmt.Errorf("%s: %w", answersFile, err)
+	}
+	return answers, nil
+}
+
+// saveAnswers writes the raw model output of each outcome as the canned
+// answers of the dataset, keeping existing answers for the other images.
+func saveAnswers(dir string, outcomes []Outcome) error {
+	answers, err := loadAnswers(dir)
+	if err != nil {
+		return err
+	}
+	for _, o := range outcomes {
+		if o.RawOutput != "" {
+			answers[o.Path] = o.RawOutput
+		}
+	}
+	data, err := json.MarshalIndent(answers, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, answersFile), append(data, '\n'), 0o644)
+}
@@ -0,0 +1,201 @@
+// Command kyc-eval runs the document verifier over a labeled dataset and
+// reports precision, recall and the confusion matrix per document type and
+// prompt version.
+//
+// The dataset has one directory per expected class: a document type such
+// as NID or PASSPORT, or IRRELEVANT for images that must be rejected.
+//
+//	dataset/NID/front.jpg
+//	dataset/PASSPORT/data-page.png
+//	dataset/IRRELEVANT/receipt.jpg
+//	dataset/answers.json
+//
+// With -stub, the verifier talks to a local OpenAI-compatible server that
+// replays the model answers in answers.json, so the evaluation runs in CI
+// without network access. -record fills answers.json from a live run.
+//
+// Usage:
+//
+//	kyc-eval -data ./dataset -stub -min-accuracy 0.95
+//	kyc-eval -data ./dataset -prompt classify-v2 -prompt classify-v3 -record
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kyc/internal/config"
+	"kyc/internal/modelstub"
+	"kyc/internal/services"
+	"kyc/internal/uploads"
+)
+
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, " ") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+func main() {
+	var prompts listFlag
+	dataDir := flag.String("data", "", "dataset directory (required)")
+	verifier := flag.String("verifier", "", "verifier chain, default VERIFIER_CHAIN")
+	flag.Var(&prompts, "prompt", "classification prompt version or split to evaluate, repeatable; default PROMPT_CLASSIFY")
+	stub := flag.Bool("stub", false, "use a local model server that replays answers.json")
+	record := flag.Bool("record", false, "write the model answers to answers.json")
+	outDir := flag.String("out", "eval-report", "directory for report.json and report.md")
+	minAccuracy := flag.Float64("min-accuracy", 0, "exit with status 1 if any prompt version scores lower")
+	flag.Parse()
+
+	if *dataDir == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *stub && *record {
+		log.Fatal("-record needs a live model, not -stub")
+	}
+
+	cfg := config.LoadConfig()
+	if *verifier != "" {
+		cfg.VerifierChain = *verifier
+	}
+	if len(prompts) == 0 {
+		prompts = listFlag{cfg.PromptClassify}
+	}
+
+	report, err := run(cfg, *dataDir, prompts, *stub)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := writeReports(*outDir, report); err != nil {
+		log.Fatalf("Failed to write reports: %v", err)
+	}
+	if *record {
+		if err := saveAnswers(*dataDir, report.Outcomes); err != nil {
+			log.Fatalf("Failed to record answers: %v", err)
+		}
+	}
+
+	failed := false
+	for _, v := range report.Versions {
+		fmt.Printf("%-24s accuracy %.3f (%d/%d), %d errors\n", v.PromptVersion, v.Accuracy, v.Correct, v.Total, v.Errors)
+		if v.Accuracy < *minAccuracy {
+			failed = true
+		}
+	}
+	fmt.Printf("Reports written to %s\n", *outDir)
+	if failed {
+		fmt.Printf("Accuracy below %.3f\n", *minAccuracy)
+		os.Exit(1)
+	}
+}
+
+// run evaluates every prompt version on the dataset in dataDir. With stub,
+// cfg is pointed at a local server replaying the dataset's canned answers.
+func run(cfg *config.Config, dataDir string, prompts []string, stub bool) (*Report, error) {
+	samples, err := loadDataset(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dataset: %w", err)
+	}
+
+	if stub {
+		handler, err := stubHandler(dataDir, samples, cfg.ModelMaxDimension)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load canned answers: %w", err)
+		}
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		cfg.VerifierChain = "openai"
+		cfg.OpenAIBaseURL = server.URL + "/v1"
+		cfg.OpenAIModelID = "stub"
+		cfg.OpenAIAPIKey = ""
+	}
+
+	var outcomes []Outcome
+	for _, prompt := range prompts {
+		cfg.PromptClassify = prompt
+		registry, err := services.LoadPrompts(cfg)
+		if err != nil {
+			return nil, err
+		}
+		v, err := services.NewDocumentVerifier(cfg, registry, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure verifier: %w", err)
+		}
+		log.Printf("Evaluating %s with prompt %s on %d images", v.Name(), prompt, len(samples))
+		for _, s := range samples {
+			outcomes = append(outcomes, evaluate(v, dataDir, s, prompt, cfg.VerifyTimeout))
+		}
+	}
+
+	return &Report{
+		GeneratedAt: time.Now(),
+		Dataset:     dataDir,
+		Verifier:    cfg.VerifierChain,
+		Stub:        stub,
+		Versions:    summarize(outcomes),
+		Outcomes:    outcomes,
+	}, nil
+}
+
+// evaluate verifies one sample. The expected type is declared to the
+// verifier as a user would, so type-specific prompts are exercised.
+func evaluate(v services.DocumentVerifier, dataDir string, s Sample, prompt string, timeout time.Duration) Outcome {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if s.Expected != Irrelevant {
+		ctx = services.WithDocumentType(ctx, s.Expected)
+	}
+
+	o := Outcome{Path: s.Path, Expected: s.Expected}
+	res, err := v.VerifyImage(ctx, filepath.Join(dataDir, filepath.FromSlash(s.Path)))
+	if err != nil {
+		o.Predicted = classError
+		o.PromptVersion = prompt
+		o.Error = err.Error()
+		return o
+	}
+	o.Predicted = predictedClass(res)
+	o.PromptVersion = res.PromptVersion
+	if o.PromptVersion == "" {
+		o.PromptVersion = "none" // Verifiers without prompts, e.g. rules
+	}
+	o.Provider = res.Provider
+	o.Confidence = res.Confidence
+	o.LatencyMS = res.LatencyMS
+	o.RawOutput = res.RawOutput
+	return o
+}
+
+// stubHandler keys the canned answers by the image as the verifier will
+// send it, after downscaling.
+func stubHandler(dataDir string, samples []Sample, maxDimension int) (*modelstub.Handler, error) {
+	answers, err := loadAnswers(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	handler := &modelstub.Handler{Answers: map[string]string{}}
+	for _, s := range samples {
+		answer, ok := answers[s.Path]
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dataDir, filepath.FromSlash(s.Path)))
+		if err != nil {
+			return nil, err
+		}
+		sent, _, err := uploads.ForModel(data, maxDimension)
+		if err != nil {
+			continue // Never sent to the model
+		}
+		handler.Answers[modelstub.ImageKey(sent)] = answer
+	}
+	log.Printf("Stub model has answers for %d of %d images", len(handler.Answers), len(samples))
+	return handler, nil
+}
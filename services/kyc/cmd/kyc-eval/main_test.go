@@ -0,0 +1,129 @@
+package main
+
+import (
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kyc/internal/config"
+	"kyc/internal/prompts"
+)
+
+// TestStubEvaluation replays testdata/dataset/answers.json through the stub
+// model. The answers misclassify one passport as an NID and one selfie as a
+// passport; the blank image has no answer and gets the stub's default.
+func TestStubEvaluation(t *testing.T) {
+	cfg := config.LoadConfig()
+	cfg.PromptDir = ""
+	report, err := run(cfg, "testdata/dataset", []string{prompts.DefaultClassify}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Outcomes) != 7 || len(report.Versions) != 1 {
+		t.Fatalf("%d outcomes, %d versions", len(report.Outcomes), len(report.Versions))
+	}
+	for _, o := range report.Outcomes {
+		if o.Error != "" || o.Provider == "" {
+			t.Errorf("%s: provider %q, error %q", o.Path, o.Provider, o.Error)
+		}
+	}
+
+	v := report.Versions[0]
+	if v.PromptVersion != prompts.DefaultClassify || v.Total != 7 || v.Correct != 5 || v.Errors != 0 {
+		t.Errorf("version %s: %d/%d correct, %d errors", v.PromptVersion, v.Correct, v.Total, v.Errors)
+	}
+	wantConfusion := map[string]map[string]int{
+		"IRRELEVANT": {"IRRELEVANT": 2, "PASSPORT": 1},
+		"NID":        {"NID": 2},
+		"PASSPORT":   {"PASSPORT": 1, "NID": 1},
+	}
+	if !maps.EqualFunc(v.Confusion, wantConfusion, maps.Equal) {
+		t.Errorf("confusion = %v, want %v", v.Confusion, wantConfusion)
+	}
+
+	wantClasses := []ClassMetrics{
+		{Class: "IRRELEVANT", Support: 3, TP: 2, FP: 0, FN: 1, Precision: 1, Recall: 2.0 / 3},
+		{Class: "NID", Support: 2, TP: 2, FP: 1, FN: 0, Precision: 2.0 / 3, Recall: 1},
+		{Class: "PASSPORT", Support: 2, TP: 1, FP: 1, FN: 1, Precision: 0.5, Recall: 0.5},
+	}
+	checkClasses(t, v.Classes, wantClasses)
+
+	dir := t.TempDir()
+	if err := writeReports(dir, report); err != nil {
+		t.Fatal(err)
+	}
+	md, err := os.ReadFile(filepath.Join(dir, "report.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Model: local stub",
+		"| PASSPORT | 2 | 0.500 | 0.500 | 0.500 |",
+		"| PASSPORT | 0 | 1 | 1 |",
+		"`PASSPORT/data-page-2.png`: expected PASSPORT, got NID",
+		"`IRRELEVANT/selfie.png`: expected IRRELEVANT, got PASSPORT",
+	} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("report.md lacks %q:\n%s", want, md)
+		}
+	}
+}
+
+func checkClasses(t *testing.T, got, want []ClassMetrics) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("classes = %+v, want %+v", got, want)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for i, w := range want {
+		g := got[i]
+		if w.F1 == 0 && w.Precision+w.Recall > 0 {
+			w.F1 = 2 * w.Precision * w.Recall / (w.Precision + w.Recall)
+		}
+		if g.Class != w.Class || g.Support != w.Support || g.TP != w.TP || g.FP != w.FP || g.FN != w.FN ||
+			!near(g.Precision, w.Precision) || !near(g.Recall, w.Recall) || !near(g.F1, w.F1) {
+			t.Errorf("class %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestLoadDataset(t *testing.T) {
+	samples, err := loadDataset("testdata/dataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 7 || samples[0] != (Sample{Path: "IRRELEVANT/blank.png", Expected: Irrelevant}) {
+		t.Errorf("samples = %+v", samples)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, answersFile), []byte("{}"), 0o644)
+	os.Mkdir(filepath.Join(dir, ".git"), 0o755)
+	os.WriteFile(filepath.Join(dir, ".git", "HEAD"), nil, 0o644)
+	if _, err := loadDataset(dir); err == nil {
+		t.Error("a dataset without class directories loaded")
+	}
+}
+
+func TestSaveAnswers(t *testing.T) {
+	dir := t.TempDir()
+	if answers, err := loadAnswers(dir); err != nil || len(answers) != 0 {
+		t.Fatalf("loadAnswers without a file = %v, %v", answers, err)
+	}
+	os.WriteFile(filepath.Join(dir, answersFile), []byte(`{"NID/a.png": "old", "NID/b.png": "kept"}`), 0o644)
+	err := saveAnswers(dir, []Outcome{
+		{Path: "NID/a.png", RawOutput: "new"},
+		{Path: "NID/c.png", Predicted: classError}, // No output to record
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	answers, err := loadAnswers(dir)
+	want := map[string]string{"NID/a.png": "new", "NID/b.png": "kept"}
+	if err != nil || !maps.Equal(answers, want) {
+		t.Errorf("answers = %v, %v, want %v", answers, err, want)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"kyc/internal/models"
+)
+
+// Classes predicted besides document types.
+const (
+	classManualReview = "MANUAL_REVIEW"
+	classError        = "ERROR"
+	classUnknownType  = "UNKNOWN_TYPE" // Accepted without a document type
+)
+
+// Outcome is the result of verifying one sample.
+type Outcome struct {
+	Path          string  `json:"path"`
+	Expected      string  `json:"expected"`
+	Predicted     string  `json:"predicted"`
+	PromptVersion string  `json:"prompt_version"`
+	Provider      string  `json:"provider,omitempty"`
+	Confidence    float64 `json:"confidence"`
+	LatencyMS     int64   `json:"latency_ms"`
+	RawOutput     string  `json:"raw_output,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// predictedClass maps a verification result to the class it predicts.
+func predictedClass(res *models.VerificationResult) string {
+	switch {
+	case res.Verdict == models.VerdictManualReview:
+		return classManualReview
+	case res.Verdict != models.VerdictValid:
+		return Irrelevant
+	case res.DocumentType == "":
+		return classUnknownType
+	default:
+		return res.DocumentType
+	}
+}
+
+// ClassMetrics are the one-vs-rest scores of one class. Precision and recall
+// are 0 when undefined.
+type ClassMetrics struct {
+	Class     string  `json:"class"`
+	Support   int     `json:"support"` // Samples expected in this class
+	TP        int     `json:"tp"`
+	FP        int     `json:"fp"`
+	FN        int     `json:"fn"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// VersionReport summarizes the outcomes of one prompt version.
+type VersionReport struct {
+	PromptVersion string                    `json:"prompt_version"`
+	Total         int                       `json:"total"`
+	Correct       int                       `json:"correct"`
+	Errors        int                       `json:"errors"`
+	Accuracy      float64                   `json:"accuracy"`
+	Classes       []ClassMetrics            `json:"classes"`
+	Labels        []string                  `json:"labels"`    // Rows and columns of the confusion matrix
+	Confusion     map[string]map[string]int `json:"confusion"` // expected -> predicted -> count
+}
+
+// promptFamily drops the document type from type-specific prompt IDs, so
+// that "classify-v3/PASSPORT" is reported with "classify-v3".
+func promptFamily(id string) string {
+	version, _, _ := strings.Cut(id, "/")
+	return version
+}
+
+// summarize groups outcomes by prompt version and scores each group.
+func summarize(outcomes []Outcome) []VersionReport {
+	groups := map[string][]Outcome{}
+	for _, o := range outcomes {
+		v := promptFamily(o.PromptVersion)
+		groups[v] = append(groups[v], o)
+	}
+	var reports []VersionReport
+	for v, group := range groups {
+		reports = append(reports, score(v, group))
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].PromptVersion < reports[j].PromptVersion })
+	return reports
+}
+
+func score(version string, outcomes []Outcome) VersionReport {
+	r := VersionReport{PromptVersion: version, Total: len(outcomes), Confusion: map[string]map[string]int{}}
+	labels := map[string]bool{}
+	for _, o := range outcomes {
+		labels[o.Expected], labels[o.Predicted] = true, true
+		if r.Confusion[o.Expected] == nil {
+			r.Confusion[o.Expected] = map[string]int{}
+		}
+		r.Confusion[o.Expected][o.Predicted]++
+		if o.Predicted == o.Expected {
+			r.Correct++
+		}
+		if o.Predicted == classError {
+			r.Errors++
+		}
+	}
+	if r.Total > 0 {
+		r.Accuracy = float64(r.Correct) / float64(r.Total)
+	}
+	for l := range labels {
+		r.Labels = append(r.Labels, l)
+	}
+	sort.Strings(r.Labels)
+
+	for _, class := range r.Labels {
+		m := ClassMetrics{Class: class}
+		for _, o := range outcomes {
+			switch {
+			case o.Expected == class && o.Predicted == class:
+				m.TP++
+			case o.Predicted == class:
+				m.FP++
+			case o.Expected == class:
+				m.FN++
+			}
+		}
+		m.Support = m.TP + m.FN
+		if m.Support == 0 {
+			continue // Only ever predicted, e.g. ERROR; visible in the matrix
+		}
+		if m.TP+m.FP > 0 {
+			m.Precision = float64(m.TP) / float64(m.TP+m.FP)
+		}
+		m.Recall = float64(m.TP) / float64(m.Support)
+		if m.Precision+m.Recall > 0 {
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+		r.Classes = append(r.Classes, m)
+	}
+	return r
+}
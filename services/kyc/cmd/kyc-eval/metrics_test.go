@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	"kyc/internal/models"
+)
+
+func TestPredictedClass(t *testing.T) {
+	tests := []struct {
+		res  models.VerificationResult
+		want string
+	}{
+		{models.VerificationResult{Verdict: models.VerdictValid, DocumentType: "NID"}, "NID"},
+		{models.VerificationResult{Verdict: models.VerdictValid}, classUnknownType},
+		{models.VerificationResult{Verdict: models.VerdictInvalid, DocumentType: "NID"}, Irrelevant},
+		{models.VerificationResult{Verdict: models.VerdictManualReview}, classManualReview},
+	}
+	for _, tt := range tests {
+		if got := predictedClass(&tt.res); got != tt.want {
+			t.Errorf("predictedClass(%s, %q) = %s, want %s", tt.res.Verdict, tt.res.DocumentType, got, tt.want)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	outcomes := []Outcome{
+		{Expected: "NID", Predicted: "NID"},
+		{Expected: "NID", Predicted: "NID"},
+		{Expected: "NID", Predicted: classError},
+		{Expected: "PASSPORT", Predicted: "NID"},
+		{Expected: "PASSPORT", Predicted: classManualReview},
+		{Expected: Irrelevant, Predicted: Irrelevant},
+	}
+	r := score("classify-v2", outcomes)
+	if r.Total != 6 || r.Correct != 3 || r.Errors != 1 || r.Accuracy != 0.5 {
+		t.Errorf("total %d, correct %d, errors %d, accuracy %v", r.Total, r.Correct, r.Errors, r.Accuracy)
+	}
+	wantLabels := []string{classError, Irrelevant, classManualReview, "NID", "PASSPORT"}
+	if len(r.Labels) != len(wantLabels) {
+		t.Fatalf("labels = %v, want %v", r.Labels, wantLabels)
+	}
+	for i := range wantLabels {
+		if r.Labels[i] != wantLabels[i] {
+			t.Errorf("labels = %v, want %v", r.Labels, wantLabels)
+			break
+		}
+	}
+	if r.Confusion["NID"][classError] != 1 || r.Confusion["PASSPORT"]["NID"] != 1 || r.Confusion[classError] != nil {
+		t.Errorf("confusion = %v", r.Confusion)
+	}
+
+	// ERROR and MANUAL_REVIEW are only predicted, so they have no metrics.
+	checkClasses(t, r.Classes, []ClassMetrics{
+		{Class: Irrelevant, Support: 1, TP: 1, Precision: 1, Recall: 1},
+		{Class: "NID", Support: 3, TP: 2, FP: 1, FN: 1, Precision: 2.0 / 3, Recall: 2.0 / 3},
+		{Class: "PASSPORT", Support: 2, FN: 2},
+	})
+
+	if r := score("empty", nil); r.Total != 0 || r.Accuracy != 0 || len(r.Classes) != 0 {
+		t.Errorf("empty = %+v", r)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	reports := summarize([]Outcome{
+		{Expected: "PASSPORT", Predicted: "PASSPORT", PromptVersion: "classify-v3/PASSPORT"},
+		{Expected: "NID", Predicted: Irrelevant, PromptVersion: "classify-v3"},
+		{Expected: "NID", Predicted: "NID", PromptVersion: "classify-v2"},
+		{Expected: "NID", Predicted: "NID", PromptVersion: "none"},
+	})
+	want := []struct {
+		version        string
+		total, correct int
+	}{
+		{"classify-v2", 1, 1},
+		{"classify-v3", 2, 1}, // The type-specific prompt counts with its family
+		{"none", 1, 1},
+	}
+	if len(reports) != len(want) {
+		t.Fatalf("%d reports, want %d", len(reports), len(want))
+	}
+	for i, w := range want {
+		r := reports[i]
+		if r.PromptVersion != w.version || r.Total != w.total || r.Correct != w.correct {
+			t.Errorf("report %d = %s %d/%d, want %s %d/%d", i, r.PromptVersion, r.Correct, r.Total, w.version, w.correct, w.total)
+		}
+	}
+}
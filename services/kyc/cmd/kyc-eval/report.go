@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Report is the result of one evaluation run.
+type Report struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Dataset     string          `json:"dataset"`
+	Verifier    string          `json:"verifier"`
+	Stub        bool            `json:"stub"`
+	Versions    []VersionReport `json:"versions"`
+	Outcomes    []Outcome       `json:"outcomes"`
+}
+
+// writeReports writes report.json and report.md to dir.
+func writeReports(dir string, report *Report) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "report.json"), append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "report.md"), []byte(markdown(report)), 0o644)
+}
+
+func markdown(report *Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# KYC verifier evaluation\n\n")
+	fmt.Fprintf(&b, "- Dataset: `%s`\n- Verifier: `%s`\n", report.Dataset, report.Verifier)
+	if report.Stub {
+		fmt.Fprintf(&b, "- Model: local stub with canned answers\n")
+	}
+	fmt.Fprintf(&b, "- Generated: %s\n", report.GeneratedAt.Format(time.RFC3339))
+
+	for _, v := range report.Versions {
+		fmt.Fprintf(&b, "\n## Prompt %s\n\n", v.PromptVersion)
+		fmt.Fprintf(&b, "Accuracy %.3f (%d/%d), %d errors.\n\n", v.Accuracy, v.Correct, v.Total, v.Errors)
+
+		b.WriteString("| Class | Support | Precision | Recall | F1 |\n|---|---:|---:|---:|---:|\n")
+		for _, c := range v.Classes {
+			fmt.Fprintf(&b, "| %s | %d | %.3f | %.3f | %.3f |\n", c.Class, c.Support, c.Precision, c.Recall, c.F1)
+		}
+
+		b.WriteString("\nConfusion matrix (rows expected, columns predicted):\n\n| |")
+		for _, l := range v.Labels {
+			fmt.Fprintf(&b, " %s |", l)
+		}
+		b.WriteString("\n|---|" + strings.Repeat("---:|", len(v.Labels)) + "\n")
+		for _, expected := range v.Labels {
+			row, ok := v.Confusion[expected]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&b, "| %s |", expected)
+			for _, predicted := range v.Labels {
+				fmt.Fprintf(&b, " %d |", row[predicted])
+			}
+			b.WriteString("\n")
+		}
+
+		var misses []string
+		for _, o := range report.Outcomes {
+			if promptFamily(o.PromptVersion) == v.PromptVersion && o.Predicted != o.Expected {
+				miss := fmt.Sprintf("- `%s`: expected %s, got %s", o.Path, o.Expected, o.Predicted)
+				if o.Error != "" {
+					miss += " (" + o.Error + ")"
+				}
+				misses = append(misses, miss)
+			}
+		}
+		if len(misses) > 0 {
+			b.WriteString("\nMisclassified:\n\n" + strings.Join(misses, "\n") + "\n")
+		}
+	}
+	return b.String()
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,9 +15,59 @@ type Config struct {
 	MongoURI            string
 	DBName              string
 	AuthServiceURL      string
+	AuthServiceToken    string // Bearer token for the auth service's /internal routes
 	HuggingFaceAPIKey   string
 	HuggingFaceModelURL string
 	HuggingFaceModelID  string
+	OpenAIBaseURL       string
+	OpenAIAPIKey        string
+	OpenAIModelID       string
+	VerifierChain       string
+	UploadMaxFileMB     int
+	UploadMaxFiles      int
+	UploadMaxPixels     int
+	UploadMaxDimension  int
+	UploadAllowPDF      bool
+	ModelMaxDimension   int
+	ResubmitCooldown    time.Duration
+	MaxKYCAttempts      int
+	NeedsInfoTTL        time.Duration
+	ExpiryCheckInterval time.Duration
+	ReviewClaimLease    time.Duration
+	ReviewSLA           time.Duration
+	DocumentURLSecret   string
+	DocumentURLTTL      time.Duration
+	DocumentWatermark   bool
+	OrphanUploadGrace   time.Duration
+	JanitorInterval     time.Duration
+	VerifyConcurrency   int
+	VerifyTimeout       time.Duration
+	AIMaxAttempts       int
+	AIAttemptTimeout    time.Duration
+	AICallTimeout       time.Duration
+	AIBreakerThreshold  int
+	AIBreakerCooldown   time.Duration
+	VerdictCacheTTL     time.Duration // 0 disables the cache
+	PromptDir           string
+	PromptClassify      string // Version, or an A/B split such as "classify-v3:90,classify-v4:10"
+	PromptExtract       string
+	PromptFaceMatch     string
+	FaceMatcher         string // none, stub, openai or huggingface
+	FaceMatchThreshold  float64
+	SelfieRequired      bool
+	ForensicsEnabled    bool
+	ForensicsHighRisk   float64 // Score from which a submission goes to fraud review
+	MalwareScanner      string  // none or clamd
+	ClamdAddress        string  // tcp://host:port or unix:///path
+	ClamdTimeout        time.Duration
+	QuarantineDir       string
+	WatchlistDir        string  // Sanctions and PEP list files, reloaded before each rescreen
+	ScreeningThreshold  float64 // Match score from which a watchlist entry is a hit
+	ScreeningInterval   time.Duration
+	TierConfig          string   // JSON file replacing the built-in KYC tiers
+	ServiceToken        string   // Bearer token of other services calling /internal
+	Reviewers           []string // User IDs allowed on /kyc/admin
+	ComplianceOfficers  []string // Reviewers who may resolve screening cases
 }
 
 func LoadConfig() *Config {
@@ -27,9 +80,59 @@ func LoadConfig() *Config {
 		MongoURI:            getEnv("MONGO_URI", "mongodb://localhost:27017"),
 		DBName:              getEnv("DB_NAME", "kyc_db"),
 		AuthServiceURL:      getEnv("AUTH_SERVICE_URL", "http://localhost:8080"),
+		AuthServiceToken:    getEnv("AUTH_SERVICE_TOKEN", ""),
 		HuggingFaceAPIKey:   getEnv("HUGGINGFACE_API_KEY", ""),
 		HuggingFaceModelURL: getEnv("HUGGINGFACE_ROUTER_URL", "https://router.huggingface.co/v1/chat/completions"),
 		HuggingFaceModelID:  getEnv("HUGGINGFACE_MODEL_ID", "google/gemma-3-27b-it:nebius"),
+		OpenAIBaseURL:       getEnv("OPENAI_BASE_URL", ""),
+		OpenAIAPIKey:        getEnv("OPENAI_API_KEY", ""),
+		OpenAIModelID:       getEnv("OPENAI_MODEL_ID", ""),
+		VerifierChain:       getEnv("VERIFIER_CHAIN", "huggingface,manual"),
+		UploadMaxFileMB:     getEnvInt("UPLOAD_MAX_FILE_MB", 10),
+		UploadMaxFiles:      getEnvInt("UPLOAD_MAX_FILES", 4),
+		UploadMaxPixels:     getEnvInt("UPLOAD_MAX_PIXELS", 40_000_000),
+		UploadMaxDimension:  getEnvInt("UPLOAD_MAX_DIMENSION", 4096),
+		UploadAllowPDF:      getEnv("UPLOAD_ALLOW_PDF", "false") == "true",
+		ModelMaxDimension:   getEnvInt("MODEL_MAX_DIMENSION", 1600),
+		ResubmitCooldown:    getEnvDuration("KYC_RESUBMIT_COOLDOWN", 24*time.Hour),
+		MaxKYCAttempts:      getEnvInt("KYC_MAX_ATTEMPTS", 3),
+		NeedsInfoTTL:        getEnvDuration("KYC_NEEDS_INFO_TTL", 14*24*time.Hour),
+		ExpiryCheckInterval: getEnvDuration("KYC_EXPIRY_CHECK_INTERVAL", time.Hour),
+		ReviewClaimLease:    getEnvDuration("KYC_REVIEW_CLAIM_LEASE", 15*time.Minute),
+		ReviewSLA:           getEnvDuration("KYC_REVIEW_SLA", 24*time.Hour),
+		DocumentURLSecret:   getEnv("DOCUMENT_URL_SECRET", ""),
+		DocumentURLTTL:      getEnvDuration("DOCUMENT_URL_TTL", 5*time.Minute),
+		DocumentWatermark:   getEnv("DOCUMENT_WATERMARK", "true") == "true",
+		OrphanUploadGrace:   getEnvDuration("UPLOAD_ORPHAN_GRACE", time.Hour),
+		JanitorInterval:     getEnvDuration("UPLOAD_JANITOR_INTERVAL", time.Hour),
+		VerifyConcurrency:   getEnvInt("VERIFY_CONCURRENCY", 3),
+		VerifyTimeout:       getEnvDuration("VERIFY_TIMEOUT", 90*time.Second),
+		AIMaxAttempts:       getEnvInt("AI_MAX_ATTEMPTS", 4),
+		AIAttemptTimeout:    getEnvDuration("AI_ATTEMPT_TIMEOUT", 30*time.Second),
+		AICallTimeout:       getEnvDuration("AI_CALL_TIMEOUT", 60*time.Second),
+		AIBreakerThreshold:  getEnvInt("AI_BREAKER_THRESHOLD", 5),
+		AIBreakerCooldown:   getEnvDuration("AI_BREAKER_COOLDOWN", 30*time.Second),
+		VerdictCacheTTL:     getEnvDuration("VERDICT_CACHE_TTL", 7*24*time.Hour),
+		PromptDir:           getEnv("PROMPT_DIR", ""),
+		PromptClassify:      getEnv("PROMPT_CLASSIFY", "classify-v3"),
+		PromptExtract:       getEnv("PROMPT_EXTRACT", "extract-v1"),
+		PromptFaceMatch:     getEnv("PROMPT_FACE_MATCH", "face-v1"),
+		FaceMatcher:         getEnv("FACE_MATCHER", "none"),
+		FaceMatchThreshold:  getEnvFloat("FACE_MATCH_THRESHOLD", 0.75),
+		SelfieRequired:      getEnv("SELFIE_REQUIRED", "false") == "true",
+		ForensicsEnabled:    getEnv("FORENSICS_ENABLED", "true") == "true",
+		ForensicsHighRisk:   getEnvFloat("FORENSICS_HIGH_RISK", 0.7),
+		MalwareScanner:      getEnv("MALWARE_SCANNER", "none"),
+		ClamdAddress:        getEnv("CLAMD_ADDRESS", "tcp://localhost:3310"),
+		ClamdTimeout:        getEnvDuration("CLAMD_TIMEOUT", 30*time.Second),
+		QuarantineDir:       getEnv("QUARANTINE_DIR", "quarantine"),
+		WatchlistDir:        getEnv("WATCHLIST_DIR", "watchlists"),
+		ScreeningThreshold:  getEnvFloat("SCREENING_THRESHOLD", 0.9),
+		ScreeningInterval:   getEnvDuration("SCREENING_INTERVAL", 24*time.Hour),
+		TierConfig:          getEnv("TIER_CONFIG", ""),
+		ServiceToken:        getEnv("SERVICE_API_TOKEN", ""),
+		Reviewers:           getEnvList("KYC_REVIEWERS"),
+		ComplianceOfficers:  getEnvList("KYC_COMPLIANCE_OFFICERS"),
 	}
 }
 
@@ -39,3 +142,53 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %g", key, value, fallback)
+		return fallback
+	}
+	return f
+}
+
+// getEnvList reads a comma-separated list, leaving out empty items.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
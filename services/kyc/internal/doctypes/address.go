@@ -0,0 +1,25 @@
+package doctypes
+
+// Proofs of address are not submitted on their own: the image goes in the
+// address_proof field of an identity document submission, with
+// address_proof_type naming one of these types.
+func init() {
+	Register(&DocumentType{
+		Code:         UtilityBill,
+		Country:      "*",
+		Name:         "Utility bill",
+		AddressProof: true,
+		Fields: []Field{
+			{Name: RoleAddressProof, Label: "Electricity, gas, water or internet bill", Kind: "file", Required: true, Hint: "Issued in the last 3 months, showing your name and address"},
+		},
+	})
+	Register(&DocumentType{
+		Code:         BankStatement,
+		Country:      "*",
+		Name:         "Bank statement",
+		AddressProof: true,
+		Fields: []Field{
+			{Name: RoleAddressProof, Label: "First page of a bank statement", Kind: "file", Required: true, Hint: "Issued in the last 3 months, showing your name and address"},
+		},
+	})
+}
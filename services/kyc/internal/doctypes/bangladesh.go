@@ -0,0 +1,108 @@
+package doctypes
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	digitsOnly = regexp.MustCompile(`^[0-9]+$`)
+
+	// Bangladesh passports: one or two letters followed by digits, nine
+	// characters in total (e.g. "EB0123456", e-passports "A01234567").
+	bdPassport = regexp.MustCompile(`^(?:[A-Z][0-9]{8}|[A-Z]{2}[0-9]{7})$`)
+	// ICAO 9303 allows up to nine alphanumeric characters in the MRZ field.
+	anyPassport = regexp.MustCompile(`^[A-Z0-9]{6,9}$`)
+
+	// BRTA smart card licences: two-letter district code, seven digits,
+	// licence class letter and a five-digit serial (e.g. "DK0123456C00001").
+	bdLicenseSmart = regexp.MustCompile(`^[A-Z]{2}[0-9]{7}[A-Z][0-9]{5}$`)
+	// Older handwritten licences: district code followed by a serial.
+	bdLicenseLegacy = regexp.MustCompile(`^[A-Z]{2}[0-9]{4,8}$`)
+)
+
+var dateOfBirthField = Field{Name: "date_of_birth", Label: "Date of birth", Kind: "date", Hint: "YYYY-MM-DD"}
+
+func init() {
+	Register(&DocumentType{
+		Code:    NID,
+		Country: "BD",
+		Name:    "Bangladesh National ID",
+		Fields: []Field{
+			{Name: "document_number", Label: "NID number", Kind: "text", Required: true, Pattern: `^[0-9]{10}$|^[0-9]{13}$|^[0-9]{17}$`, Hint: "10-digit smart card, 13-digit or 17-digit number"},
+			dateOfBirthField,
+			{Name: RoleFront, Label: "Front of the card", Kind: "file", Required: true},
+			{Name: RoleBack, Label: "Back of the card", Kind: "file", Required: true},
+		},
+		validate: validateBangladeshNID,
+	})
+	Register(&DocumentType{
+		Code:    Passport,
+		Country: "BD",
+		Name:    "Bangladesh Passport",
+		Fields: []Field{
+			{Name: "document_number", Label: "Passport number", Kind: "text", Required: true, Pattern: bdPassport.String(), Hint: "e.g. EB0123456"},
+			{Name: "mrz", Label: "Machine-readable zone", Kind: "text", Hint: "The two lines at the bottom of the data page"},
+			{Name: RoleDataPage, Label: "Passport data page", Kind: "file", Required: true},
+		},
+		validate: patternValidator("passport number must be 9 characters: 1-2 letters followed by digits", bdPassport),
+	})
+	Register(&DocumentType{
+		Code:    Passport,
+		Country: "*",
+		Name:    "Passport",
+		Fields: []Field{
+			{Name: "document_number", Label: "Passport number", Kind: "text", Required: true, Pattern: anyPassport.String()},
+			{Name: "mrz", Label: "Machine-readable zone", Kind: "text", Hint: "The two lines at the bottom of the data page"},
+			{Name: RoleDataPage, Label: "Passport data page", Kind: "file", Required: true},
+		},
+		validate: patternValidator("passport number must be 6-9 letters or digits", anyPassport),
+	})
+	Register(&DocumentType{
+		Code:    DrivingLicense,
+		Country: "BD",
+		Name:    "Bangladesh Driving Licence",
+		Fields: []Field{
+			{Name: "document_number", Label: "Licence number", Kind: "text", Required: true, Pattern: bdLicenseSmart.String() + "|" + bdLicenseLegacy.String(), Hint: "e.g. DK0123456C00001"},
+			dateOfBirthField,
+			{Name: RoleFront, Label: "Front of the licence", Kind: "file", Required: true},
+			{Name: RoleBack, Label: "Back of the licence", Kind: "file"},
+		},
+		validate: patternValidator("licence number must look like DK0123456C00001", bdLicenseSmart, bdLicenseLegacy),
+	})
+}
+
+// validateBangladeshNID accepts the three NID formats in use:
+//   - 10 digits: smart card NID.
+//   - 13 digits: paper NID (district, RMO, upazila, union/ward, serial).
+//   - 17 digits: the 13-digit number prefixed with the holder's birth year.
+//
+// For 17-digit numbers the birth year must be plausible and, when a date of
+// birth was given, equal to its year.
+func validateBangladeshNID(number, dateOfBirth string) error {
+	if !digitsOnly.MatchString(number) {
+		return errors.New("NID number must contain digits only")
+	}
+
+	switch len(number) {
+	case 10, 13:
+		return nil
+	case 17:
+		year, _ := strconv.Atoi(number[:4])
+		if year < 1900 || year > time.Now().Year() {
+			return fmt.Errorf("17-digit NID must start with a birth year, got %d", year)
+		}
+		if dateOfBirth != "" {
+			dob, _ := time.Parse("2006-01-02", dateOfBirth) // Format checked by ValidateNumber
+			if dob.Year() != year {
+				return fmt.Errorf("17-digit NID starts with %d but date of birth is in %d", year, dob.Year())
+			}
+		}
+		return nil
+	default:
+		return errors.New("NID number must have 10, 13 or 17 digits")
+	}
+}
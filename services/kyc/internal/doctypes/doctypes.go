@@ -0,0 +1,170 @@
+// Package doctypes is the registry of identity documents accepted for KYC,
+// with the form fields each one needs and rules for its document number.
+package doctypes
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"kyc/internal/matching"
+)
+
+// DefaultCountry is assumed when a submission does not name one.
+const DefaultCountry = "BD"
+
+// Document type codes, as stored in KYCRequest.Type.
+const (
+	NID            = "NID"
+	Passport       = "PASSPORT"
+	DrivingLicense = "DRIVING_LICENSE"
+	// Proofs of address, uploaded alongside an identity document.
+	UtilityBill   = "UTILITY_BILL"
+	BankStatement = "BANK_STATEMENT"
+)
+
+// Image roles: what an uploaded image shows. Each role is uploaded in the
+// form field of the same name; the file fields of a document type are the
+// roles it takes.
+const (
+	RoleFront        = "front"
+	RoleBack         = "back"
+	RoleDataPage     = "data_page" // Passport page with the photo and MRZ
+	RoleSelfie       = "selfie"
+	RoleAddressProof = "address_proof"
+)
+
+// Field describes one input of the KYC form for a document type.
+type Field struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Kind     string `json:"kind"` // text, date or file
+	Required bool   `json:"required"`
+	Pattern  string `json:"pattern,omitempty"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+// NumberValidator checks a normalized document number. dateOfBirth is
+// YYYY-MM-DD or empty when the user did not provide it.
+type NumberValidator func(number, dateOfBirth string) error
+
+// DocumentType is one entry of the registry.
+type DocumentType struct {
+	Code    string  `json:"code"`
+	Country string  `json:"country"` // ISO 3166-1 alpha-2, or "*" for any country
+	Name    string  `json:"name"`
+	Fields  []Field `json:"fields"`
+	// AddressProof marks documents that prove the holder's address rather
+	// than their identity, e.g. utility bills.
+	AddressProof bool `json:"address_proof,omitempty"`
+
+	validate NumberValidator
+}
+
+var registry []*DocumentType
+
+// Register adds a document type. It is meant to be called from init.
+func Register(d *DocumentType) {
+	registry = append(registry, d)
+}
+
+// All returns every registered document type.
+func All() []*DocumentType {
+	return slices.Clone(registry)
+}
+
+// Lookup returns the document type for the given code and country. A
+// country-specific entry wins over a "*" entry.
+func Lookup(code, country string) (*DocumentType, bool) {
+	code, country = strings.ToUpper(code), strings.ToUpper(country)
+	if country == "" {
+		country = DefaultCountry
+	}
+	var fallback *DocumentType
+	for _, d := range registry {
+		if d.Code != code {
+			continue
+		}
+		if d.Country == country {
+			return d, true
+		}
+		if d.Country == "*" {
+			fallback = d
+		}
+	}
+	return fallback, fallback != nil
+}
+
+// Roles returns the image roles the document type takes, in form order.
+func (d *DocumentType) Roles() []Field {
+	var roles []Field
+	for _, f := range d.Fields {
+		if f.Kind == "file" {
+			roles = append(roles, f)
+		}
+	}
+	return roles
+}
+
+// MissingRoles returns the required roles that are not in present.
+func (d *DocumentType) MissingRoles(present []string) []string {
+	var missing []string
+	for _, f := range d.Roles() {
+		if f.Required && !slices.Contains(present, f.Name) {
+			missing = append(missing, f.Name)
+		}
+	}
+	return missing
+}
+
+// Known reports whether code is registered for any country.
+func Known(code string) bool {
+	return slices.ContainsFunc(registry, func(d *DocumentType) bool { return d.Code == code })
+}
+
+// ErrUnsupportedType is returned for a type/country pair that is not registered.
+var ErrUnsupportedType = errors.New("unsupported document type")
+
+// ValidateNumber normalizes the document number and checks it against the
+// rules of the document type. It returns the normalized number.
+func (d *DocumentType) ValidateNumber(number, dateOfBirth string) (string, error) {
+	normalized := matching.NormalizeDocumentNumber(number)
+	if normalized == "" {
+		return "", errors.New("document number is required")
+	}
+	if dateOfBirth != "" {
+		if _, err := time.Parse("2006-01-02", dateOfBirth); err != nil {
+			return "", errors.New("date of birth must be YYYY-MM-DD")
+		}
+	}
+	if d.validate != nil {
+		if err := d.validate(normalized, dateOfBirth); err != nil {
+			return "", err
+		}
+	}
+	return normalized, nil
+}
+
+// Validate looks up the document type and validates the number in one step.
+func Validate(code, country, number, dateOfBirth string) (*DocumentType, string, error) {
+	d, ok := Lookup(code, country)
+	if !ok {
+		return nil, "", ErrUnsupportedType
+	}
+	normalized, err := d.ValidateNumber(number, dateOfBirth)
+	return d, normalized, err
+}
+
+// patternValidator accepts numbers matching any of the patterns.
+func patternValidator(message string, patterns ...*regexp.Regexp) NumberValidator {
+	return func(number, _ string) error {
+		for _, p := range patterns {
+			if p.MatchString(number) {
+				return nil
+			}
+		}
+		return errors.New(message)
+	}
+}
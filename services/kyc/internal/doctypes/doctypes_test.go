@@ -0,0 +1,154 @@
+package doctypes
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestValidateNumber(t *testing.T) {
+	nextYear := strconv.Itoa(time.Now().Year() + 1)
+	tests := []struct {
+		code, country string
+		number, dob   string
+		want          string // Normalized number; "" when invalid
+	}{
+		// NID: 10, 13 or 17 digits.
+		{NID, "BD", "1234567890", "", "1234567890"},
+		{NID, "BD", "123 456 7890", "", "1234567890"},
+		{NID, "BD", "১২৩৪৫৬৭৮৯০", "", "1234567890"},
+		{NID, "BD", "1234567890123", "", "1234567890123"},
+		{NID, "BD", "19901234567890123", "", "19901234567890123"},
+		{NID, "BD", "19901234567890123", "1990-05-17", "19901234567890123"},
+		{NID, "BD", "19901234567890123", "1991-05-17", ""},
+		{NID, "BD", "18991234567890123", "", ""},
+		{NID, "BD", nextYear + "1234567890123", "", ""},
+		{NID, "BD", "123456789", "", ""},
+		{NID, "BD", "12345678901", "", ""},
+		{NID, "BD", "123456789012345678", "", ""},
+		{NID, "BD", "12345A7890", "", ""},
+		{NID, "BD", "1234567890", "17-05-1990", ""},
+		// Bangladesh passport: nine characters, 1-2 letters then digits.
+		{Passport, "BD", "EB0123456", "", "EB0123456"},
+		{Passport, "BD", "A01234567", "", "A01234567"},
+		{Passport, "BD", "eb 012 3456", "", "EB0123456"},
+		{Passport, "BD", "A1234567", "", ""},
+		{Passport, "BD", "AB12345678", "", ""},
+		{Passport, "BD", "ABC123456", "", ""},
+		{Passport, "BD", "123456789", "", ""},
+		// Other passports: ICAO document number.
+		{Passport, "IN", "K1234567", "", "K1234567"},
+		{Passport, "GB", "123456", "", "123456"},
+		{Passport, "GB", "12345", "", ""},
+		{Passport, "GB", "1234567890", "", ""},
+		// Driving licence: smart card or legacy.
+		{DrivingLicense, "BD", "DK0123456C00001", "", "DK0123456C00001"},
+		{DrivingLicense, "BD", "dk-0123456-c-00001", "", "DK0123456C00001"},
+		{DrivingLicense, "BD", "DK1234", "", "DK1234"},
+		{DrivingLicense, "BD", "DK12345678", "", "DK12345678"},
+		{DrivingLicense, "BD", "DK123", "", ""},
+		{DrivingLicense, "BD", "D0123456C00001", "", ""},
+		{DrivingLicense, "BD", "DK0123456C0001", "", ""},
+		// Missing number.
+		{NID, "BD", " - ", "", ""},
+	}
+	for _, tt := range tests {
+		_, got, err := Validate(tt.code, tt.country, tt.number, tt.dob)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("Validate(%s, %s, %q, %q) = %q, want an error", tt.code, tt.country, tt.number, tt.dob, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Validate(%s, %s, %q, %q) = %q, %v, want %q", tt.code, tt.country, tt.number, tt.dob, got, err, tt.want)
+		}
+	}
+}
+
+// TestFieldPatterns checks that the pattern sent to clients agrees with the
+// server-side validation of the number field.
+func TestFieldPatterns(t *testing.T) {
+	numbers := []string{"1234567890", "1234567890123", "EB0123456", "A01234567", "A1234567", "AB12345678", "DK0123456C00001", "DK1234", "K1234567"}
+	for _, d := range All() {
+		for _, f := range d.Fields {
+			if f.Name != "document_number" || f.Pattern == "" {
+				continue
+			}
+			pattern := regexp.MustCompile("^(?:" + f.Pattern + ")$")
+			for _, n := range numbers {
+				if d.Code == NID && len(n) == 17 {
+					continue // The birth year rule is not expressible as a pattern
+				}
+				_, err := d.ValidateNumber(n, "")
+				if pattern.MatchString(n) != (err == nil) {
+					t.Errorf("%s/%s: pattern match %v for %q, validation error %v", d.Code, d.Country, pattern.MatchString(n), n, err)
+				}
+			}
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		code, country string
+		wantCountry   string
+		wantOK        bool
+	}{
+		{"NID", "BD", "BD", true},
+		{"nid", "bd", "BD", true},
+		{"NID", "", "BD", true},
+		{"NID", "IN", "", false},
+		{"PASSPORT", "BD", "BD", true},
+		{"PASSPORT", "IN", "*", true},
+		{"DRIVING_LICENSE", "BD", "BD", true},
+		{"DRIVING_LICENSE", "GB", "", false},
+		{"UTILITY_BILL", "BD", "*", true},
+		{"BANK_STATEMENT", "IN", "*", true},
+		{"VISA", "BD", "", false},
+	}
+	for _, tt := range tests {
+		d, ok := Lookup(tt.code, tt.country)
+		if ok != tt.wantOK || (ok && d.Country != tt.wantCountry) {
+			t.Errorf("Lookup(%q, %q) = %v, %v, want country %q, %v", tt.code, tt.country, d, ok, tt.wantCountry, tt.wantOK)
+		}
+	}
+
+	if _, _, err := Validate("VISA", "BD", "V123", ""); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Validate(VISA) err = %v, want ErrUnsupportedType", err)
+	}
+	if !Known(Passport) || Known("VISA") {
+		t.Error("Known disagrees with the registry")
+	}
+}
+
+func TestRoles(t *testing.T) {
+	tests := []struct {
+		code        string
+		roles       []string
+		present     []string
+		wantMissing []string
+	}{
+		{NID, []string{RoleFront, RoleBack}, []string{RoleFront}, []string{RoleBack}},
+		{NID, []string{RoleFront, RoleBack}, []string{RoleBack, RoleFront}, nil},
+		{Passport, []string{RoleDataPage}, nil, []string{RoleDataPage}},
+		{DrivingLicense, []string{RoleFront, RoleBack}, []string{RoleFront}, nil}, // The back is optional
+		{UtilityBill, []string{RoleAddressProof}, nil, []string{RoleAddressProof}},
+	}
+	for _, tt := range tests {
+		d, _ := Lookup(tt.code, "BD")
+		var roles []string
+		for _, f := range d.Roles() {
+			roles = append(roles, f.Name)
+		}
+		if !slices.Equal(roles, tt.roles) {
+			t.Errorf("%s: roles %v, want %v", tt.code, roles, tt.roles)
+		}
+		if got := d.MissingRoles(tt.present); !slices.Equal(got, tt.wantMissing) {
+			t.Errorf("%s: MissingRoles(%v) = %v, want %v", tt.code, tt.present, got, tt.wantMissing)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package forensics
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"sort"
+)
+
+const (
+	elaQuality   = 90
+	elaBlockSize = 16
+)
+
+// errorLevel re-compresses img and measures, per block, how much the luma
+// changes. A photo compressed once loses about the same everywhere; a
+// region pasted in from another source, or retouched after compression,
+// changes more than the rest. The error is divided by the block's own
+// texture so that text and edges, which always change more, do not count
+// as inconsistent. It returns the worst block error (ignoring the top 0.1%)
+// over the median, and the worst raw block error in luma levels.
+func errorLevel(img image.Image) (ratio, worst float64, err error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: elaQuality}); err != nil {
+		return 0, 0, err
+	}
+	again, err := jpeg.Decode(&buf)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	before, after := lumaPlane(img), lumaPlane(again)
+	var errs, raw []float64
+	for by := 0; by+elaBlockSize <= h; by += elaBlockSize {
+		for bx := 0; bx+elaBlockSize <= w; bx += elaBlockSize {
+			var diff, sum, sumSq float64
+			for y := by; y < by+elaBlockSize; y++ {
+				for x := bx; x < bx+elaBlockSize; x++ {
+					l := before[y*w+x]
+					diff += math.Abs(l - after[y*w+x])
+					sum += l
+					sumSq += l * l
+				}
+			}
+			n := float64(elaBlockSize * elaBlockSize)
+			mean := diff / n
+			sd := math.Sqrt(math.Max(0, sumSq/n-(sum/n)*(sum/n)))
+			raw = append(raw, mean)
+			errs = append(errs, mean*16/(sd+16))
+		}
+	}
+	if len(errs) < 16 {
+		return 0, 0, nil
+	}
+	sort.Float64s(errs)
+	sort.Float64s(raw)
+	top := len(errs) - 1 - len(errs)/1000
+	return errs[top] / math.Max(errs[len(errs)/2], 0.5), raw[top], nil
+}
+
+// lumaPlane returns the brightness of every pixel, row by row.
+func lumaPlane(img image.Image) []float64 {
+	b := img.Bounds()
+	out := make([]float64, 0, b.Dx()*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			out = append(out, luma(img, x, y))
+		}
+	}
+	return out
+}
+
+// luma returns the brightness of a pixel, reading the Y plane directly when
+// the image is a decoded JPEG.
+func luma(img image.Image, x, y int) float64 {
+	switch img := img.(type) {
+	case *image.YCbCr:
+		return float64(img.Y[img.YOffset(x, y)])
+	case *image.Gray:
+		return float64(img.GrayAt(x, y).Y)
+	}
+	return float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+}
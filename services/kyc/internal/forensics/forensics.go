@@ -0,0 +1,181 @@
+// Package forensics looks for signs that a document image was edited or is
+// a picture of a screen: editing software in the metadata, screenshot
+// dimensions, moiré, inconsistent JPEG error levels and heavy compression.
+//
+// It works on the file as uploaded, before the upload policy re-encodes it
+// and drops its metadata. Every signal is a heuristic with false positives;
+// the combined score is meant to route a submission to a reviewer, not to
+// reject it.
+package forensics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"math"
+	"strings"
+
+	"kyc/internal/models"
+	"kyc/internal/uploads"
+)
+
+// Weights of the signals in the combined score.
+const (
+	weightEditingSoftware  = 0.6
+	weightScreenshot       = 0.65
+	weightScreenMoire      = 0.5
+	weightELAInconsistent  = 0.45
+	weightHeavyCompression = 0.25
+	weightLowResolution    = 0.2
+	weightNoCameraMetadata = 0.15
+)
+
+// Thresholds of the individual signals.
+const (
+	minDocumentSide   = 480        // Shorter side of a readable document photo
+	minJPEGQuality    = 60         // Estimated from the quantization tables
+	maxELARatio       = 3.0        // Worst block error over the median block error
+	minELAError       = 2.5        // Worst block error worth reporting, in luma levels
+	elaMaxPixels      = 16_000_000 // Larger images take seconds to re-compress
+	maxMoireRatio     = 3.5        // Strongest spectral peak over its neighbourhood
+	moireDimension    = 512
+	minMoireDimension = 64
+)
+
+var ErrUnsupported = errors.New("forensic analysis needs a JPEG or PNG image")
+
+// Analyzer combines the signals of an image into a risk level.
+type Analyzer struct {
+	HighRisk float64 // Score from which an image is high risk; half of it is medium
+}
+
+// Analyze runs every check on an uploaded image. HEIC and PDF files are not
+// analysed and return ErrUnsupported.
+func (a Analyzer) Analyze(data []byte) (*models.Forensics, error) {
+	kind, ok := uploads.Sniff(data)
+	if !ok || (kind != uploads.KindJPEG && kind != uploads.KindPNG) {
+		return nil, ErrUnsupported
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image cannot be decoded: %w", err)
+	}
+
+	f := &models.Forensics{Format: string(kind), Width: cfg.Width, Height: cfg.Height}
+	meta := readMetadata(data, kind)
+	f.Software, f.Camera, f.JPEGQuality = meta.software, meta.camera, meta.quality
+
+	if editor := editingSoftware(meta.software); editor != "" {
+		f.Signals = append(f.Signals, models.ForensicSignal{Name: models.ForensicEditingSoftware, Score: weightEditingSoftware, Detail: meta.software})
+	}
+	if f.Camera == "" {
+		f.Signals = append(f.Signals, models.ForensicSignal{Name: models.ForensicNoCameraMetadata, Score: weightNoCameraMetadata})
+	}
+	switch {
+	case meta.screenshot:
+		f.Signals = append(f.Signals, models.ForensicSignal{Name: models.ForensicScreenshot, Score: weightScreenshot, Detail: "marked as a screenshot in the metadata"})
+	case f.Camera == "" && isScreenSize(cfg.Width, cfg.Height):
+		f.Signals = append(f.Signals, models.ForensicSignal{Name: models.ForensicScreenshot, Score: weightScreenshot, Detail: fmt.Sprintf("%dx%d is a common screen size", cfg.Width, cfg.Height)})
+	}
+	if min(cfg.Width, cfg.Height) < minDocumentSide {
+		f.Signals = append(f.Signals, models.ForensicSignal{Name: models.ForensicLowResolution, Score: weightLowResolution, Detail: fmt.Sprintf("%dx%d", cfg.Width, cfg.Height)})
+	}
+	if f.JPEGQuality > 0 && f.JPEGQuality < minJPEGQuality {
+		f.Signals = append(f.Signals, models.ForensicSignal{Name: models.ForensicHeavyCompression, Score: weightHeavyCompression, Detail: fmt.Sprintf("estimated quality %d", f.JPEGQuality)})
+	}
+
+	if kind == uploads.KindJPEG && cfg.Width*cfg.Height <= elaMaxPixels {
+		// Decoded without applying the EXIF orientation, so that blocks line
+		// up with the 8x8 grid the file was compressed on.
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("image cannot be decoded: %w", err)
+		}
+		ratio, worst, err := errorLevel(img)
+		if err != nil {
+			return nil, err
+		}
+		f.ELARatio = round2(ratio)
+		if ratio > maxELARatio && worst > minELAError {
+			f.Signals = append(f.Signals, models.ForensicSignal{Name: models.ForensicELAInconsistent, Score: weightELAInconsistent, Detail: fmt.Sprintf("error level ratio %.1f", ratio)})
+		}
+	}
+
+	img, err := uploads.DecodeForAnalysis(data, moireDimension)
+	if err != nil {
+		return nil, fmt.Errorf("image cannot be decoded: %w", err)
+	}
+	if ratio, ok := moireRatio(img); ok {
+		f.MoireRatio = round2(ratio)
+		if ratio > maxMoireRatio {
+			f.Signals = append(f.Signals, models.ForensicSignal{Name: models.ForensicScreenMoire, Score: weightScreenMoire, Detail: fmt.Sprintf("periodic pattern ratio %.1f", ratio)})
+		}
+	}
+
+	f.Score, f.Risk = a.risk(f.Signals)
+	return f, nil
+}
+
+// risk combines independent signals: each one removes its share of the
+// remaining doubt, so the score stays below 1 and grows with every signal.
+func (a Analyzer) risk(signals []models.ForensicSignal) (float64, string) {
+	clean := 1.0
+	for _, s := range signals {
+		clean *= 1 - s.Score
+	}
+	score := round2(1 - clean)
+	switch {
+	case score >= a.HighRisk:
+		return score, models.RiskHigh
+	case score >= a.HighRisk/2:
+		return score, models.RiskMedium
+	default:
+		return score, models.RiskLow
+	}
+}
+
+// editors are substrings of the software names of image editors, lower case.
+var editors = []string{
+	"photoshop", "lightroom", "illustrator", "gimp", "krita", "inkscape", "paint.net",
+	"pixelmator", "affinity", "photopea", "snapseed", "picsart", "canva", "fotor",
+	"facetune", "meitu", "polarr", "vsco", "photodirector", "photoscape",
+}
+
+// editingSoftware returns the editor named in software, or "".
+func editingSoftware(software string) string {
+	s := strings.ToLower(software)
+	for _, e := range editors {
+		if strings.Contains(s, e) {
+			return e
+		}
+	}
+	return ""
+}
+
+// screenSizes are native resolutions of common phones and monitors, in
+// portrait orientation.
+var screenSizes = [][2]int{
+	{750, 1334}, {828, 1792}, {1125, 2436}, {1170, 2532}, {1179, 2556}, {1242, 2688},
+	{1284, 2778}, {1290, 2796}, {720, 1600}, {1080, 1920}, {1080, 2160}, {1080, 2340},
+	{1080, 2400}, {1080, 2412}, {1440, 2960}, {1440, 3120}, {1440, 3200},
+	{768, 1366}, {864, 1536}, {900, 1440}, {1440, 2560}, {1600, 2560},
+	{1800, 2880}, {2160, 3840},
+}
+
+func isScreenSize(w, h int) bool {
+	if w > h {
+		w, h = h, w
+	}
+	for _, s := range screenSizes {
+		if s[0] == w && s[1] == h {
+			return true
+		}
+	}
+	return false
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
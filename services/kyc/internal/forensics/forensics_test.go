@@ -0,0 +1,279 @@
+package forensics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"kyc/internal/models"
+)
+
+// photo returns a smooth gradient with mild sensor-like noise, the same on
+// every run.
+func photo(w, h int) *image.RGBA {
+	rng := rand.New(rand.NewSource(int64(w*h + 1)))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := 60 + 120*float64(x)/float64(w) + 40*float64(y)/float64(h) + rng.NormFloat64()*4
+			c := uint8(max(0, min(255, v)))
+			img.SetRGBA(x, y, color.RGBA{c, uint8(int(c) * 9 / 10), uint8(int(c) * 8 / 10), 255})
+		}
+	}
+	return img
+}
+
+// screen adds the fine periodic stripes a photographed pixel grid leaves.
+func screen(img *image.RGBA, period float64) *image.RGBA {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			d := 30 * math.Sin(2*math.Pi*float64(x)/period)
+			c := img.RGBAAt(x, y)
+			shift := func(v uint8) uint8 { return uint8(max(0, min(255, float64(v)+d))) }
+			img.SetRGBA(x, y, color.RGBA{shift(c.R), shift(c.G), shift(c.B), 255})
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t testing.TB, img image.Image, quality int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t testing.TB, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func app1(payload []byte) []byte {
+	seg := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// withSegments inserts segments right after the JPEG start of image marker.
+func withSegments(jpg []byte, segs ...[]byte) []byte {
+	out := bytes.Clone(jpg[:2])
+	for _, s := range segs {
+		out = append(out, s...)
+	}
+	return append(out, jpg[2:]...)
+}
+
+func cameraEXIF(software string) []byte {
+	entries := []tiffEntry{{tagMake, "Apple"}, {tagModel, "iPhone 13"}}
+	if software != "" {
+		entries = append(entries, tiffEntry{tagSoftware, software})
+	}
+	return app1(append([]byte("Exif\x00\x00"), buildTIFF(binary.LittleEndian, entries...)...))
+}
+
+// spliced returns a JPEG whose background was saved at the error level
+// quality before a patch was pasted in and the whole image saved again.
+func spliced(t *testing.T) []byte {
+	base, err := jpeg.Decode(bytes.NewReader(encodeJPEG(t, photo(640, 480), elaQuality)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(base.Bounds())
+	draw.Draw(img, img.Bounds(), base, image.Point{}, draw.Src)
+	patch := image.NewRGBA(image.Rect(0, 0, 96, 48))
+	rng := rand.New(rand.NewSource(7))
+	for i := range patch.Pix {
+		patch.Pix[i] = uint8(100 + rng.Intn(40))
+		if i%4 == 3 {
+			patch.Pix[i] = 255
+		}
+	}
+	draw.Draw(img, image.Rect(256, 208, 352, 256), patch, image.Point{}, draw.Src)
+	return encodeJPEG(t, img, 98)
+}
+
+func signalNames(f *models.Forensics) []string {
+	var names []string
+	for _, s := range f.Signals {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestAnalyzeSignals(t *testing.T) {
+	camera := cameraEXIF("")
+	tests := []struct {
+		name string
+		data []byte
+		want []string
+	}{
+		{"camera photo", withSegments(encodeJPEG(t, photo(640, 480), 92), camera), nil},
+		{"no camera metadata", encodePNG(t, photo(640, 480)), []string{models.ForensicNoCameraMetadata}},
+		{"editor", withSegments(encodeJPEG(t, photo(640, 480), 92), cameraEXIF("Adobe Photoshop 25.0")), []string{models.ForensicEditingSoftware}},
+		{"screenshot metadata", withSegments(encodeJPEG(t, photo(640, 480), 92), app1(append(bytes.Clone(xmpPrefix), "<dc:description>Screenshot</dc:description>"...))),
+			[]string{models.ForensicNoCameraMetadata, models.ForensicScreenshot}},
+		{"screen size", encodePNG(t, photo(1080, 1920)), []string{models.ForensicNoCameraMetadata, models.ForensicScreenshot}},
+		{"screen size with camera", withSegments(encodeJPEG(t, photo(1920, 1080), 92), camera), nil},
+		{"low resolution", withSegments(encodeJPEG(t, photo(400, 300), 92), camera), []string{models.ForensicLowResolution}},
+		{"heavy compression", withSegments(encodeJPEG(t, photo(640, 480), 35), camera), []string{models.ForensicHeavyCompression}},
+		{"screen moire", withSegments(encodeJPEG(t, screen(photo(640, 480), 3.3), 92), camera), []string{models.ForensicScreenMoire}},
+		{"spliced", withSegments(spliced(t), camera), []string{models.ForensicELAInconsistent}},
+	}
+	a := Analyzer{HighRisk: 0.6}
+	for _, tt := range tests {
+		f, err := a.Analyze(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := signalNames(f); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: signals %v, want %v (ELA %.2f, moiré %.2f)", tt.name, got, tt.want, f.ELARatio, f.MoireRatio)
+		}
+		if score, risk := a.risk(f.Signals); f.Score != score || f.Risk != risk {
+			t.Errorf("%s: score %v %s, want %v %s", tt.name, f.Score, f.Risk, score, risk)
+		}
+	}
+}
+
+func TestAnalyzeReport(t *testing.T) {
+	data := withSegments(encodeJPEG(t, photo(640, 480), 80), cameraEXIF("Adobe Photoshop 25.0"))
+	f, err := Analyzer{HighRisk: 0.6}.Analyze(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Format != "jpeg" || f.Width != 640 || f.Height != 480 || f.Camera != "Apple iPhone 13" || f.Software != "Adobe Photoshop 25.0" {
+		t.Errorf("got %+v", f)
+	}
+	if f.JPEGQuality < 78 || f.JPEGQuality > 82 || f.ELARatio == 0 || f.MoireRatio == 0 {
+		t.Errorf("quality %d, ELA %v, moiré %v", f.JPEGQuality, f.ELARatio, f.MoireRatio)
+	}
+	if f.Score != weightEditingSoftware || f.Risk != models.RiskHigh {
+		t.Errorf("score %v, risk %s", f.Score, f.Risk)
+	}
+}
+
+func TestAnalyzeUnsupported(t *testing.T) {
+	a := Analyzer{HighRisk: 0.6}
+	for name, data := range map[string][]byte{
+		"pdf":   []byte("%PDF-1.7\n"),
+		"heic":  append([]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), "mif1heic"...),
+		"text":  []byte("hello"),
+		"empty": nil,
+	} {
+		if _, err := a.Analyze(data); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%s: err = %v, want ErrUnsupported", name, err)
+		}
+	}
+	jpg := encodeJPEG(t, photo(64, 64), 90)
+	if _, err := a.Analyze(jpg[:len(jpg)/2]); err == nil || errors.Is(err, ErrUnsupported) {
+		t.Errorf("truncated jpeg: err = %v", err)
+	}
+}
+
+func TestRisk(t *testing.T) {
+	sig := func(scores ...float64) []models.ForensicSignal {
+		var s []models.ForensicSignal
+		for _, v := range scores {
+			s = append(s, models.ForensicSignal{Score: v})
+		}
+		return s
+	}
+	tests := []struct {
+		signals []models.ForensicSignal
+		score   float64
+		risk    string
+	}{
+		{nil, 0, models.RiskLow},
+		{sig(weightNoCameraMetadata), 0.15, models.RiskLow},
+		{sig(0.3), 0.3, models.RiskMedium},
+		{sig(weightEditingSoftware), 0.6, models.RiskHigh},
+		{sig(weightNoCameraMetadata, weightLowResolution), 0.32, models.RiskMedium},
+		{sig(weightEditingSoftware, weightScreenshot), 0.86, models.RiskHigh},
+		{sig(0.9, 0.9, 0.9, 0.9), 1, models.RiskHigh},
+	}
+	a := Analyzer{HighRisk: 0.6}
+	for _, tt := range tests {
+		score, risk := a.risk(tt.signals)
+		if score != tt.score || risk != tt.risk {
+			t.Errorf("risk(%v) = %v, %s, want %v, %s", tt.signals, score, risk, tt.score, tt.risk)
+		}
+	}
+}
+
+func TestEditingSoftware(t *testing.T) {
+	tests := map[string]string{
+		"Adobe Photoshop Lightroom Classic 13.0": "photoshop",
+		"GIMP 2.10.36":                           "gimp",
+		"Picsart":                                "picsart",
+		"iOS 17.1":                               "",
+		"HDR+ 1.0.540104767zd":                   "",
+		"":                                       "",
+	}
+	for software, want := range tests {
+		if got := editingSoftware(software); got != want {
+			t.Errorf("editingSoftware(%q) = %q, want %q", software, got, want)
+		}
+	}
+}
+
+func TestIsScreenSize(t *testing.T) {
+	tests := []struct {
+		w, h int
+		want bool
+	}{
+		{1170, 2532, true},
+		{2532, 1170, true},
+		{1920, 1080, true},
+		{1080, 1921, false},
+		{3024, 4032, false},
+	}
+	for _, tt := range tests {
+		if got := isScreenSize(tt.w, tt.h); got != tt.want {
+			t.Errorf("isScreenSize(%d, %d) = %v, want %v", tt.w, tt.h, got, tt.want)
+		}
+	}
+}
+
+func FuzzAnalyze(f *testing.F) {
+	jpg := encodeJPEG(f, photo(96, 64), 75)
+	f.Add(jpg)
+	f.Add(withSegments(jpg, cameraEXIF("Adobe Photoshop 25.0")))
+	f.Add(withSegments(jpg, app1(append(bytes.Clone(xmpPrefix), "<xmp:CreatorTool>GIMP</xmp:CreatorTool>"...))))
+	pngData := encodePNG(f, photo(96, 64))
+	f.Add(pngData)
+	f.Add(withChunks(pngData, pngChunk("eXIf", buildTIFF(binary.BigEndian, tiffEntry{tagMake, "Google"})), pngChunk("tEXt", []byte("Software\x00GIMP"))))
+	f.Add([]byte("%PDF-1.7\n"))
+
+	a := Analyzer{HighRisk: 0.6}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Uploads are checked against the pixel limit before analysis.
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil && cfg.Width*cfg.Height > 4_000_000 {
+			return
+		}
+		report, err := a.Analyze(data)
+		if err != nil {
+			return
+		}
+		if report.Score < 0 || report.Score > 1 {
+			t.Errorf("score %v out of range", report.Score)
+		}
+		if report.Risk != models.RiskLow && report.Risk != models.RiskMedium && report.Risk != models.RiskHigh {
+			t.Errorf("risk %q", report.Risk)
+		}
+	})
+}
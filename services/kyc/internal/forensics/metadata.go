@@ -0,0 +1,229 @@
+package forensics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"regexp"
+	"strings"
+
+	"kyc/internal/uploads"
+)
+
+type metadata struct {
+	software   string
+	camera     string
+	screenshot bool
+	quality    int // Estimated JPEG quality, 0 if unknown
+}
+
+// EXIF tags read from the first IFD.
+const (
+	tagMake     = 0x010F
+	tagModel    = 0x0110
+	tagSoftware = 0x0131
+)
+
+var (
+	xmpPrefix   = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	creatorTool = regexp.MustCompile(`CreatorTool(?:="|>)([^"<]+)`)
+)
+
+func readMetadata(data []byte, kind uploads.Kind) metadata {
+	if kind == uploads.KindPNG {
+		return pngMetadata(data)
+	}
+	return jpegMetadata(data)
+}
+
+// jpegMetadata walks the segments before the image data, reading EXIF, XMP
+// and the luminance quantization table.
+func jpegMetadata(data []byte) metadata {
+	var m metadata
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			break
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan, end of image
+			break
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if size < 2 || i+2+size > len(data) {
+			break
+		}
+		seg := data[i+4 : i+2+size]
+		switch {
+		case marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")):
+			m.readEXIF(seg[6:])
+		case marker == 0xE1 && bytes.HasPrefix(seg, xmpPrefix):
+			m.readXMP(seg[len(xmpPrefix):])
+		case marker == 0xDB && m.quality == 0:
+			m.quality = jpegQuality(seg)
+		}
+		i += 2 + size
+	}
+	return m
+}
+
+// pngMetadata reads text chunks, XMP and eXIf chunks.
+func pngMetadata(data []byte) metadata {
+	var m metadata
+	for i := 8; i+8 <= len(data); {
+		n := int(binary.BigEndian.Uint32(data[i : i+4]))
+		typ := string(data[i+4 : i+8])
+		if n < 0 || i+12+n > len(data) || typ == "IDAT" {
+			break
+		}
+		chunk := data[i+8 : i+8+n]
+		switch typ {
+		case "tEXt", "iTXt":
+			key, text, _ := bytes.Cut(chunk, []byte{0})
+			if typ == "iTXt" {
+				text = itxtText(text)
+			}
+			switch string(key) {
+			case "Software":
+				m.setSoftware(string(text))
+			case "XML:com.adobe.xmp":
+				m.readXMP(text)
+			case "Description", "Comment", "Title":
+				m.checkScreenshot(string(text))
+			}
+		case "eXIf":
+			m.readEXIF(chunk)
+		}
+		i += 12 + n
+	}
+	return m
+}
+
+// itxtText returns the text of an uncompressed iTXt chunk after its keyword.
+func itxtText(rest []byte) []byte {
+	if len(rest) < 2 || rest[0] != 0 { // Compressed text is skipped
+		return nil
+	}
+	rest = rest[2:]
+	for range 2 { // Language tag and translated keyword
+		_, after, ok := bytes.Cut(rest, []byte{0})
+		if !ok {
+			return nil
+		}
+		rest = after
+	}
+	return rest
+}
+
+func (m *metadata) readXMP(xmp []byte) {
+	if match := creatorTool.FindSubmatch(xmp); match != nil {
+		m.setSoftware(string(match[1]))
+	}
+	m.checkScreenshot(string(xmp))
+}
+
+func (m *metadata) setSoftware(s string) {
+	s = strings.TrimSpace(strings.Trim(s, "\x00"))
+	if s != "" && m.software == "" {
+		m.software = s
+	}
+	m.checkScreenshot(s)
+}
+
+// checkScreenshot looks for the marker phones and desktops leave in
+// screenshots, e.g. the user comment "Screenshot" written by macOS.
+func (m *metadata) checkScreenshot(s string) {
+	if strings.Contains(strings.ToLower(s), "screenshot") {
+		m.screenshot = true
+	}
+}
+
+// readEXIF reads the make, model and software from the first IFD of a TIFF
+// structure.
+func (m *metadata) readEXIF(tiff []byte) {
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return
+	}
+	var maker, model string
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for e := 0; e < entries; e++ {
+		off := ifd + 2 + e*12
+		if off+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[off : off+2])
+		if tag != tagMake && tag != tagModel && tag != tagSoftware {
+			continue
+		}
+		value := tiffASCII(tiff, off, order)
+		switch tag {
+		case tagMake:
+			maker = value
+		case tagModel:
+			model = value
+		case tagSoftware:
+			m.setSoftware(value)
+		}
+	}
+	if model != "" && !strings.HasPrefix(model, maker) {
+		model = strings.TrimSpace(maker + " " + model)
+	}
+	if model == "" {
+		model = maker
+	}
+	if model != "" {
+		m.camera = model
+	}
+}
+
+// tiffASCII returns the value of the ASCII entry at off, which is stored in
+// the entry itself when it fits in four bytes.
+func tiffASCII(tiff []byte, off int, order binary.ByteOrder) string {
+	if order.Uint16(tiff[off+2:off+4]) != 2 {
+		return ""
+	}
+	n := int(order.Uint32(tiff[off+4 : off+8]))
+	start := off + 8
+	if n > 4 {
+		start = int(order.Uint32(tiff[off+8 : off+12]))
+	}
+	if n <= 0 || n > 256 || start < 0 || start+n > len(tiff) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(tiff[start:start+n]), "\x00"))
+}
+
+// stdLuminance is the sum of the example luminance table of the JPEG
+// standard, which encoders scale to reach a quality setting.
+const stdLuminance = 3688
+
+// jpegQuality estimates the quality setting from the first 8-bit table of a
+// DQT segment, using the libjpeg scaling formula in reverse.
+func jpegQuality(seg []byte) int {
+	if len(seg) < 65 || seg[0]>>4 != 0 { // 16-bit tables are not used by cameras
+		return 0
+	}
+	sum := 0
+	for _, q := range seg[1:65] {
+		sum += int(q)
+	}
+	scale := float64(sum) * 100 / stdLuminance
+	var quality float64
+	if scale <= 100 {
+		quality = (200 - scale) / 2
+	} else {
+		quality = 5000 / scale
+	}
+	return max(1, min(100, int(quality+0.5)))
+}
@@ -0,0 +1,229 @@
+package forensics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+// tiffEntry is an IFD entry of type ASCII.
+type tiffEntry struct {
+	tag   uint16
+	value string // Written with a terminating NUL
+}
+
+// buildTIFF returns a TIFF structure whose first IFD holds the entries.
+// Values longer than four bytes are stored after the IFD.
+func buildTIFF(order binary.AppendByteOrder, entries ...tiffEntry) []byte {
+	tiff := []byte("II*\x00")
+	if order == binary.BigEndian {
+		tiff = []byte("MM\x00*")
+	}
+	tiff = order.AppendUint32(tiff, 8)
+	tiff = order.AppendUint16(tiff, uint16(len(entries)))
+	data := 8 + 2 + 12*len(entries) + 4
+	var values []byte
+	for _, e := range entries {
+		v := append([]byte(e.value), 0)
+		tiff = order.AppendUint16(tiff, e.tag)
+		tiff = order.AppendUint16(tiff, 2)
+		tiff = order.AppendUint32(tiff, uint32(len(v)))
+		if len(v) <= 4 {
+			tiff = append(tiff, append(v, make([]byte, 4-len(v))...)...)
+		} else {
+			tiff = order.AppendUint32(tiff, uint32(data+len(values)))
+			values = append(values, v...)
+		}
+	}
+	tiff = order.AppendUint32(tiff, 0) // No next IFD
+	return append(tiff, values...)
+}
+
+func TestReadEXIF(t *testing.T) {
+	tests := []struct {
+		name       string
+		tiff       []byte
+		camera     string
+		software   string
+		screenshot bool
+	}{
+		{
+			name:   "little endian",
+			tiff:   buildTIFF(binary.LittleEndian, tiffEntry{tagMake, "Apple"}, tiffEntry{tagModel, "iPhone 13"}, tiffEntry{tagSoftware, "17.1"}),
+			camera: "Apple iPhone 13", software: "17.1",
+		},
+		{
+			name:   "big endian",
+			tiff:   buildTIFF(binary.BigEndian, tiffEntry{tagMake, "samsung"}, tiffEntry{tagModel, "SM-A525F"}),
+			camera: "samsung SM-A525F",
+		},
+		{
+			name:   "model includes make",
+			tiff:   buildTIFF(binary.LittleEndian, tiffEntry{tagMake, "Canon"}, tiffEntry{tagModel, "Canon EOS 80D"}),
+			camera: "Canon EOS 80D",
+		},
+		{
+			name:   "inline value",
+			tiff:   buildTIFF(binary.BigEndian, tiffEntry{tagMake, "LG"}),
+			camera: "LG",
+		},
+		{
+			name:     "editor without camera",
+			tiff:     buildTIFF(binary.LittleEndian, tiffEntry{tagSoftware, "Adobe Photoshop 25.0 (Windows)"}),
+			software: "Adobe Photoshop 25.0 (Windows)",
+		},
+		{
+			name:     "screenshot",
+			tiff:     buildTIFF(binary.LittleEndian, tiffEntry{tagSoftware, "Screenshot"}),
+			software: "Screenshot", screenshot: true,
+		},
+		{
+			name: "other tags",
+			tiff: buildTIFF(binary.LittleEndian, tiffEntry{0x010E, "Canon"}),
+		},
+		{name: "bad byte order", tiff: append([]byte("XX"), buildTIFF(binary.LittleEndian, tiffEntry{tagMake, "Apple"})[2:]...)},
+		{name: "IFD past the end", tiff: []byte("II*\x00\xff\x00\x00\x00")},
+		{name: "truncated entries", tiff: buildTIFF(binary.LittleEndian, tiffEntry{tagMake, "Apple"}, tiffEntry{tagModel, "iPhone 13"})[:20]},
+		{name: "too short", tiff: []byte("II*")},
+	}
+	for _, tt := range tests {
+		var m metadata
+		m.readEXIF(tt.tiff)
+		if m.camera != tt.camera || m.software != tt.software || m.screenshot != tt.screenshot {
+			t.Errorf("%s: camera %q, software %q, screenshot %v, want %q, %q, %v", tt.name, m.camera, m.software, m.screenshot, tt.camera, tt.software, tt.screenshot)
+		}
+	}
+}
+
+func TestTIFFASCII(t *testing.T) {
+	order := binary.LittleEndian
+	entry := func(typ uint16, count, value uint32) []byte {
+		b := order.AppendUint16(nil, tagMake)
+		b = order.AppendUint16(b, typ)
+		b = order.AppendUint32(b, count)
+		return order.AppendUint32(b, value)
+	}
+	inline := func(s string) []byte {
+		b := entry(2, uint32(len(s)), 0)
+		copy(b[8:], s)
+		return b
+	}
+	tests := []struct {
+		name string
+		tiff []byte
+		want string
+	}{
+		{"inline", inline("LG\x00"), "LG"},
+		{"offset", append(entry(2, 8, 12), "Google \x00"...), "Google"},
+		{"trailing NULs", append(entry(2, 10, 12), "Nikon\x00\x00\x00\x00\x00"...), "Nikon"},
+		{"not ASCII", entry(3, 1, 1), ""},
+		{"empty", entry(2, 0, 0), ""},
+		{"too long", append(entry(2, 300, 12), bytes.Repeat([]byte("A"), 300)...), ""},
+		{"past the end", append(entry(2, 8, 12), "Goog"...), ""},
+		{"offset overflow", entry(2, 8, 0xFFFFFFFF), ""},
+	}
+	for _, tt := range tests {
+		if got := tiffASCII(tt.tiff, 0, order); got != tt.want {
+			t.Errorf("%s: tiffASCII = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// dqtSegment returns the payload of the first DQT segment of a JPEG.
+func dqtSegment(t *testing.T, data []byte) []byte {
+	t.Helper()
+	for i := 2; i+4 <= len(data); {
+		size := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if data[i+1] == 0xDB {
+			return data[i+4 : i+2+size]
+		}
+		i += 2 + size
+	}
+	t.Fatal("no DQT segment")
+	return nil
+}
+
+func TestJPEGQuality(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	// Below about 25 the tables clip at 255 and the estimate drifts.
+	for _, quality := range []int{25, 30, 50, 75, 90, 95, 100} {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			t.Fatal(err)
+		}
+		got := jpegQuality(dqtSegment(t, buf.Bytes()))
+		if got < quality-2 || got > quality+2 {
+			t.Errorf("quality %d estimated as %d", quality, got)
+		}
+	}
+
+	sixteenBit := append([]byte{0x10}, make([]byte, 128)...)
+	for name, seg := range map[string][]byte{"16-bit table": sixteenBit, "short": {0, 1, 2}, "empty": nil} {
+		if got := jpegQuality(seg); got != 0 {
+			t.Errorf("%s: jpegQuality = %d, want 0", name, got)
+		}
+	}
+}
+
+// pngChunk returns a PNG chunk with its CRC.
+func pngChunk(typ string, data []byte) []byte {
+	c := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	c = append(append(c, typ...), data...)
+	return binary.BigEndian.AppendUint32(c, crc32.ChecksumIEEE(c[4:]))
+}
+
+// withChunks inserts chunks after the IHDR chunk of a PNG.
+func withChunks(png []byte, chunks ...[]byte) []byte {
+	const afterIHDR = 8 + 25
+	out := bytes.Clone(png[:afterIHDR])
+	for _, c := range chunks {
+		out = append(out, c...)
+	}
+	return append(out, png[afterIHDR:]...)
+}
+
+func TestPNGMetadata(t *testing.T) {
+	png := encodePNG(t, photo(64, 64))
+	xmp := `<x:xmpmeta><rdf:Description xmp:CreatorTool="Adobe Photoshop 2024"/></x:xmpmeta>`
+	exif := buildTIFF(binary.BigEndian, tiffEntry{tagMake, "Google"}, tiffEntry{tagModel, "Pixel 7"})
+	tests := []struct {
+		name       string
+		data       []byte
+		camera     string
+		software   string
+		screenshot bool
+	}{
+		{"no metadata", png, "", "", false},
+		{"software text", withChunks(png, pngChunk("tEXt", []byte("Software\x00GIMP 2.10"))), "", "GIMP 2.10", false},
+		{"XMP in iTXt", withChunks(png, pngChunk("iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00en\x00\x00"+xmp))), "", "Adobe Photoshop 2024", false},
+		{"compressed iTXt", withChunks(png, pngChunk("iTXt", []byte("Software\x00\x01\x00\x00\x00x\x9c"))), "", "", false},
+		{"screenshot description", withChunks(png, pngChunk("iTXt", []byte("Description\x00\x00\x00\x00\x00Screenshot"))), "", "", true},
+		{"eXIf", withChunks(png, pngChunk("eXIf", exif)), "Google Pixel 7", "", false},
+		{"first software wins", withChunks(png, pngChunk("tEXt", []byte("Software\x00Snapseed")), pngChunk("tEXt", []byte("Software\x00GIMP"))), "", "Snapseed", false},
+		{"after image data", append(bytes.Clone(png[:len(png)-12]), append(pngChunk("tEXt", []byte("Software\x00GIMP")), png[len(png)-12:]...)...), "", "", false},
+		{"truncated chunk", withChunks(png[:33], pngChunk("tEXt", []byte("Software\x00GIMP"))[:12]), "", "", false},
+	}
+	for _, tt := range tests {
+		m := pngMetadata(tt.data)
+		if m.camera != tt.camera || m.software != tt.software || m.screenshot != tt.screenshot {
+			t.Errorf("%s: camera %q, software %q, screenshot %v, want %q, %q, %v", tt.name, m.camera, m.software, m.screenshot, tt.camera, tt.software, tt.screenshot)
+		}
+	}
+}
+
+func TestJPEGMetadata(t *testing.T) {
+	jpg := encodeJPEG(t, photo(64, 64), 85)
+	xmp := append(bytes.Clone(xmpPrefix), `<xmp:CreatorTool>Snapseed 2.0</xmp:CreatorTool>`...)
+	exif := append([]byte("Exif\x00\x00"), buildTIFF(binary.LittleEndian, tiffEntry{tagMake, "Apple"}, tiffEntry{tagModel, "iPhone 15"})...)
+
+	m := jpegMetadata(withSegments(jpg, app1(exif), app1(xmp)))
+	if m.camera != "Apple iPhone 15" || m.software != "Snapseed 2.0" || m.quality < 83 || m.quality > 87 {
+		t.Errorf("got %+v", m)
+	}
+	if m := jpegMetadata(jpg[:20]); m.camera != "" || m.software != "" {
+		t.Errorf("truncated: %+v", m)
+	}
+}
@@ -0,0 +1,128 @@
+package forensics
+
+import (
+	"image"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// moireRatio looks for a strong periodic pattern, as left by the pixel grid
+// of a screen photographed by a camera. It averages the spectra of the rows
+// and of the columns of the image and returns the highest peak relative to
+// the median of its neighbouring frequencies. Natural photos and printed
+// documents have smooth averaged spectra; a screen shows isolated spikes.
+// Frequencies of the 8x8 JPEG block grid are ignored. ok is false for
+// images too small to analyse.
+func moireRatio(img image.Image) (float64, bool) {
+	b := img.Bounds()
+	n := 1
+	for n*2 <= min(b.Dx(), b.Dy(), moireDimension) {
+		n *= 2
+	}
+	if n < minMoireDimension {
+		return 0, false
+	}
+	x0 := b.Min.X + (b.Dx()-n)/2
+	y0 := b.Min.Y + (b.Dy()-n)/2
+
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+	}
+	rows := make([]float64, n/2)
+	cols := make([]float64, n/2)
+	line := make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			line[j] = luma(img, x0+j, y0+i)
+		}
+		addSpectrum(rows, line, window)
+		for j := 0; j < n; j++ {
+			line[j] = luma(img, x0+i, y0+j)
+		}
+		addSpectrum(cols, line, window)
+	}
+	return max(peakRatio(rows), peakRatio(cols)), true
+}
+
+// addSpectrum adds the magnitude spectrum of the windowed, zero-mean line to
+// acc.
+func addSpectrum(acc, line, window []float64) {
+	var mean float64
+	for _, v := range line {
+		mean += v
+	}
+	mean /= float64(len(line))
+	buf := make([]complex128, len(line))
+	for i, v := range line {
+		buf[i] = complex((v-mean)*window[i], 0)
+	}
+	fft(buf)
+	for k := range acc {
+		acc[k] += cmplx.Abs(buf[k])
+	}
+}
+
+// peakRatio returns the largest ratio of a frequency to the median of the
+// eight frequencies on either side, skipping the lowest sixteenth of the
+// band, where image content dominates, and the JPEG block frequencies.
+func peakRatio(spectrum []float64) float64 {
+	n := len(spectrum) * 2
+	const reach = 8
+	best := 0.0
+	neighbours := make([]float64, 0, 2*reach)
+	for k := n / 16; k < len(spectrum)-1; k++ {
+		if nearBlockFrequency(k, n) {
+			continue
+		}
+		neighbours = neighbours[:0]
+		for j := k - reach; j <= k+reach; j++ {
+			if j != k && j > 0 && j < len(spectrum) && !nearBlockFrequency(j, n) {
+				neighbours = append(neighbours, spectrum[j])
+			}
+		}
+		if len(neighbours) < reach {
+			continue
+		}
+		sort.Float64s(neighbours)
+		median := neighbours[len(neighbours)/2]
+		if median > 0 {
+			best = max(best, spectrum[k]/median)
+		}
+	}
+	return best
+}
+
+func nearBlockFrequency(k, n int) bool {
+	step := n / 8
+	r := k % step
+	return r <= 1 || r >= step-1
+}
+
+// fft is an in-place radix-2 Cooley-Tukey transform; len(a) must be a power
+// of two.
+func fft(a []complex128) {
+	n := len(a)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u, v := a[start+k], a[start+k+size/2]*w
+				a[start+k], a[start+k+size/2] = u+v, u-v
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"kyc/internal/models"
+	"kyc/internal/repository"
+	"kyc/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AppealRequest struct {
+	Note string `json:"note" binding:"required,max=2000"`
+}
+
+// Appeal sends the user's automatically rejected latest attempt to the
+// human review queue. It goes to fraud review instead if the checks that
+// ran on the submission found a duplicate or a forensic risk, or if the
+// user is on a watchlist, which is only screened now.
+func (h *KYCHandler) Appeal(c *gin.Context) {
+	userID := c.GetString("userID")
+	var req AppealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kyc, err := h.repo.GetByUserID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if kyc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC record not found"})
+		return
+	}
+
+	now := time.Now()
+	change := models.StatusChange{To: models.StatusPending, Actor: userID, Reason: "Appeal: " + req.Note, At: now}
+	if len(kyc.FraudMatches) > 0 || slices.Contains(kyc.Flags, models.FlagForensicRisk) {
+		change.To = models.StatusFraudReview
+	}
+	if kyc.ScreenedAs == nil {
+		// Rejected before screening ran on rejections.
+		subject := services.NewScreeningSubject(kyc, c.GetString("userName"))
+		kyc.ScreenedAs = &subject
+	}
+	screeningHits, err := h.screening.Screen(c.Request.Context(), userID, *kyc.ScreenedAs, models.ScreeningOnAppeal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	flags := addFlags(kyc.Flags, models.FlagAppealed)
+	if len(screeningHits) > 0 {
+		flags = addFlags(flags, models.FlagWatchlistHit)
+		change.To = models.StatusFraudReview
+	}
+	if err := services.CheckTransition(kyc, change, now); err != nil {
+		if errors.Is(err, services.ErrInvalidTransition) {
+			err = services.ErrNotAppealable
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": kyc.Status})
+		return
+	}
+
+	// As on submission, the case exists before the request is held.
+	var screeningCase *models.ScreeningCase
+	if len(screeningHits) > 0 {
+		if screeningCase, err = h.screening.OpenCase(c.Request.Context(), kyc, models.ScreeningOnAppeal, screeningHits); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+	msg := models.NewMessage(userID, models.RoleUser, req.Note)
+	appeal := models.Appeal{Note: req.Note, At: now}
+	err = h.repo.FileAppeal(c.Request.Context(), kyc, change, appeal, msg, flags)
+	if err != nil && screeningCase != nil {
+		if err := h.screening.DeleteCase(c.Request.Context(), screeningCase); err != nil {
+			fmt.Printf("Failed to delete screening case %s: %v\n", screeningCase.ID.Hex(), err)
+		}
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "KYC request was changed, please reload"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to file appeal"})
+		return
+	}
+	if len(kyc.FraudMatches) == 0 {
+		// Rejected attempts do not hold their document until appealed.
+		if err := h.claimIdentity(c.Request.Context(), kyc); err != nil {
+			fmt.Printf("Failed to claim document for appealed %s: %v\n", kyc.ID.Hex(), err)
+		}
+	}
+	c.JSON(http.StatusOK, kyc)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kyc/internal/models"
+	"kyc/internal/repository"
+	"kyc/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminDocumentURL issues a short-lived signed URL to view one image of a
+// request, or its selfie for the index "selfie". The URL works without the Authorization header, so it can be
+// used directly as an image source.
+func (h *KYCHandler) AdminDocumentURL(c *gin.Context) {
+	index, err := parseDocumentIndex(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document index"})
+		return
+	}
+	kyc, ok := h.loadRequest(c)
+	if !ok {
+		return
+	}
+
+	signed, err := h.documents.Issue(c.Request.Context(), kyc, index, c.GetString("userID"), accessEntry(c))
+	if errors.Is(err, services.ErrNoSuchDocument) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue document URL"})
+		return
+	}
+	c.JSON(http.StatusOK, signed)
+}
+
+// ServeDocument streams a document for a signed URL.
+func (h *KYCHandler) ServeDocument(c *gin.Context) {
+	index, err := parseDocumentIndex(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document index"})
+		return
+	}
+	reviewer, err := h.documents.Verify(c.Param("id"), index, c.Request.URL.Query(), time.Now())
+	if errors.Is(err, services.ErrLinkExpired) {
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	kyc, ok := h.loadRequest(c)
+	if !ok {
+		return
+	}
+
+	data, contentType, err := h.documents.Open(c.Request.Context(), kyc, index, reviewer, accessEntry(c))
+	if errors.Is(err, services.ErrNoSuchDocument) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to serve document %d of %s: %v", index, kyc.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read document"})
+		return
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Disposition", "inline")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// AdminAccessLog lists who issued links to and viewed a request's documents.
+func (h *KYCHandler) AdminAccessLog(c *gin.Context) {
+	kyc, ok := h.loadRequest(c)
+	if !ok {
+		return
+	}
+	entries, err := h.documents.AccessLog(c.Request.Context(), kyc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// loadRequest fetches the request named by the :id parameter. On failure it
+// writes the error response and returns false.
+func (h *KYCHandler) loadRequest(c *gin.Context) (*models.KYCRequest, bool) {
+	kyc, err := h.repo.GetByID(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC record not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return kyc, true
+}
+
+func accessEntry(c *gin.Context) models.DocumentAccess {
+	return models.DocumentAccess{IP: c.ClientIP(), UserAgent: c.Request.UserAgent(), At: time.Now()}
+}
+
+// parseDocumentIndex accepts an image index or "selfie".
+func parseDocumentIndex(s string) (int, error) {
+	if s == "selfie" {
+		return models.SelfieIndex, nil
+	}
+	return strconv.Atoi(s)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"kyc/internal/scanner"
+	"kyc/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Readiness reports whether the service can take submissions: MongoDB and
+// the malware scanner must answer and at least one verifier must not have
+// an open circuit. The
+// state of every verifier is included, so a degraded AI provider is visible
+// even while a fallback keeps the service ready.
+func Readiness(client *mongo.Client, verifier services.DocumentVerifier, malware scanner.Scanner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+
+		ready := true
+		mongoStatus := "ok"
+		if err := client.Ping(ctx, nil); err != nil {
+			ready = false
+			mongoStatus = err.Error()
+		}
+
+		scannerStatus := "ok"
+		if p, ok := malware.(scanner.Pinger); ok {
+			if err := p.Ping(ctx); err != nil {
+				ready = false
+				scannerStatus = err.Error()
+			}
+		}
+
+		var providers []services.ProviderHealth
+		if r, ok := verifier.(services.HealthReporter); ok {
+			providers = r.Health()
+		}
+		available, degraded := len(providers) == 0, false
+		for _, p := range providers {
+			if p.State == services.CircuitOpen {
+				degraded = true
+			} else {
+				available = true
+			}
+		}
+		ready = ready && available
+
+		status, code := "ready", http.StatusOK
+		if !ready {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		} else if degraded {
+			status = "degraded"
+		}
+		c.JSON(code, gin.H{"status": status, "mongo": mongoStatus, "scanner": scannerStatus, "verifiers": providers})
+	}
+}
@@ -1,122 +1,639 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/http"
-	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"kyc/internal/doctypes"
+	"kyc/internal/forensics"
 	"kyc/internal/models"
+	"kyc/internal/mrz"
 	"kyc/internal/repository"
 	"kyc/internal/services"
+	"kyc/internal/tiers"
+	"kyc/internal/uploads"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type KYCHandler struct {
 	repo          *repository.KYCRepository
-	verifyService *services.VerificationService
+	verifyService services.DocumentVerifier
+	duplicates    *services.DuplicateDetector
+	uploadPolicy  uploads.Policy
+	resubmission  services.ResubmissionPolicy
+	queue         *services.ReviewQueue
+	documents     *services.DocumentAccess
+	verifyLimits  services.VerifyLimits
+	faces         *services.FaceCheck
+	forensics     *forensics.Analyzer
+	malware       *services.MalwareGuard
+	screening     *services.Screener
+	tiers         *services.TierService
 }
 
-func NewKYCHandler(repo *repository.KYCRepository, verifyService *services.VerificationService) *KYCHandler {
+func NewKYCHandler(repo *repository.KYCRepository, verifyService services.DocumentVerifier, duplicates *services.DuplicateDetector, uploadPolicy uploads.Policy, resubmission services.ResubmissionPolicy, queue *services.ReviewQueue, documents *services.DocumentAccess, verifyLimits services.VerifyLimits, faces *services.FaceCheck, forensics *forensics.Analyzer, malware *services.MalwareGuard, screening *services.Screener, tiers *services.TierService) *KYCHandler {
 	return &KYCHandler{
 		repo:          repo,
 		verifyService: verifyService,
+		duplicates:    duplicates,
+		uploadPolicy:  uploadPolicy,
+		resubmission:  resubmission,
+		queue:         queue,
+		documents:     documents,
+		verifyLimits:  verifyLimits,
+		faces:         faces,
+		forensics:     forensics,
+		malware:       malware,
+		screening:     screening,
+		tiers:         tiers,
 	}
 }
 
 type SubmitKYCRequest struct {
 	Type           string `form:"type" binding:"required"`
 	DocumentNumber string `form:"document_number" binding:"required"`
+	Country        string `form:"country"`       // ISO 3166-1 alpha-2, defaults to BD
+	DateOfBirth    string `form:"date_of_birth"` // Optional, YYYY-MM-DD
+	MRZ            string `form:"mrz"`           // Optional, typed by the user for passports
+	// AddressProofType is the document type of the address_proof image,
+	// UTILITY_BILL by default.
+	AddressProofType string `form:"address_proof_type"`
 }
 
 func (h *KYCHandler) SubmitKYC(c *gin.Context) {
 	userID := c.GetString("userID")
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.uploadPolicy.MaxRequestBytes())
 
-	// Check if already exists
-	existing, err := h.repo.GetByUserID(c.Request.Context(), userID)
+	// A new attempt is only allowed after a rejection
+	latest, err := h.repo.GetByUserID(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
-	if existing != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "KYC request already exists for this user"})
+	attempts, err := h.repo.CountByUserID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if err := h.resubmission.Check(latest, attempts, time.Now()); err != nil {
+		var cooldown *services.CooldownError
+		switch {
+		case errors.As(err, &cooldown):
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(cooldown.Until).Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Resubmission is not allowed yet", "retry_after": cooldown.Until})
+		case errors.Is(err, services.ErrMaxAttempts):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Maximum number of KYC attempts reached"})
+		default:
+			c.JSON(http.StatusConflict, gin.H{"error": "KYC request already exists for this user"})
+		}
 		return
 	}
 
 	var req SubmitKYCRequest
 	if err := c.ShouldBind(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Handle File Upload
-	form, _ := c.MultipartForm()
-	files := form.File["images"]
-	if len(files) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No images provided. Please upload a document image."})
+	req.Type = strings.ToUpper(req.Type)
+	if req.Country == "" {
+		req.Country = doctypes.DefaultCountry
+	}
+	req.Country = strings.ToUpper(req.Country)
+	docType, documentNumber, err := doctypes.Validate(req.Type, req.Country, req.DocumentNumber, req.DateOfBirth)
+	if errors.Is(err, doctypes.ErrUnsupportedType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported document type %s for country %s", req.Type, req.Country)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document number: " + err.Error()})
+		return
+	}
+	req.DocumentNumber = documentNumber
+	if docType.AddressProof {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is a proof of address. Upload it as %s together with an identity document.", docType.Name, doctypes.RoleAddressProof)})
 		return
 	}
-	var imagePaths []string
 
-	for _, file := range files {
-		// Save file locally for now (simulate S3)
-		filename := fmt.Sprintf("%s_%d_%s", userID, time.Now().Unix(), filepath.Base(file.Filename))
-		path := filepath.Join("uploads", filename)
-		if err := c.SaveUploadedFile(file, path); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+	// Handle File Upload
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Images must be uploaded as multipart/form-data"})
+		return
+	}
+	if len(form.File["images"]) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload each image in the field named after its role", "roles": docType.Roles()})
+		return
+	}
+	var addressType *doctypes.DocumentType
+	if len(form.File[doctypes.RoleAddressProof]) > 0 {
+		if req.AddressProofType == "" {
+			req.AddressProofType = doctypes.UtilityBill
+		}
+		t, ok := doctypes.Lookup(req.AddressProofType, req.Country)
+		if !ok || !t.AddressProof {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported proof of address type %s", req.AddressProofType)})
 			return
 		}
-		imagePaths = append(imagePaths, path)
+		addressType = t
+		req.AddressProofType = t.Code
+	} else {
+		req.AddressProofType = ""
+	}
+	files, roles, err := roleUploads(form, docType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	selfies := form.File[doctypes.RoleSelfie]
+	if len(selfies) > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only one selfie can be uploaded"})
+		return
+	}
+	missing := docType.MissingRoles(roles)
+	if len(selfies) == 0 && h.faces.Required {
+		missing = append(missing, doctypes.RoleSelfie)
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s submission is missing images for: %s", docType.Name, strings.Join(missing, ", ")), "missing_roles": missing})
+		return
+	}
+	if err := h.uploadPolicy.CheckCount(len(files)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for i, file := range files {
+		if !h.scanUploads(c, primitive.NilObjectID, roles[i], []*multipart.FileHeader{file}) {
+			return
+		}
+	}
+	if !h.scanUploads(c, primitive.NilObjectID, doctypes.RoleSelfie, selfies) {
+		return
+	}
+	docTypes := make([]string, len(roles))
+	for i, role := range roles {
+		docTypes[i] = req.Type
+		if role == doctypes.RoleAddressProof {
+			docTypes[i] = addressType.Code
+		}
+	}
+	imagePaths, verifications, flags, rejected, ok := h.saveAndVerify(c, files, userID, docTypes)
+	if !ok {
+		return
+	}
+	identityImages := imagesExcept(imagePaths, roles, doctypes.RoleAddressProof)
 
-		// Verify Image using AI
-		isValid, err := h.verifyService.VerifyImage(path)
+	var selfiePath string
+	var faceMatch *models.FaceMatch
+	if len(selfies) == 1 {
+		saved, err := h.uploadPolicy.Save(selfies[0], "uploads", userID)
 		if err != nil {
-			fmt.Printf("AI Verification Error: %v\n", err)
-			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "AI Verification service unavailable", "details": err.Error()})
+			c.JSON(uploadErrorStatus(err), gin.H{"error": "Selfie rejected: " + err.Error()})
 			return
 		}
+		selfiePath = saved.Path
+		if !saved.Kind.Decodable() {
+			flags = addFlags(flags, models.FlagUnanalyzedFormat)
+		}
+		var faceFlags []string
+		faceMatch, faceFlags = h.faces.Check(services.WithDocumentType(c.Request.Context(), req.Type), selfiePath, identityImages)
+		flags = addFlags(flags, faceFlags...)
+	}
 
-		if !isValid {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Image rejected: Document irrelevant or not recognized as ID/Passport"})
-			return
+	extracted := h.extractFields(services.WithDocumentType(c.Request.Context(), req.Type), identityImages)
+	var fieldMatch *models.FieldMatch
+	if extracted != nil {
+		var matchFlags []string
+		fieldMatch, matchFlags = services.MatchFields(extracted, req.DocumentNumber, c.GetString("userName"))
+		flags = addFlags(flags, matchFlags...)
+	}
+
+	var passportMRZ *mrz.MRZ
+	if req.Type == doctypes.Passport {
+		mrzText := req.MRZ
+		if mrzText == "" && extracted != nil {
+			mrzText = extracted.MRZ
+		}
+		if mrzText == "" {
+			flags = addFlags(flags, models.FlagMRZUnavailable)
+		} else {
+			passportMRZ, err = services.CheckPassportMRZ(mrzText, req.DocumentNumber)
+			if errors.Is(err, mrz.ErrNotFound) && req.MRZ == "" {
+				// The model returned something that is not an MRZ.
+				flags = addFlags(flags, models.FlagMRZUnavailable)
+			} else if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Passport rejected: " + err.Error()})
+				return
+			}
+		}
+	}
+
+	hashes := hashImages(imagePaths)
+	fraudMatches, err := h.findDuplicates(c, userID, req.Type, req.DocumentNumber, hashes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	for _, m := range fraudMatches {
+		if m.Kind == models.MatchDocumentNumber {
+			flags = addFlags(flags, models.FlagDuplicateDocument)
+		} else {
+			flags = addFlags(flags, models.FlagDuplicateImage)
 		}
 	}
 
 	kyc := &models.KYCRequest{
-		UserID:         userID,
-		Type:           req.Type,
-		DocumentNumber: req.DocumentNumber,
-		Images:         imagePaths,
+		UserID:           userID,
+		Attempt:          1,
+		Type:             req.Type,
+		Country:          req.Country,
+		DocumentNumber:   req.DocumentNumber,
+		DateOfBirth:      req.DateOfBirth,
+		Images:           imagePaths,
+		ImageRoles:       roles,
+		AddressProofType: req.AddressProofType,
+		Verifications:    verifications,
+		Flags:            flags,
+		Extracted:        extracted,
+		FieldMatch:       fieldMatch,
+		MRZ:              passportMRZ,
+		FraudMatches:     fraudMatches,
+		Selfie:           selfiePath,
+		FaceMatch:        faceMatch,
+	}
+	if latest != nil {
+		kyc.Attempt = max(latest.Attempt, attempts) + 1
+	}
+	subject := services.NewScreeningSubject(kyc, c.GetString("userName"))
+	kyc.ScreenedAs = &subject
+	if rejected != nil {
+		// Screened if and when the user appeals.
+		h.recordRejection(c, kyc, hashes, rejected)
+		return
+	}
+	screeningHits, err := h.screening.Screen(c.Request.Context(), userID, subject, models.ScreeningOnSubmission)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if len(screeningHits) > 0 {
+		kyc.Flags = addFlags(kyc.Flags, models.FlagWatchlistHit)
 	}
 
-	if err := h.repo.Create(c.Request.Context(), kyc); err != nil {
+	if len(fraudMatches) > 0 || len(screeningHits) > 0 || slices.Contains(flags, models.FlagForensicRisk) {
+		kyc.Status = models.StatusFraudReview
+	}
+	// The case is opened first: a request held by WATCHLIST_HIT cannot be
+	// approved, and only resolving its case removes the flag.
+	var screeningCase *models.ScreeningCase
+	if len(screeningHits) > 0 {
+		kyc.ID = primitive.NewObjectID()
+		if screeningCase, err = h.screening.OpenCase(c.Request.Context(), kyc, models.ScreeningOnSubmission, screeningHits); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+	err = h.repo.Create(c.Request.Context(), kyc)
+	if err != nil && screeningCase != nil {
+		if err := h.screening.DeleteCase(c.Request.Context(), screeningCase); err != nil {
+			fmt.Printf("Failed to delete screening case %s: %v\n", screeningCase.ID.Hex(), err)
+		}
+	}
+	if repository.IsDuplicateAttempt(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Another KYC submission is in progress for this user"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create KYC request"})
 		return
 	}
+	if len(fraudMatches) == 0 {
+		// The new attempt takes over the document from the user's earlier ones.
+		if err := h.claimIdentity(c.Request.Context(), kyc); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
+	if err := h.duplicates.SaveHashes(c.Request.Context(), kyc, hashes); err != nil {
+		fmt.Printf("Failed to save image hashes for %s: %v\n", kyc.ID.Hex(), err)
+	}
 
 	c.JSON(http.StatusCreated, kyc)
 }
 
+// saveAndVerify stores the uploaded images and runs the document verifier on
+// all of them concurrently, attaching the forensic report of each upload to
+// its verification. docTypes holds the document type declared for each
+// file. If the verifier rejects any image, the first
+// rejected result is returned as rejected, together with every image and
+// verification. Files that cannot be decoded (HEIC, PDF) raise
+// UNANALYZED_FORMAT. On other failures it writes the error response and
+// returns false.
+func (h *KYCHandler) saveAndVerify(c *gin.Context, files []*multipart.FileHeader, userID string, docTypes []string) ([]string, []models.VerificationResult, []string, *models.VerificationResult, bool) {
+	var imagePaths []string
+	var flags []string
+	for _, file := range files {
+		// Save file locally for now (simulate S3)
+		saved, err := h.uploadPolicy.Save(file, "uploads", userID)
+		if err != nil {
+			c.JSON(uploadErrorStatus(err), gin.H{"error": "Upload rejected: " + err.Error(), "file": file.Filename})
+			return nil, nil, nil, nil, false
+		}
+		if !saved.Kind.Decodable() {
+			flags = addFlags(flags, models.FlagUnanalyzedFormat)
+		}
+		imagePaths = append(imagePaths, saved.Path)
+	}
+
+	// The forensic checks need the files as uploaded, with their metadata,
+	// and run while the model looks at the stored copies.
+	analysis := make(chan []*models.Forensics, 1)
+	go func() { analysis <- h.analyzeUploads(files) }()
+
+	// The request context is cancelled when the client disconnects.
+	results, err := services.VerifyAll(c.Request.Context(), h.verifyService, imagePaths, docTypes, h.verifyLimits)
+	reports := <-analysis
+	if err != nil {
+		fmt.Printf("AI Verification Error: %v\n", err)
+		switch {
+		case c.Request.Context().Err() != nil:
+			c.AbortWithStatus(499) // Client closed request; nobody reads the response
+		case errors.Is(err, context.DeadlineExceeded):
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": "AI Verification timed out"})
+		default:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "AI Verification service unavailable", "details": err.Error()})
+		}
+		return nil, nil, nil, nil, false
+	}
+
+	var verifications []models.VerificationResult
+	var rejected *models.VerificationResult
+	for i, result := range results {
+		verification := *result
+		verification.Forensics = reports[i]
+		if reports[i] != nil && reports[i].Risk == models.RiskHigh {
+			flags = addFlags(flags, models.FlagForensicRisk)
+		}
+		verifications = append(verifications, verification)
+		if !result.IsValid() && rejected == nil {
+			rejected = result
+		}
+		if result.DocumentType != "" && result.DocumentType != docTypes[i] {
+			flags = addFlags(flags, models.FlagDocumentTypeMismatch)
+		}
+	}
+	return imagePaths, verifications, flags, rejected, true
+}
+
+// analyzeUploads runs the forensic checks on each uploaded file. Files that
+// cannot be analysed, such as PDFs, get a nil report.
+func (h *KYCHandler) analyzeUploads(files []*multipart.FileHeader) []*models.Forensics {
+	reports := make([]*models.Forensics, len(files))
+	if h.forensics == nil {
+		return reports
+	}
+	for i, file := range files {
+		data, err := readUpload(file, h.uploadPolicy.MaxFileBytes)
+		if err != nil || data == nil {
+			fmt.Printf("Failed to read %s for forensic analysis: %v\n", file.Filename, err)
+			continue
+		}
+		report, err := h.forensics.Analyze(data)
+		if err != nil {
+			if !errors.Is(err, forensics.ErrUnsupported) {
+				fmt.Printf("Forensic analysis of %s failed: %v\n", file.Filename, err)
+			}
+			continue
+		}
+		reports[i] = report
+	}
+	return reports
+}
+
+// recordRejection stores a submission the verifier rejected as an
+// automatically rejected attempt, so the user can appeal it, and writes
+// the 400 response. The other checks have run on kyc as on any submission,
+// so an appeal can route it to fraud review.
+func (h *KYCHandler) recordRejection(c *gin.Context, kyc *models.KYCRequest, hashes []models.ImageHash, rejected *models.VerificationResult) {
+	kyc.Flags = addFlags(kyc.Flags, models.FlagAutoRejected)
+	kyc.AutoRejected = true
+	kyc.Status = models.StatusRejected
+	kyc.Clarification = "Image rejected by automated verification: " + rejected.Reason
+	stored := true
+	if err := h.repo.Create(c.Request.Context(), kyc); err != nil {
+		fmt.Printf("Failed to record rejected submission for %s: %v\n", kyc.UserID, err)
+		stored = false
+	} else if err := h.duplicates.SaveHashes(c.Request.Context(), kyc, hashes); err != nil {
+		fmt.Printf("Failed to save image hashes for %s: %v\n", kyc.ID.Hex(), err)
+	}
+
+	resp := gin.H{"error": "Image rejected: Document irrelevant or not recognized as ID/Passport", "verification": rejected}
+	if i := slices.Index(kyc.Images, rejected.Image); i >= 0 {
+		resp["role"] = kyc.ImageRoles[i]
+		if kyc.ImageRoles[i] == doctypes.RoleAddressProof {
+			resp["error"] = "Image rejected: not recognized as a proof of address"
+		}
+	}
+	if stored {
+		resp["kyc_id"] = kyc.ID
+		resp["can_appeal"] = true
+	}
+	c.JSON(http.StatusBadRequest, resp)
+}
+
+// extractFields reads the document fields from every image and merges them.
+// Extraction is best effort: failures are logged and leave the reviewer to
+// compare the images by eye.
+func (h *KYCHandler) extractFields(ctx context.Context, imagePaths []string) *models.ExtractedFields {
+	extractor, ok := h.verifyService.(services.FieldExtractor)
+	if !ok {
+		return nil
+	}
+
+	var merged *models.ExtractedFields
+	for _, path := range imagePaths {
+		fields, err := extractor.ExtractFields(ctx, path)
+		if err != nil {
+			fmt.Printf("Field extraction failed for %s: %v\n", path, err)
+			continue
+		}
+		if merged == nil {
+			merged = fields
+		} else {
+			merged.Merge(fields)
+		}
+	}
+	return merged
+}
+
+// hashImages computes perceptual hashes for the images that can be decoded.
+func hashImages(imagePaths []string) []models.ImageHash {
+	var hashes []models.ImageHash
+	for _, path := range imagePaths {
+		hash, err := services.HashImage(path)
+		if err != nil {
+			fmt.Printf("Failed to hash %s: %v\n", path, err)
+		}
+		if hash != nil {
+			hashes = append(hashes, *hash)
+		}
+	}
+	return hashes
+}
+
+// findDuplicates returns other users' requests that use the same document
+// number or a near-identical image.
+func (h *KYCHandler) findDuplicates(c *gin.Context, userID, docType, documentNumber string, hashes []models.ImageHash) ([]models.FraudMatch, error) {
+	others, err := h.repo.GetByDocument(c.Request.Context(), docType, documentNumber, userID)
+	if err != nil {
+		return nil, err
+	}
+	var matches []models.FraudMatch
+	for _, other := range others {
+		matches = append(matches, models.FraudMatch{Kind: models.MatchDocumentNumber, KYCID: other.ID, UserID: other.UserID})
+	}
+
+	imageMatches, err := h.duplicates.FindImageMatches(c.Request.Context(), userID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return append(matches, imageMatches...), nil
+}
+
+// claimIdentity makes the stored request the holder of its document. If
+// another user claimed the same document concurrently, the request goes to
+// fraud review instead.
+func (h *KYCHandler) claimIdentity(ctx context.Context, kyc *models.KYCRequest) error {
+	err := h.repo.ClaimIdentity(ctx, kyc)
+	if !repository.IsDuplicateDocument(err) {
+		return err
+	}
+	if kyc.Status == models.StatusFraudReview {
+		if err := h.repo.AddFlag(ctx, kyc.ID, models.FlagDuplicateDocument); err != nil {
+			return err
+		}
+		kyc.Flags = addFlags(kyc.Flags, models.FlagDuplicateDocument)
+		return nil
+	}
+	change := models.StatusChange{To: models.StatusFraudReview, Actor: models.ActorSystem}
+	return h.repo.Escalate(ctx, kyc, change, addFlags(slices.Clone(kyc.Flags), models.FlagDuplicateDocument))
+}
+
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, uploads.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, uploads.ErrUnsupported), errors.Is(err, uploads.ErrDisallowedKind):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, uploads.ErrTooManyPixels), errors.Is(err, uploads.ErrCorrupt):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func addFlags(flags []string, add ...string) []string {
+	for _, f := range add {
+		if !slices.Contains(flags, f) {
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+// ListDocumentTypes returns the supported document types and the form
+// fields each one requires.
+func (h *KYCHandler) ListDocumentTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, doctypes.All())
+}
+
+// StatusResponse is the user's latest attempt together with whether and
+// when they may submit again.
+type StatusResponse struct {
+	*models.KYCRequest
+	Attempts      int          `json:"attempts"`
+	CanResubmit   bool         `json:"can_resubmit"`
+	NextAttemptAt *time.Time   `json:"next_attempt_at,omitempty"`
+	Tier          tiers.Status `json:"tier"`
+}
+
 func (h *KYCHandler) GetStatus(c *gin.Context) {
 	userID := c.GetString("userID")
+	tier, err := h.callerTier(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
 	kyc, err := h.repo.GetByUserID(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 	if kyc == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "KYC record not found"})
+		// A phone-verified user has a tier before submitting anything.
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC record not found", "tier": tier})
+		return
+	}
+	attempts, err := h.repo.CountByUserID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, kyc)
+	c.JSON(http.StatusOK, StatusResponse{
+		KYCRequest:    kyc,
+		Attempts:      attempts,
+		CanResubmit:   h.resubmission.Check(kyc, attempts, time.Now()) == nil,
+		NextAttemptAt: h.resubmission.NextAttemptAt(kyc, attempts),
+		Tier:          tier,
+	})
+}
+
+// GetHistory lists all of the user's KYC attempts, newest first.
+func (h *KYCHandler) GetHistory(c *gin.Context) {
+	requests, err := h.repo.GetHistory(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
 }
 
 // Admin Handlers
 
+// AdminGetPending lists pending submissions as a plain array, as it did
+// before the paginated review queue.
 func (h *KYCHandler) AdminGetPending(c *gin.Context) {
-	requests, err := h.repo.GetPending(c.Request.Context())
+	requests, err := h.repo.GetByStatus(c.Request.Context(), models.StatusPending)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// AdminGetFraudReview lists submissions that reuse another user's document or image.
+func (h *KYCHandler) AdminGetFraudReview(c *gin.Context) {
+	requests, err := h.repo.GetByStatus(c.Request.Context(), models.StatusFraudReview)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
@@ -125,8 +642,13 @@ func (h *KYCHandler) AdminGetPending(c *gin.Context) {
 }
 
 type VerificationRequest struct {
-	Status        string `json:"status" binding:"required,oneof=APPROVED REJECTED"`
+	Status        string `json:"status" binding:"required,oneof=APPROVED REJECTED NEEDS_INFO"`
 	Clarification string `json:"clarification"`
+	// Items lists what the user must provide, required for NEEDS_INFO.
+	Items []models.InfoItem `json:"items"`
+	// Version is the request version the reviewer looked at. If set, the
+	// decision is refused when the request has changed since.
+	Version *int `json:"version"`
 }
 
 func (h *KYCHandler) AdminVerify(c *gin.Context) {
@@ -137,10 +659,67 @@ func (h *KYCHandler) AdminVerify(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.UpdateStatus(c.Request.Context(), id, models.KYCStatus(req.Status), req.Clarification); err != nil {
+	kyc, err := h.repo.GetByID(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC record not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if req.Version != nil && *req.Version != kyc.Version {
+		c.JSON(http.StatusConflict, gin.H{"error": "KYC request has changed since it was loaded", "version": kyc.Version, "status": kyc.Status})
+		return
+	}
+
+	change := models.StatusChange{
+		To:     models.KYCStatus(req.Status),
+		Actor:  c.GetString("userID"),
+		Reason: req.Clarification,
+	}
+	if kyc.Claim.ActiveFor(change.Actor, time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "KYC request is claimed by another reviewer", "claim": kyc.Claim})
+		return
+	}
+	if err := services.CheckTransition(kyc, change, time.Now()); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrInvalidTransition) {
+			status = http.StatusConflict
+		} else if errors.Is(err, services.ErrSelfReview) {
+			status = http.StatusForbidden
+		} else if errors.Is(err, services.ErrScreeningHold) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if change.To == models.StatusNeedsInfo {
+		if len(req.Items) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "At least one requested item is required", "allowed": models.InfoItems})
+			return
+		}
+		for _, item := range req.Items {
+			if !slices.Contains(models.InfoItems, item) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown requested item " + string(item), "allowed": models.InfoItems})
+				return
+			}
+		}
+		msg := models.NewMessage(change.Actor, models.RoleReviewer, req.Clarification)
+		msg.Items = req.Items
+		err = h.repo.RequestInfo(c.Request.Context(), kyc, change, req.Items, msg)
+	} else {
+		err = h.repo.Transition(c.Request.Context(), kyc, change)
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "KYC request was decided by another reviewer"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "KYC status updated"})
+	c.JSON(http.StatusOK, gin.H{"message": "KYC status updated", "kyc": kyc})
 }
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"kyc/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxQuarantineLimit = 200
+
+// scanUploads runs the malware scanner on the files of a form field before
+// anything is stored. One infected file rejects the whole submission. On
+// rejection, or when the scanner cannot be reached, it writes the error
+// response and returns false.
+func (h *KYCHandler) scanUploads(c *gin.Context, kycID primitive.ObjectID, field string, files []*multipart.FileHeader) bool {
+	for _, file := range files {
+		data, err := readUpload(file, h.uploadPolicy.MaxFileBytes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Upload could not be read", "file": file.Filename})
+			return false
+		}
+		if data == nil {
+			continue // Too large; saving it fails with the right error
+		}
+		entry, err := h.malware.Check(c.Request.Context(), data, models.QuarantinedUpload{
+			UserID:       c.GetString("userID"),
+			KYCID:        kycID,
+			Field:        field,
+			OriginalName: file.Filename,
+			IP:           c.ClientIP(),
+			UserAgent:    c.Request.UserAgent(),
+		})
+		if err != nil {
+			fmt.Printf("Malware scan of %s failed: %v\n", file.Filename, err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Malware scanner unavailable, please try again later"})
+			return false
+		}
+		if entry != nil {
+			fmt.Printf("Quarantined %s from user %s: %s\n", file.Filename, entry.UserID, entry.Signature)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Upload rejected: the file contains malware", "file": file.Filename})
+			return false
+		}
+	}
+	return true
+}
+
+// readUpload reads an uploaded file. It returns nil without an error when
+// the file is larger than limit.
+func readUpload(fh *multipart.FileHeader, limit int64) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil || int64(len(data)) > limit {
+		return nil, err
+	}
+	return data, nil
+}
+
+// AdminQuarantine lists uploads rejected by the malware scanner, newest
+// first, optionally for one user_id.
+func (h *KYCHandler) AdminQuarantine(c *gin.Context) {
+	limit := 50
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(n, maxQuarantineLimit)
+	}
+	entries, err := h.malware.QuarantineLog(c.Request.Context(), c.Query("user_id"), int64(limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"slices"
+
+	"kyc/internal/doctypes"
+	"kyc/internal/models"
+	"kyc/internal/mrz"
+	"kyc/internal/repository"
+	"kyc/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageRequest struct {
+	Body string `json:"body" binding:"required,max=2000"`
+}
+
+// PostMessage adds the user's message to the thread of their latest request.
+func (h *KYCHandler) PostMessage(c *gin.Context) {
+	userID := c.GetString("userID")
+	var req MessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kyc, err := h.repo.GetByUserID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if kyc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC record not found"})
+		return
+	}
+
+	msg := models.NewMessage(userID, models.RoleUser, req.Body)
+	if err := h.repo.AddMessage(c.Request.Context(), kyc.ID, msg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save message"})
+		return
+	}
+	c.JSON(http.StatusCreated, msg)
+}
+
+// AdminPostMessage adds a reviewer's message to a request's thread without
+// changing its status.
+func (h *KYCHandler) AdminPostMessage(c *gin.Context) {
+	var req MessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kyc, err := h.repo.GetByID(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC record not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	msg := models.NewMessage(c.GetString("userID"), models.RoleReviewer, req.Body)
+	if err := h.repo.AddMessage(c.Request.Context(), kyc.ID, msg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save message"})
+		return
+	}
+	c.JSON(http.StatusCreated, msg)
+}
+
+// SubmitSupplement answers an information request: the user uploads the
+// requested images against their open request, which then goes back to the
+// review queue with its earlier images and history intact. Duplicate or
+// forged images send it to FRAUD_REVIEW instead.
+func (h *KYCHandler) SubmitSupplement(c *gin.Context) {
+	userID := c.GetString("userID")
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.uploadPolicy.MaxRequestBytes())
+
+	kyc, err := h.repo.GetByUserID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if kyc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC record not found"})
+		return
+	}
+	if kyc.Status != models.StatusNeedsInfo {
+		c.JSON(http.StatusConflict, gin.H{"error": "No information was requested for this KYC request", "status": kyc.Status})
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	docType, ok := doctypes.Lookup(kyc.Type, kyc.Country)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Unknown document type %s", kyc.Type)})
+		return
+	}
+	if len(form.File["images"]) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload each image in the field named after its role", "roles": docType.Roles()})
+		return
+	}
+	files, roles, err := roleUploads(form, docType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	selfies := form.File[doctypes.RoleSelfie]
+	if len(selfies) > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only one selfie can be uploaded"})
+		return
+	}
+	if len(files) == 0 && len(selfies) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No images provided"})
+		return
+	}
+	addressProofType := kyc.AddressProofType
+	if slices.Contains(roles, doctypes.RoleAddressProof) {
+		if values := form.Value["address_proof_type"]; len(values) > 0 && values[0] != "" {
+			addressProofType = values[0]
+		}
+		if addressProofType == "" {
+			addressProofType = doctypes.UtilityBill
+		}
+		t, ok := doctypes.Lookup(addressProofType, kyc.Country)
+		if !ok || !t.AddressProof {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported proof of address type %s", addressProofType)})
+			return
+		}
+		addressProofType = t.Code
+	}
+	if err := h.uploadPolicy.CheckCount(len(files)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i, file := range files {
+		if !h.scanUploads(c, kyc.ID, roles[i], []*multipart.FileHeader{file}) {
+			return
+		}
+	}
+	if !h.scanUploads(c, kyc.ID, doctypes.RoleSelfie, selfies) {
+		return
+	}
+
+	docTypes := make([]string, len(roles))
+	for i, role := range roles {
+		docTypes[i] = kyc.Type
+		if role == doctypes.RoleAddressProof {
+			docTypes[i] = addressProofType
+		}
+	}
+	imagePaths, verifications, flags, rejected, ok := h.saveAndVerify(c, files, userID, docTypes)
+	if !ok {
+		return
+	}
+	if rejected != nil {
+		// The request stays in NEEDS_INFO; the janitor removes the files.
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image rejected: Document irrelevant or not recognized as ID/Passport", "verification": rejected})
+		return
+	}
+
+	// The new identity images are checked like those of a submission; the
+	// selfie is compared with them, or a new selfie with the stored ones.
+	ctx := services.WithDocumentType(c.Request.Context(), kyc.Type)
+	identityImages := imagesExcept(imagePaths, roles, doctypes.RoleAddressProof)
+	supp := repository.Supplement{Images: imagePaths, Verifications: verifications}
+	if slices.Contains(roles, doctypes.RoleAddressProof) {
+		supp.AddressProofType = addressProofType
+	}
+	selfiePath := kyc.Selfie
+	if len(selfies) == 1 {
+		saved, err := h.uploadPolicy.Save(selfies[0], "uploads", userID)
+		if err != nil {
+			c.JSON(uploadErrorStatus(err), gin.H{"error": "Selfie rejected: " + err.Error()})
+			return
+		}
+		selfiePath = saved.Path
+		supp.Selfie = saved.Path
+		if !saved.Kind.Decodable() {
+			flags = addFlags(flags, models.FlagUnanalyzedFormat)
+		}
+	}
+	faceImages := identityImages
+	if len(faceImages) == 0 {
+		faceImages = imagesExcept(kyc.Images, kyc.ImageRoles, doctypes.RoleAddressProof)
+	}
+	if selfiePath != "" && (supp.Selfie != "" || len(identityImages) > 0) {
+		var faceFlags []string
+		supp.FaceMatch, faceFlags = h.faces.Check(ctx, selfiePath, faceImages)
+		flags = addFlags(flags, faceFlags...)
+	}
+
+	if len(identityImages) > 0 {
+		extracted := h.extractFields(ctx, identityImages)
+		if extracted != nil {
+			if kyc.Extracted != nil {
+				// Fields read from the new images win over the earlier ones.
+				extracted.Merge(kyc.Extracted)
+			}
+			var matchFlags []string
+			supp.Extracted = extracted
+			supp.FieldMatch, matchFlags = services.MatchFields(extracted, kyc.DocumentNumber, c.GetString("userName"))
+			flags = addFlags(flags, matchFlags...)
+		}
+		if kyc.Type == doctypes.Passport && kyc.MRZ == nil && extracted != nil && extracted.MRZ != "" {
+			supp.MRZ, err = services.CheckPassportMRZ(extracted.MRZ, kyc.DocumentNumber)
+			if errors.Is(err, mrz.ErrNotFound) {
+				// The model returned something that is not an MRZ.
+				flags = addFlags(flags, models.FlagMRZUnavailable)
+			} else if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Passport rejected: " + err.Error()})
+				return
+			}
+		}
+	}
+
+	hashes := hashImages(imagePaths)
+	fraudMatches, err := h.duplicates.FindImageMatches(c.Request.Context(), userID, hashes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if len(fraudMatches) > 0 {
+		flags = addFlags(flags, models.FlagDuplicateImage)
+	}
+
+	var body string
+	if values := form.Value["message"]; len(values) > 0 {
+		body = values[0]
+	}
+	msg := models.NewMessage(userID, models.RoleUser, body)
+	msg.Images = imagePaths
+
+	change := models.StatusChange{To: models.StatusPending, Actor: userID, Reason: "Requested information provided"}
+	if len(fraudMatches) > 0 || slices.Contains(flags, models.FlagForensicRisk) {
+		change.To = models.StatusFraudReview
+	}
+	if err := services.CheckTransition(kyc, change, msg.CreatedAt); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if len(kyc.ImageRoles) == len(kyc.Images) {
+		// Requests stored before roles have none; keep them aligned.
+		supp.ImageRoles = roles
+	}
+	supp.Flags = addFlags(kyc.Flags, flags...)
+	supp.FraudMatches = fraudMatches
+	supp.Message = msg
+	err = h.repo.Supplement(c.Request.Context(), kyc, change, supp)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "KYC request was changed, please reload"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update KYC request"})
+		return
+	}
+
+	if err := h.duplicates.SaveHashes(c.Request.Context(), kyc, hashes); err != nil {
+		fmt.Printf("Failed to save image hashes for %s: %v\n", kyc.ID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, kyc)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"kyc/internal/models"
+	"kyc/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultQueueLimit = 20
+	maxQueueLimit     = 100
+)
+
+// QueueItem is a queued request with its SLA age.
+type QueueItem struct {
+	*models.KYCRequest
+	AgeSeconds  int64 `json:"age_seconds"`
+	SLABreached bool  `json:"sla_breached"`
+}
+
+type QueuePage struct {
+	Items      []QueueItem `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// AdminGetQueue returns a page of the review queue.
+//
+// Query parameters: status (PENDING or FRAUD_REVIEW, default PENDING), type,
+// submitted_from and submitted_to (RFC 3339 or YYYY-MM-DD), verdict, flag
+// (repeatable), unclaimed=true, sort=oldest|newest, limit and cursor.
+func (h *KYCHandler) AdminGetQueue(c *gin.Context) {
+	now := time.Now()
+	filter := repository.QueueFilter{
+		Statuses: []models.KYCStatus{models.StatusPending},
+		Type:     strings.ToUpper(c.Query("type")),
+		Verdict:  models.Verdict(strings.ToUpper(c.Query("verdict"))),
+		Flags:    c.QueryArray("flag"),
+		Now:      now,
+		Limit:    defaultQueueLimit,
+	}
+	if status := models.KYCStatus(strings.ToUpper(c.Query("status"))); status != "" {
+		if !slices.Contains(models.ReviewStatuses, status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of PENDING, FRAUD_REVIEW"})
+			return
+		}
+		filter.Statuses = []models.KYCStatus{status}
+	}
+	if c.Query("unclaimed") == "true" {
+		filter.ExcludeClaimedBy = c.GetString("userID")
+	}
+	switch c.DefaultQuery("sort", "oldest") {
+	case "oldest":
+	case "newest":
+		filter.Newest = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be oldest or newest"})
+		return
+	}
+
+	var err error
+	if filter.SubmittedFrom, err = parseQueryTime(c.Query("submitted_from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submitted_from"})
+		return
+	}
+	if filter.SubmittedTo, err = parseQueryTime(c.Query("submitted_to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submitted_to"})
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = min(n, maxQueueLimit)
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		filter.After, err = repository.DecodeQueueCursor(cursor, filter)
+		if errors.Is(err, repository.ErrCursorMismatch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor was issued for a different sort or filters"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+	}
+
+	requests, next, err := h.repo.Queue(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	page := QueuePage{Items: make([]QueueItem, len(requests))}
+	for i := range requests {
+		page.Items[i] = QueueItem{
+			KYCRequest:  &requests[i],
+			AgeSeconds:  int64(h.queue.Age(&requests[i], now).Seconds()),
+			SLABreached: h.queue.Breached(&requests[i], now),
+		}
+	}
+	if next != nil {
+		page.NextCursor = next.Encode()
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// parseQueryTime accepts RFC 3339 timestamps and plain dates. An empty
+// value gives the zero time.
+func parseQueryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// AdminClaim gives the reviewer a lease on a queued request.
+func (h *KYCHandler) AdminClaim(c *gin.Context) {
+	kyc, err := h.queue.Claim(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC record not found"})
+	case errors.Is(err, repository.ErrClaimed), errors.Is(err, repository.ErrNotInQueue):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	default:
+		c.JSON(http.StatusOK, kyc)
+	}
+}
+
+// AdminRelease returns a claimed request to the queue.
+func (h *KYCHandler) AdminRelease(c *gin.Context) {
+	err := h.queue.Release(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC record not found"})
+	case errors.Is(err, repository.ErrClaimed):
+		c.JSON(http.StatusConflict, gin.H{"error": "KYC request is not claimed by you"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Claim released"})
+	}
+}
+
+// AdminStats returns queue sizes with SLA breaches and per-reviewer
+// throughput since the given time (default: the last 7 days).
+func (h *KYCHandler) AdminStats(c *gin.Context) {
+	now := time.Now()
+	since := now.AddDate(0, 0, -7)
+	if value := c.Query("since"); value != "" {
+		t, err := parseQueryTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since"})
+			return
+		}
+		since = t
+	}
+
+	queues, err := h.repo.QueueSummaries(c.Request.Context(), now.Add(-h.queue.SLA))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	reviewers, err := h.repo.ReviewerStats(c.Request.Context(), since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"since":       since,
+		"sla_seconds": int64(h.queue.SLA.Seconds()),
+		"queues":      queues,
+		"reviewers":   reviewers,
+	})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"fmt"
+	"mime/multipart"
+
+	"kyc/internal/doctypes"
+)
+
+// roleUploads collects the document images of a submission from the form
+// fields named after their roles, in the order the document type lists
+// them, followed by the proof of address. It returns the files and the
+// role of each.
+func roleUploads(form *multipart.Form, docType *doctypes.DocumentType) ([]*multipart.FileHeader, []string, error) {
+	var names []string
+	for _, f := range docType.Roles() {
+		names = append(names, f.Name)
+	}
+	names = append(names, doctypes.RoleAddressProof)
+
+	var files []*multipart.FileHeader
+	var roles []string
+	for _, role := range names {
+		uploaded := form.File[role]
+		if len(uploaded) > 1 {
+			return nil, nil, fmt.Errorf("only one image can be uploaded as %s", role)
+		}
+		if len(uploaded) == 1 {
+			files = append(files, uploaded[0])
+			roles = append(roles, role)
+		}
+	}
+	return files, roles, nil
+}
+
+// imagesExcept returns the images whose role is not role.
+func imagesExcept(imagePaths, roles []string, role string) []string {
+	var out []string
+	for i, path := range imagePaths {
+		if i >= len(roles) || roles[i] != role {
+			out = append(out, path)
+		}
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kyc/internal/models"
+	"kyc/internal/repository"
+	"kyc/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxScreeningLimit = 500
+
+// AdminScreeningCases lists watchlist screening cases, oldest first.
+// Query parameters: status (OPEN, CLEARED or CONFIRMED, default OPEN; ALL
+// for every case) and limit.
+func (h *KYCHandler) AdminScreeningCases(c *gin.Context) {
+	status := models.ScreeningStatus(strings.ToUpper(c.DefaultQuery("status", string(models.ScreeningOpen))))
+	switch status {
+	case models.ScreeningOpen, models.ScreeningCleared, models.ScreeningConfirmed:
+	case "ALL":
+		status = ""
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of OPEN, CLEARED, CONFIRMED, ALL"})
+		return
+	}
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(n, maxScreeningLimit)
+	}
+
+	cases, err := h.screening.Cases(c.Request.Context(), status, int64(limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, cases)
+}
+
+type ResolveScreeningRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=CLEARED CONFIRMED"`
+	Note     string `json:"note" binding:"required,max=2000"`
+}
+
+// AdminResolveScreening records a reviewer's decision on an open case.
+func (h *KYCHandler) AdminResolveScreening(c *gin.Context) {
+	var req ResolveScreeningRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sc, err := h.screening.Resolve(c.Request.Context(), c.Param("id"), c.GetString("userID"), models.ScreeningStatus(req.Decision), req.Note)
+	switch {
+	case errors.Is(err, repository.ErrCaseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Screening case not found"})
+	case errors.Is(err, services.ErrCaseResolved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSelfReview):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case sc == nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve screening case"})
+	case err != nil:
+		// The decision is stored, but the request keeps WATCHLIST_HIT and
+		// cannot be approved until the same decision is sent again.
+		log.Printf("Failed to release KYC request %s after screening case %s: %v", sc.KYCID.Hex(), sc.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decision recorded, but releasing the KYC request failed. Send the same decision again to retry.", "case": sc})
+	default:
+		c.JSON(http.StatusOK, sc)
+	}
+}
+
+// AdminWatchlists describes the loaded watchlist files.
+func (h *KYCHandler) AdminWatchlists(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"lists":     h.screening.Lists(),
+		"entries":   h.screening.Entries(),
+		"threshold": h.screening.Threshold,
+	})
+}
+
+// AdminReloadWatchlists reads the watchlist files again, e.g. after a new
+// list was published.
+func (h *KYCHandler) AdminReloadWatchlists(c *gin.Context) {
+	if err := h.screening.Reload(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to load watchlists: " + err.Error()})
+		return
+	}
+	h.AdminWatchlists(c)
+}
+
+// AdminRescreen screens all approved users against the loaded lists now.
+func (h *KYCHandler) AdminRescreen(c *gin.Context) {
+	n, err := h.screening.Rescreen(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Rescreening failed", "held": n})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"held": n})
+}
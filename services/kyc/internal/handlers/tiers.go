@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"kyc/internal/services"
+	"kyc/internal/tiers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTiers returns the KYC tiers with their requirements and limits.
+func (h *KYCHandler) ListTiers(c *gin.Context) {
+	c.JSON(http.StatusOK, h.tiers.Config())
+}
+
+// GetTier returns the caller's tier and what the next one needs.
+func (h *KYCHandler) GetTier(c *gin.Context) {
+	tier, err := h.callerTier(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, tier)
+}
+
+// ServiceUserTier lets other services look up a user's tier and limits.
+// Phone verification is asked from the auth service.
+func (h *KYCHandler) ServiceUserTier(c *gin.Context) {
+	userID := c.Param("user_id")
+	tier, err := h.tiers.Lookup(c.Request.Context(), userID)
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Tier lookup for %s failed: %v", userID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to look up tier"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "tier": tier})
+}
+
+// callerTier returns the authenticated user's tier, with the phone
+// verification the auth service reported for their token.
+func (h *KYCHandler) callerTier(c *gin.Context) (tiers.Status, error) {
+	return h.tiers.ForUser(c.Request.Context(), c.GetString("userID"), c.GetBool("phoneVerified"))
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"kyc/internal/repository"
+	"kyc/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerdictCacheHandler struct {
+	cache *services.VerdictCache
+}
+
+// NewVerdictCacheHandler creates the admin handler for the verdict cache.
+// cache is nil when the cache is disabled.
+func NewVerdictCacheHandler(cache *services.VerdictCache) *VerdictCacheHandler {
+	return &VerdictCacheHandler{cache: cache}
+}
+
+// Stats reports hits and misses since startup and the number of stored verdicts.
+func (h *VerdictCacheHandler) Stats(c *gin.Context) {
+	if h.cache == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	stats, err := h.cache.Stats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read verdict cache"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "stats": stats})
+}
+
+// Invalidate removes cached verdicts by image hash, model or prompt
+// version. Clearing the whole cache needs all=true.
+func (h *VerdictCacheHandler) Invalidate(c *gin.Context) {
+	if h.cache == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Verdict cache is disabled"})
+		return
+	}
+	filter := repository.VerdictCacheFilter{
+		SHA256:        strings.ToLower(strings.TrimSpace(c.Query("sha256"))),
+		ModelID:       strings.TrimSpace(c.Query("model_id")),
+		PromptVersion: strings.TrimSpace(c.Query("prompt_version")),
+	}
+	if filter == (repository.VerdictCacheFilter{}) && c.Query("all") != "true" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Specify sha256, model_id or prompt_version, or all=true"})
+		return
+	}
+
+	deleted, err := h.cache.Invalidate(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invalidate verdict cache"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
@@ -0,0 +1,286 @@
+// Package httprecord records HTTP exchanges with a model provider as fixture
+// files and replays them, so provider integrations can be tested without
+// network access or API keys.
+//
+// Fixtures are sanitized before they are written: credentials are dropped
+// and images in data URIs are replaced by their SHA-256, which keeps the
+// files small and free of personal data while still telling images apart.
+package httprecord
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RecordEnv, when set to 1, makes Transport record instead of replay.
+const RecordEnv = "KYC_RECORD_FIXTURES"
+
+// Fixture is one recorded exchange.
+type Fixture struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body"`
+}
+
+// Response headers kept in fixtures; the rest is noise or tracking.
+var keptHeaders = []string{"Content-Type", "Retry-After"}
+
+// Query parameters whose values are removed from recorded URLs.
+var secretParams = []string{"api_key", "key", "token", "access_token"}
+
+// Transport returns a Recorder writing to dir when RecordEnv is 1, and a
+// Replayer of the fixtures in dir otherwise.
+func Transport(dir string, real http.RoundTripper) (http.RoundTripper, error) {
+	if os.Getenv(RecordEnv) == "1" {
+		return &Recorder{Dir: dir, Transport: real}, nil
+	}
+	return Load(dir)
+}
+
+// Recorder passes requests to Transport and writes every exchange to Dir
+// as 001.json, 002.json and so on.
+type Recorder struct {
+	Dir       string
+	Transport http.RoundTripper // http.DefaultTransport if nil
+
+	mu sync.Mutex
+	n  int
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := Fixture{
+		Request: Request{Method: req.Method, URL: sanitizeURL(req.URL), Body: sanitizeBody(reqBody)},
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: map[string]string{},
+			Body:   string(respBody),
+		},
+	}
+	for _, h := range keptHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			fixture.Response.Header[h] = v
+		}
+	}
+
+	r.mu.Lock()
+	r.n++
+	name := fmt.Sprintf("%03d.json", r.n)
+	r.mu.Unlock()
+	if err := writeFixture(filepath.Join(r.Dir, name), &fixture); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return resp, nil
+}
+
+func writeFixture(path string, f *Fixture) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // Keep prompts readable
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Replayer answers requests from fixtures. A request matches a fixture with
+// the same method, URL and sanitized body. Identical requests, such as
+// retries, get the matching fixtures in recording order; the last one is
+// repeated once they are used up.
+type Replayer struct {
+	mu       sync.Mutex
+	fixtures []Fixture
+	used     []bool
+}
+
+// Load reads the fixtures in dir in file name order.
+func Load(dir string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no fixtures in %s; record them with %s=1", dir, RecordEnv)
+	}
+	sort.Strings(files)
+	r := &Replayer{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		r.fixtures = append(r.fixtures, f)
+	}
+	r.used = make([]bool, len(r.fixtures))
+	return r, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	method, u, body := req.Method, sanitizeURL(req.URL), canonicalJSON(sanitizeBody(reqBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	match := -1
+	for i, f := range r.fixtures {
+		if f.Request.Method != method || f.Request.URL != u || canonicalJSON(f.Request.Body) != body {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("httprecord: no fixture for %s %s; re-record with %s=1", method, u, RecordEnv)
+	}
+	r.used[match] = true
+
+	f := r.fixtures[match].Response
+	header := http.Header{}
+	for k, v := range f.Header {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
+
+// readBody reads *body and replaces it with a fresh reader of the same bytes.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func sanitizeURL(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+	q := clean.Query()
+	for _, p := range secretParams {
+		if q.Has(p) {
+			q.Set(p, "REDACTED")
+		}
+	}
+	clean.RawQuery = q.Encode()
+	return clean.String()
+}
+
+// sanitizeBody replaces base64 data URIs in a JSON body by their hash. Other
+// bodies are stored as a JSON string.
+func sanitizeBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return marshal(string(body))
+	}
+	return marshal(sanitizeValue(v))
+}
+
+func sanitizeValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = sanitizeValue(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = sanitizeValue(e)
+		}
+	case string:
+		if prefix, encoded, ok := strings.Cut(v, ";base64,"); ok && strings.HasPrefix(prefix, "data:") {
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				data = []byte(encoded)
+			}
+			sum := sha256.Sum256(data)
+			return prefix + ";sha256," + hex.EncodeToString(sum[:])
+		}
+	}
+	return v
+}
+
+// canonicalJSON re-encodes JSON so that formatting and key order do not
+// affect matching.
+func canonicalJSON(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	return string(marshal(v))
+}
+
+func marshal(v any) json.RawMessage {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return bytes.TrimSpace(buf.Bytes())
+}
@@ -0,0 +1,98 @@
+package httprecord
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kyc/internal/modelstub"
+)
+
+func chatRequest(t *testing.T, url, image string) *http.Request {
+	t.Helper()
+	body := `{"model": "m", "messages": [{"role": "user", "content": [{"type": "image_url", "image_url": {"url": "data:image/png;base64,` +
+		base64.StdEncoding.EncodeToString([]byte(image)) + `"}}]}]}`
+	req, err := http.NewRequest(http.MethodPost, url+"/v1/chat/completions?api_key=secret-in-query", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret-token")
+	return req
+}
+
+func roundTrip(t *testing.T, rt http.RoundTripper, req *http.Request) (int, string) {
+	t.Helper()
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server, _ := modelstub.NewServer(modelstub.RateLimited("2"), modelstub.Answer("VALID_NID"))
+	dir := t.TempDir()
+	recorder := &Recorder{Dir: dir}
+
+	var recorded []string
+	for range 2 {
+		status, body := roundTrip(t, recorder, chatRequest(t, server.URL, "front"))
+		recorded = append(recorded, body)
+		if status != http.StatusTooManyRequests && status != http.StatusOK {
+			t.Fatalf("unexpected status %d", status)
+		}
+	}
+	server.Close()
+
+	first, err := os.ReadFile(filepath.Join(dir, "001.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := string(first)
+	for _, secret := range []string{"secret-token", "secret-in-query", base64.StdEncoding.EncodeToString([]byte("front"))} {
+		if strings.Contains(fixture, secret) {
+			t.Errorf("fixture contains %q:\n%s", secret, fixture)
+		}
+	}
+	if !strings.Contains(fixture, "data:image/png;sha256,") || !strings.Contains(fixture, `"Retry-After": "2"`) {
+		t.Errorf("fixture lacks the image hash or Retry-After:\n%s", fixture)
+	}
+
+	replayer, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The retry gets the second recorded response, as it did when recording.
+	wantStatus := []int{http.StatusTooManyRequests, http.StatusOK, http.StatusOK}
+	for i, want := range wantStatus {
+		req := chatRequest(t, server.URL, "front")
+		req.Header.Set("Authorization", "Bearer other-token")
+		status, body := roundTrip(t, replayer, req)
+		if status != want {
+			t.Errorf("replay %d: status %d, want %d", i, status, want)
+		}
+		if i < len(recorded) && body != recorded[i] {
+			t.Errorf("replay %d: body %q, want %q", i, body, recorded[i])
+		}
+	}
+
+	_, err = replayer.RoundTrip(chatRequest(t, server.URL, "back"))
+	if err == nil || !strings.Contains(err.Error(), RecordEnv) {
+		t.Errorf("unmatched request: err = %v", err)
+	}
+}
+
+func TestLoadEmptyDir(t *testing.T) {
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without fixtures")
+	}
+}
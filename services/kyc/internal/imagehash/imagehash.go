@@ -0,0 +1,119 @@
+// Package imagehash computes perceptual hashes of images, so that the same
+// photo can be recognised after re-encoding, resizing or light edits.
+package imagehash
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// Hash is a 64-bit perceptual hash.
+type Hash uint64
+
+// Distance returns the Hamming distance between two hashes (0 to 64).
+func Distance(a, b Hash) int {
+	return bits.OnesCount64(uint64(a ^ b))
+}
+
+// DHash is the difference hash: each bit tells whether a pixel is brighter
+// than its right neighbour on a 9x8 grayscale thumbnail.
+func DHash(img image.Image) Hash {
+	g := grayscale(img, 9, 8)
+	var h Hash
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			h <<= 1
+			if g[y][x] < g[y][x+1] {
+				h |= 1
+			}
+		}
+	}
+	return h
+}
+
+// PHash is the DCT-based perceptual hash: the 8x8 lowest frequencies of a
+// 32x32 grayscale thumbnail, each compared with their median.
+func PHash(img image.Image) Hash {
+	const n = 32
+	g := grayscale(img, n, n)
+
+	// Separable 2D DCT-II, keeping only the 8x8 low-frequency block.
+	var rows [n][8]float64
+	for y := 0; y < n; y++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for x := 0; x < n; x++ {
+				sum += g[y][x] * math.Cos(float64((2*x+1)*u)*math.Pi/(2*n))
+			}
+			rows[y][u] = sum
+		}
+	}
+	var coeffs [64]float64
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				sum += rows[y][u] * math.Cos(float64((2*y+1)*v)*math.Pi/(2*n))
+			}
+			coeffs[v*8+u] = sum
+		}
+	}
+
+	// The DC term only reflects overall brightness; leave it out of the median.
+	sorted := slices.Clone(coeffs[1:])
+	slices.Sort(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var h Hash
+	for _, c := range coeffs {
+		h <<= 1
+		if c > median {
+			h |= 1
+		}
+	}
+	return h
+}
+
+// grayscale resamples img to w x h luminance values using area averaging.
+func grayscale(img image.Image, w, h int) [][]float64 {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	out := make([][]float64, h)
+	for y := 0; y < h; y++ {
+		out[y] = make([]float64, w)
+		y0 := b.Min.Y + y*sh/h
+		y1 := max(b.Min.Y+(y+1)*sh/h, y0+1)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*sw/w
+			x1 := max(b.Min.X+(x+1)*sw/w, x0+1)
+			var sum float64
+			var count int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, bl, _ := img.At(sx, sy).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+					count++
+				}
+			}
+			out[y][x] = sum / float64(count) / 0xFFFF
+		}
+	}
+	return out
+}
+
+// BandTag is set in every band value, so that bands never equal those of
+// the earlier layout of four 16-bit bands (all below 1<<18).
+const BandTag = 1 << 24
+
+// Bands splits a hash into eight 8-bit bands tagged with their position.
+// Two hashes within Hamming distance 7 always share at least one band,
+// which lets a database find near-duplicate candidates with an index.
+func Bands(h Hash) []int64 {
+	out := make([]int64, 8)
+	for i := range out {
+		out[i] = BandTag | int64(i)<<8 | int64((uint64(h)>>(8*i))&0xFF)
+	}
+	return out
+}
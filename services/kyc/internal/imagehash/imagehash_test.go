@@ -0,0 +1,167 @@
+package imagehash
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// scene draws a few shapes on a gradient, so that the hashes have
+// structure to pick up at every scale.
+func scene(w, h, shift int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := x * 255 / w
+			if (x-w/3)*(x-w/3)+(y-h/2)*(y-h/2) < (h/4)*(h/4) {
+				v = 230
+			}
+			if x > 2*w/3 && y > h/4 && y < 3*h/4 {
+				v = 20
+			}
+			g := uint8(min(v+shift, 255))
+			img.Set(x, y, color.RGBA{g, g, g, 255})
+		}
+	}
+	return img
+}
+
+// checkerboard has a pattern unrelated to scene.
+func checkerboard(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(40)
+			if (x*5/w+y*3/h)%2 == 0 {
+				v = 220
+			}
+			img.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+func TestHashes(t *testing.T) {
+	original := scene(320, 200, 0)
+	tests := []struct {
+		name    string
+		img     image.Image
+		maxDist int // Inclusive
+		minDist int
+	}{
+		{"identical", scene(320, 200, 0), 0, 0},
+		{"resized", scene(160, 100, 0), 4, 0},
+		{"brighter", scene(320, 200, 15), 4, 0},
+		{"different", checkerboard(320, 200), 64, 16},
+	}
+	hashes := []struct {
+		name string
+		fn   func(image.Image) Hash
+	}{
+		{"PHash", PHash},
+		{"DHash", DHash},
+	}
+	for _, hf := range hashes {
+		want := hf.fn(original)
+		for _, tt := range tests {
+			d := Distance(want, hf.fn(tt.img))
+			if d < tt.minDist || d > tt.maxDist {
+				t.Errorf("%s %s: distance = %d, want [%d, %d]", hf.name, tt.name, d, tt.minDist, tt.maxDist)
+			}
+		}
+	}
+}
+
+func TestHashUsesImageBounds(t *testing.T) {
+	full := scene(320, 200, 0).(*image.RGBA)
+	padded := image.NewRGBA(image.Rect(-10, -10, 330, 210))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 320; x++ {
+			padded.Set(x, y, full.At(x, y))
+		}
+	}
+	sub := padded.SubImage(image.Rect(0, 0, 320, 200))
+	if PHash(sub) != PHash(full) || DHash(sub) != DHash(full) {
+		t.Error("hashes of a sub-image differ from the same pixels at the origin")
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b Hash
+		want int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0b1010, 0b0101, 4},
+		{0, ^Hash(0), 64},
+		{0xFFFF0000FFFF0000, 0x0000FFFF0000FFFF, 64},
+		{0x8000000000000001, 0x0000000000000001, 1},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%x, %x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Distance(tt.b, tt.a); got != tt.want {
+			t.Errorf("Distance(%x, %x) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestBands(t *testing.T) {
+	got := Bands(0x0807060504030201)
+	want := []int64{
+		BandTag | 0<<8 | 0x01, BandTag | 1<<8 | 0x02, BandTag | 2<<8 | 0x03, BandTag | 3<<8 | 0x04,
+		BandTag | 4<<8 | 0x05, BandTag | 5<<8 | 0x06, BandTag | 6<<8 | 0x07, BandTag | 7<<8 | 0x08,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Bands = %x, want %x", got, want)
+	}
+	// The same byte in different positions must not give the same band.
+	same := Bands(0x4242424242424242)
+	slices.Sort(same)
+	if len(slices.Compact(same)) != 8 {
+		t.Errorf("bands of repeated bytes collide: %x", same)
+	}
+}
+
+// TestBandsRecall checks that hashes within distance 7 always share a band,
+// and that flipping one bit in every band can make them share none.
+func TestBandsRecall(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 2000 {
+		a := Hash(rng.Uint64())
+		b := a
+		for _, bit := range rng.Perm(64)[:1+rng.Intn(7)] {
+			b ^= 1 << bit
+		}
+		if !shareBand(a, b) {
+			t.Fatalf("%016x and %016x (distance %d) share no band", a, b, Distance(a, b))
+		}
+	}
+
+	a := Hash(rng.Uint64())
+	b := a ^ 0x0101010101010101
+	if shareBand(a, b) {
+		t.Errorf("%016x and %016x differ in every band but share one", a, b)
+	}
+}
+
+func shareBand(a, b Hash) bool {
+	bb := Bands(b)
+	return slices.ContainsFunc(Bands(a), func(v int64) bool { return slices.Contains(bb, v) })
+}
+
+func TestBandsAboveLegacyLayout(t *testing.T) {
+	// The earlier layout stored int64(i)<<16 | 16-bit band, i < 4.
+	const legacyMax = 3<<16 | 0xFFFF
+	for _, h := range []Hash{0, ^Hash(0), 0x0123456789ABCDEF} {
+		for _, b := range Bands(h) {
+			if b <= legacyMax {
+				t.Errorf("band %x of %016x overlaps the legacy layout", b, h)
+			}
+		}
+	}
+}
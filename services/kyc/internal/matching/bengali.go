@@ -0,0 +1,80 @@
+package matching
+
+import "strings"
+
+var bengaliVowels = map[rune]string{
+	'অ': "o", 'আ': "a", 'ই': "i", 'ঈ': "i", 'উ': "u", 'ঊ': "u",
+	'ঋ': "ri", 'এ': "e", 'ঐ': "oi", 'ও': "o", 'ঔ': "ou",
+}
+
+var bengaliVowelSigns = map[rune]string{
+	'া': "a", 'ি': "i", 'ী': "i", 'ু': "u", 'ূ': "u",
+	'ৃ': "ri", 'ে': "e", 'ৈ': "oi", 'ো': "o", 'ৌ': "ou",
+}
+
+var bengaliConsonants = map[rune]string{
+	'ক': "k", 'খ': "kh", 'গ': "g", 'ঘ': "gh", 'ঙ': "ng",
+	'চ': "ch", 'ছ': "chh", 'জ': "j", 'ঝ': "jh", 'ঞ': "n",
+	'ট': "t", 'ঠ': "th", 'ড': "d", 'ঢ': "dh", 'ণ': "n",
+	'ত': "t", 'থ': "th", 'দ': "d", 'ধ': "dh", 'ন': "n",
+	'প': "p", 'ফ': "f", 'ব': "b", 'ভ': "bh", 'ম': "m",
+	'য': "j", 'র': "r", 'ল': "l", 'শ': "sh", 'ষ': "sh",
+	'স': "s", 'হ': "h", '\u09dc': "r", '\u09dd': "rh", '\u09df': "y",
+	'ৎ': "t",
+}
+
+const (
+	bengaliHasanta  = '্'
+	bengaliAnusvara = 'ং'
+	bengaliVisarga  = 'ঃ'
+	bengaliNukta    = '়'
+)
+
+// TransliterateBengali converts Bengali script to a rough Latin spelling.
+// Text in other scripts is returned unchanged. The result is only meant for
+// fuzzy comparison, not for display.
+func TransliterateBengali(s string) string {
+	// Normalise decomposed forms of ড়, ঢ়, য় to their precomposed runes.
+	s = strings.NewReplacer("\u09a1\u09bc", "\u09dc", "\u09a2\u09bc", "\u09dd", "\u09af\u09bc", "\u09df").Replace(s)
+	runes := []rune(s)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if v, ok := bengaliVowels[r]; ok {
+			b.WriteString(v)
+			continue
+		}
+		if v, ok := bengaliVowelSigns[r]; ok {
+			b.WriteString(v)
+			continue
+		}
+		if c, ok := bengaliConsonants[r]; ok {
+			b.WriteString(c)
+			// Add the inherent vowel unless a sign, hasanta or the end of
+			// the word follows.
+			if i+1 < len(runes) {
+				next := runes[i+1]
+				_, isSign := bengaliVowelSigns[next]
+				_, isConsonant := bengaliConsonants[next]
+				if !isSign && next != bengaliHasanta && isConsonant {
+					b.WriteString("o")
+				}
+			}
+			continue
+		}
+		switch r {
+		case bengaliHasanta, bengaliNukta:
+		case bengaliAnusvara:
+			b.WriteString("ng")
+		case bengaliVisarga:
+			b.WriteString("h")
+		default:
+			if r >= '০' && r <= '৯' {
+				b.WriteRune('0' + (r - '০'))
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
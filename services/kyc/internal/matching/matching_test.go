@@ -0,0 +1,125 @@
+package matching
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTransliterateBengali(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"করিম", "korim"},
+		{"রহমান", "rohoman"},
+		{"আক্তার", "aktar"},
+		{"বাংলা", "bangla"},
+		{"১২৩৪৫", "12345"},
+		{"Karim", "Karim"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := TransliterateBengali(tt.in); got != tt.want {
+			t.Errorf("TransliterateBengali(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Md. Abdul Karim", []string{"abdul", "karim"}},
+		{"MOHAMMED Hossain", []string{"muhamad", "hasain"}},
+		{"Mst. Rahima Khatun", []string{"rahima", "katun"}},
+		{"Mr.", nil},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64 // Inclusive
+		max  float64 // Inclusive
+	}{
+		{"Abdul Karim", "Abdul Karim", 1, 1},
+		{"Md. Abdul Karim", "Abdul Karim", 1, 1},
+		{"Karim Abdul", "Abdul Karim", 1, 1},
+		{"Mohammad Hossain", "Muhammad Hussain", 0.9, 1},
+		{"Abdur Rahman", "Abdurrahman", 0.95, 1},
+		{"করিম", "Korim", 1, 1},
+		{"Abdul Karim Chowdhury", "Abdul Karim", 0.85, 0.95},
+		{"Abdul Karim", "Nusrat Jahan", 0, 0.6},
+		{"", "Abdul Karim", 0, 0},
+		{"Md.", "Mr.", 0, 0},
+	}
+	for _, tt := range tests {
+		got := NameSimilarity(tt.a, tt.b)
+		if got < tt.min || got > tt.max {
+			t.Errorf("NameSimilarity(%q, %q) = %.3f, want [%.2f, %.2f]", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"abc", "", 0},
+		{"abc", "xyz", 0},
+		{"martha", "martha", 1},
+		{"martha", "marhta", 0.961},
+		{"dwayne", "duane", 0.840},
+		{"dixon", "dicksonx", 0.813},
+	}
+	for _, tt := range tests {
+		got := JaroWinkler(tt.a, tt.b)
+		if got < tt.want-0.001 || got > tt.want+0.001 {
+			t.Errorf("JaroWinkler(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeDocumentNumber(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"1234 567 890", "1234567890"},
+		{"ab-123.45", "AB12345"},
+		{"১২৩ ৪৫৬", "123456"},
+		{" -/ ", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeDocumentNumber(tt.in); got != tt.want {
+			t.Errorf("NormalizeDocumentNumber(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentNumberSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"1234567890", "1234 567 890", 1},
+		{"১২৩৪৫৬৭৮৯০", "1234567890", 1},
+		{"1234567890", "1234567899", 0.9},
+		{"1234567890", "123456789", 0.9},
+		{"AB123", "XY987", 0},
+		{"", "1234567890", 0},
+		{"--", "--", 0},
+	}
+	for _, tt := range tests {
+		got := DocumentNumberSimilarity(tt.a, tt.b)
+		if got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("DocumentNumberSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
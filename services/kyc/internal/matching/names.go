@@ -0,0 +1,153 @@
+// Package matching compares data extracted from identity documents with the
+// data a user submitted.
+package matching
+
+import (
+	"strings"
+	"unicode"
+)
+
+// titleTokens are dropped before comparing names. "Md." and "Mst." are
+// abbreviations printed on most Bangladeshi NIDs but often left out by users.
+var titleTokens = map[string]bool{
+	"md": true, "mohd": true, "moh": true, "mst": true, "most": true, "mosammat": true, "mossammat": true,
+	"mr": true, "mrs": true, "ms": true, "miss": true, "dr": true, "late": true,
+}
+
+// muhammadVariants are common spellings of the same given name.
+var muhammadVariants = map[string]bool{
+	"mohammad": true, "mohammed": true, "muhammed": true, "mohamed": true,
+	"mohamad": true, "muhamad": true, "mohammod": true, "muhammod": true,
+}
+
+// phoneticFolds smooth over the usual ways one Bangla sound is written in
+// Latin script (e.g. "Hossain"/"Hussain", "Rahman"/"Rohman", "Zaman"/"Jaman").
+var phoneticFolds = []struct{ from, to string }{
+	{"ee", "i"}, {"oo", "u"}, {"ou", "u"}, {"aa", "a"},
+	{"ph", "f"}, {"bh", "b"}, {"dh", "d"}, {"gh", "g"}, {"jh", "j"},
+	{"kh", "k"}, {"th", "t"}, {"sh", "s"}, {"ch", "c"},
+	{"z", "j"}, {"y", "i"}, {"w", "u"}, {"q", "k"}, {"o", "a"}, {"e", "i"},
+}
+
+// NormalizeName returns the comparable tokens of a name written in Latin or
+// Bengali script.
+func NormalizeName(name string) []string {
+	name = strings.ToLower(TransliterateBengali(name))
+
+	var tokens []string
+	for _, tok := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if titleTokens[tok] {
+			continue
+		}
+		if muhammadVariants[tok] {
+			tok = "muhammad"
+		}
+		tokens = append(tokens, foldToken(tok))
+	}
+	return tokens
+}
+
+func foldToken(tok string) string {
+	for _, f := range phoneticFolds {
+		tok = strings.ReplaceAll(tok, f.from, f.to)
+	}
+	// Collapse doubled letters ("Hossain" -> "hasain").
+	var b strings.Builder
+	var prev rune
+	for _, r := range tok {
+		if r != prev {
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	return b.String()
+}
+
+// NameSimilarity scores two names between 0 and 1. Word order, titles and
+// transliteration differences are ignored.
+func NameSimilarity(a, b string) float64 {
+	ta, tb := NormalizeName(a), NormalizeName(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	if len(ta) > len(tb) {
+		ta, tb = tb, ta
+	}
+
+	// Match each token of the shorter name against its best counterpart.
+	var total float64
+	for _, x := range ta {
+		best := 0.0
+		for _, y := range tb {
+			if s := JaroWinkler(x, y); s > best {
+				best = s
+			}
+		}
+		total += best
+	}
+	tokenScore := total / float64(len(ta))
+	// Penalise names that only share part of their words.
+	tokenScore *= 0.85 + 0.15*float64(len(ta))/float64(len(tb))
+
+	// When word counts differ, compare joined forms too, so "Abdur Rahman"
+	// matches "Abdurrahman".
+	if len(ta) != len(tb) {
+		return max(tokenScore, JaroWinkler(strings.Join(ta, ""), strings.Join(tb, "")))
+	}
+	return tokenScore
+}
+
+// JaroWinkler returns the Jaro-Winkler similarity of two strings.
+func JaroWinkler(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(len(ra), len(rb))/2 - 1
+	window = max(window, 0)
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo, hi := max(0, i-window), min(len(rb), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, k := 0, 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[k] {
+			k++
+		}
+		if ra[i] != rb[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
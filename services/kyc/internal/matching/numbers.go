@@ -0,0 +1,52 @@
+package matching
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeDocumentNumber uppercases a document number and drops spaces,
+// dashes and other separators. Bengali digits are converted to ASCII.
+func NormalizeDocumentNumber(s string) string {
+	var b strings.Builder
+	for _, r := range TransliterateBengali(s) {
+		if unicode.IsDigit(r) || ('A' <= unicode.ToUpper(r) && unicode.ToUpper(r) <= 'Z') {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// DocumentNumberSimilarity scores two document numbers between 0 and 1,
+// based on the edit distance of their normalized forms.
+func DocumentNumberSimilarity(a, b string) float64 {
+	na, nb := NormalizeDocumentNumber(a), NormalizeDocumentNumber(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+	longest := max(len(na), len(nb))
+	return 1 - float64(levenshtein(na, nb))/float64(longest)
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -1,10 +1,12 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -65,8 +67,10 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 
 		// Parse user ID from response
 		var userResp struct {
-			ID    string `json:"id"`
-			Email string `json:"email"`
+			ID            string `json:"id"`
+			Name          string `json:"name"`
+			Email         string `json:"email"`
+			PhoneVerified bool   `json:"phone_verified"`
 		}
 		if err := json.NewDecoder(resp.Body).Decode(&userResp); err != nil {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse auth response"})
@@ -74,6 +78,37 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		}
 
 		c.Set("userID", userResp.ID)
+		c.Set("userName", userResp.Name)
+		c.Set("phoneVerified", userResp.PhoneVerified)
+		c.Next()
+	}
+}
+
+// RequireServiceToken admits calls from other services that present token
+// as a bearer token. With an empty token every call is refused.
+func RequireServiceToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service access is not configured"})
+			return
+		}
+		presented := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid service token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole admits authenticated users whose ID is one of userIDs. It
+// must run after RequireAuth. With no user IDs every call is refused.
+func RequireRole(role string, userIDs []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(userIDs, c.GetString("userID")) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Requires the %s role", role)})
+			return
+		}
 		c.Next()
 	}
 }
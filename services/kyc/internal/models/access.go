@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Document access actions.
+const (
+	AccessIssued = "ISSUED" // A signed URL was created
+	AccessViewed = "VIEWED" // The document was streamed
+)
+
+// DocumentAccess is one entry of the document access log.
+type DocumentAccess struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	KYCID     primitive.ObjectID `bson:"kyc_id" json:"kyc_id"`
+	Image     int                `bson:"image" json:"image"` // Index into KYCRequest.Images
+	Reviewer  string             `bson:"reviewer" json:"reviewer"`
+	Action    string             `bson:"action" json:"action"`
+	IP        string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	At        time.Time          `bson:"at" json:"at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// SelfieIndex addresses the selfie of a request where document images are
+// addressed by their index, e.g. in signed document URLs and the access log.
+const SelfieIndex = -1
+
+// Flags raised by the selfie check.
+const (
+	FlagFaceMismatch         = "FACE_MISMATCH"
+	FlagLivenessFailed       = "LIVENESS_FAILED"
+	FlagFaceMatchUnavailable = "FACE_MATCH_UNAVAILABLE"
+)
+
+// Liveness signals: reasons a selfie may not show a live person.
+const (
+	LivenessLowResolution  = "LOW_RESOLUTION"
+	LivenessBlurry         = "BLURRY"
+	LivenessUnderexposed   = "UNDEREXPOSED"
+	LivenessOverexposed    = "OVEREXPOSED"
+	LivenessLowContrast    = "LOW_CONTRAST" // Typical of photos of prints and screens
+	LivenessSameAsDocument = "SAME_AS_DOCUMENT"
+)
+
+// FaceMatch compares the selfie with the portrait on the document.
+type FaceMatch struct {
+	Provider      string    `bson:"provider" json:"provider"`
+	ModelID       string    `bson:"model_id,omitempty" json:"model_id,omitempty"`
+	PromptVersion string    `bson:"prompt_version,omitempty" json:"prompt_version,omitempty"`
+	Document      string    `bson:"document" json:"document"`     // Image the portrait was taken from
+	Similarity    float64   `bson:"similarity" json:"similarity"` // 0 to 1
+	Match         bool      `bson:"match" json:"match"`
+	Reason        string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	RawOutput     string    `bson:"raw_output,omitempty" json:"raw_output,omitempty"`
+	Liveness      *Liveness `bson:"liveness,omitempty" json:"liveness,omitempty"`
+	CheckedAt     time.Time `bson:"checked_at" json:"checked_at"`
+}
+
+// Liveness is the result of the passive liveness checks on the selfie.
+type Liveness struct {
+	Score      float64  `bson:"score" json:"score"` // 1 when no signal was raised
+	Passed     bool     `bson:"passed" json:"passed"`
+	Signals    []string `bson:"signals,omitempty" json:"signals,omitempty"`
+	Sharpness  float64  `bson:"sharpness" json:"sharpness"`   // Variance of the Laplacian
+	Brightness float64  `bson:"brightness" json:"brightness"` // Mean luma, 0 to 255
+	Contrast   float64  `bson:"contrast" json:"contrast"`     // Standard deviation of luma
+}
@@ -0,0 +1,47 @@
+package models
+
+// FlagForensicRisk is raised when an image's forensic score is high enough
+// to send the submission to fraud review.
+const FlagForensicRisk = "FORENSIC_HIGH_RISK"
+
+// Forensic signals: signs that an image was edited or shows a screen.
+const (
+	ForensicEditingSoftware  = "EDITING_SOFTWARE"
+	ForensicScreenshot       = "SCREENSHOT"
+	ForensicScreenMoire      = "SCREEN_MOIRE"
+	ForensicELAInconsistent  = "ELA_INCONSISTENT"
+	ForensicHeavyCompression = "HEAVY_COMPRESSION"
+	ForensicLowResolution    = "LOW_RESOLUTION"
+	ForensicNoCameraMetadata = "NO_CAMERA_METADATA"
+)
+
+// Forensic risk levels.
+const (
+	RiskLow    = "LOW"
+	RiskMedium = "MEDIUM"
+	RiskHigh   = "HIGH"
+)
+
+// ForensicSignal is one finding of the forensic analysis. Score is its
+// weight in the image's risk score, from 0 to 1.
+type ForensicSignal struct {
+	Name   string  `bson:"name" json:"name"`
+	Score  float64 `bson:"score" json:"score"`
+	Detail string  `bson:"detail,omitempty" json:"detail,omitempty"`
+}
+
+// Forensics is the local tampering and screen analysis of one image, run on
+// the file as uploaded, before its metadata is stripped.
+type Forensics struct {
+	Score       float64          `bson:"score" json:"score"` // 0 to 1, combined from the signals
+	Risk        string           `bson:"risk" json:"risk"`
+	Signals     []ForensicSignal `bson:"signals,omitempty" json:"signals,omitempty"`
+	Format      string           `bson:"format" json:"format"`
+	Width       int              `bson:"width" json:"width"`
+	Height      int              `bson:"height" json:"height"`
+	Software    string           `bson:"software,omitempty" json:"software,omitempty"` // EXIF, XMP or PNG text
+	Camera      string           `bson:"camera,omitempty" json:"camera,omitempty"`     // EXIF make and model
+	JPEGQuality int              `bson:"jpeg_quality,omitempty" json:"jpeg_quality,omitempty"`
+	ELARatio    float64          `bson:"ela_ratio,omitempty" json:"ela_ratio,omitempty"`     // Worst block error over the median
+	MoireRatio  float64          `bson:"moire_ratio,omitempty" json:"moire_ratio,omitempty"` // Strongest periodic peak over its surroundings
+}
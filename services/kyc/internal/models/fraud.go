@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of duplicate identity evidence.
+const (
+	MatchDocumentNumber = "DOCUMENT_NUMBER"
+	MatchImage          = "IMAGE"
+)
+
+// FraudMatch links a submission to another user's KYC request that uses the
+// same document number or a near-identical image.
+type FraudMatch struct {
+	Kind     string             `bson:"kind" json:"kind"`
+	KYCID    primitive.ObjectID `bson:"kyc_id" json:"kyc_id"`
+	UserID   string             `bson:"user_id" json:"user_id"`
+	Image    string             `bson:"image,omitempty" json:"image,omitempty"`       // Our image that matched
+	Distance int                `bson:"distance,omitempty" json:"distance,omitempty"` // Hamming distance for image matches
+}
+
+// ImageHash stores the perceptual hashes of one uploaded image.
+type ImageHash struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	KYCID     primitive.ObjectID `bson:"kyc_id" json:"kyc_id"`
+	UserID    string             `bson:"user_id" json:"user_id"`
+	Image     string             `bson:"image" json:"image"`
+	PHash     int64              `bson:"phash" json:"phash"`
+	DHash     int64              `bson:"dhash" json:"dhash"`
+	Bands     []int64            `bson:"bands" json:"-"` // pHash and dHash bands, see imagehash.Bands
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -3,25 +3,102 @@ package models
 import (
 	"time"
 
+	"kyc/internal/mrz"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type KYCStatus string
 
 const (
-	StatusPending  KYCStatus = "PENDING"
-	StatusApproved KYCStatus = "APPROVED"
-	StatusRejected KYCStatus = "REJECTED"
+	StatusPending KYCStatus = "PENDING"
+	// StatusNeedsInfo waits for the user to provide more information.
+	StatusNeedsInfo KYCStatus = "NEEDS_INFO"
+	StatusApproved  KYCStatus = "APPROVED"
+	StatusRejected  KYCStatus = "REJECTED"
+	// StatusExpired is an approval whose document has expired, or a request
+	// the user never completed.
+	StatusExpired KYCStatus = "EXPIRED"
+	// StatusFraudReview holds submissions that reuse another user's
+	// document number or image, away from the ordinary pending queue.
+	StatusFraudReview KYCStatus = "FRAUD_REVIEW"
 )
 
 type KYCRequest struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID         string             `bson:"user_id" json:"user_id"`
-	Type           string             `bson:"type" json:"type" binding:"required,oneof=NID PASSPORT"`
-	DocumentNumber string             `bson:"document_number" json:"document_number" binding:"required"`
+	Attempt        int                `bson:"attempt" json:"attempt"` // 1, then incremented on each resubmission
+	Type           string             `bson:"type" json:"type"`       // A code from the doctypes registry
+	Country        string             `bson:"country" json:"country"`
+	DocumentNumber string             `bson:"document_number" json:"document_number"` // Normalized
+	DateOfBirth    string             `bson:"date_of_birth,omitempty" json:"date_of_birth,omitempty"`
 	Images         []string           `bson:"images" json:"images"` // URLs or file paths
-	Status         KYCStatus          `bson:"status" json:"status"`
-	Clarification  string             `bson:"clarification,omitempty" json:"clarification,omitempty"`
-	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+	// ImageRoles holds the role of each image (front, back, data_page,
+	// address_proof), same order. Supplements have no role, and requests
+	// stored before roles have none at all.
+	ImageRoles       []string             `bson:"image_roles,omitempty" json:"image_roles,omitempty"`
+	AddressProofType string               `bson:"address_proof_type,omitempty" json:"address_proof_type,omitempty"`
+	Verifications    []VerificationResult `bson:"verifications,omitempty" json:"verifications,omitempty"` // One per image, same order
+	Flags            []string             `bson:"flags,omitempty" json:"flags,omitempty"`
+	Extracted        *ExtractedFields     `bson:"extracted,omitempty" json:"extracted,omitempty"`
+	FieldMatch       *FieldMatch          `bson:"field_match,omitempty" json:"field_match,omitempty"`
+	MRZ              *mrz.MRZ             `bson:"mrz,omitempty" json:"mrz,omitempty"`
+	FraudMatches     []FraudMatch         `bson:"fraud_matches,omitempty" json:"fraud_matches,omitempty"`
+	Selfie           string               `bson:"selfie,omitempty" json:"selfie,omitempty"`
+	FaceMatch        *FaceMatch           `bson:"face_match,omitempty" json:"face_match,omitempty"`
+	// ScreenedAs is who the request was screened against the watchlists as.
+	ScreenedAs *ScreeningSubject `bson:"screened_as,omitempty" json:"screened_as,omitempty"`
+	// IdentityOwner marks the request that holds the (type, document_number)
+	// pair in the unique index. Duplicates under fraud review do not.
+	IdentityOwner bool           `bson:"identity_owner" json:"-"`
+	Status        KYCStatus      `bson:"status" json:"status"`
+	StatusHistory []StatusChange `bson:"status_history,omitempty" json:"status_history,omitempty"`
+	Clarification string         `bson:"clarification,omitempty" json:"clarification,omitempty"`
+	// RequestedItems are the items asked for by the open NEEDS_INFO request.
+	RequestedItems []InfoItem `bson:"requested_items,omitempty" json:"requested_items,omitempty"`
+	Messages       []Message  `bson:"messages,omitempty" json:"messages,omitempty"`
+	// AutoRejected marks an attempt rejected by the document verifier
+	// rather than a reviewer. Such attempts can be appealed.
+	AutoRejected bool    `bson:"auto_rejected,omitempty" json:"auto_rejected,omitempty"`
+	Appeal       *Appeal `bson:"appeal,omitempty" json:"appeal,omitempty"`
+	// QueuedAt is when the request last entered a review queue, for SLA tracking.
+	QueuedAt time.Time `bson:"queued_at,omitempty" json:"queued_at,omitempty"`
+	Claim    *Claim    `bson:"claim,omitempty" json:"claim,omitempty"`
+	// Version is incremented on every status change, for optimistic concurrency.
+	Version   int       `bson:"version" json:"version"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Claim is a reviewer's lease on a request in the review queue.
+type Claim struct {
+	Reviewer  string    `bson:"reviewer" json:"reviewer"`
+	ClaimedAt time.Time `bson:"claimed_at" json:"claimed_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// ActiveFor reports whether the claim blocks reviewer at now.
+func (c *Claim) ActiveFor(reviewer string, now time.Time) bool {
+	return c != nil && c.Reviewer != reviewer && now.Before(c.ExpiresAt)
+}
+
+// ReviewStatuses are the statuses waiting for a reviewer.
+var ReviewStatuses = []KYCStatus{StatusPending, StatusFraudReview}
+
+// Appeal is the user's request for a human review of an automatic rejection.
+type Appeal struct {
+	Note string    `bson:"note" json:"note"`
+	At   time.Time `bson:"at" json:"at"`
+}
+
+// ActorSystem is the actor of status changes made by the service itself.
+const ActorSystem = "system"
+
+// StatusChange is one entry of a request's status history.
+type StatusChange struct {
+	From   KYCStatus `bson:"from,omitempty" json:"from,omitempty"`
+	To     KYCStatus `bson:"to" json:"to"`
+	Actor  string    `bson:"actor" json:"actor"` // Reviewer's user ID or ActorSystem
+	Reason string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	At     time.Time `bson:"at" json:"at"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InfoItem is something a reviewer can ask the user for in NEEDS_INFO.
+type InfoItem string
+
+const (
+	InfoClearerFront      InfoItem = "CLEARER_FRONT"
+	InfoClearerBack       InfoItem = "CLEARER_BACK"
+	InfoBackSide          InfoItem = "BACK_SIDE"
+	InfoDifferentDocument InfoItem = "DIFFERENT_DOCUMENT"
+	InfoOther             InfoItem = "OTHER" // Described in the message body
+)
+
+// InfoItems lists the accepted InfoItem values.
+var InfoItems = []InfoItem{InfoClearerFront, InfoClearerBack, InfoBackSide, InfoDifferentDocument, InfoOther}
+
+// Message author roles.
+const (
+	RoleReviewer = "reviewer"
+	RoleUser     = "user"
+	RoleSystem   = "system"
+)
+
+// Message is one entry of the conversation between reviewers and the user
+// on a KYC request.
+type Message struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Author    string             `bson:"author" json:"author"` // User ID
+	Role      string             `bson:"role" json:"role"`
+	Body      string             `bson:"body,omitempty" json:"body,omitempty"`
+	Items     []InfoItem         `bson:"items,omitempty" json:"items,omitempty"`   // Requested by a reviewer
+	Images    []string           `bson:"images,omitempty" json:"images,omitempty"` // Supplied by the user
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+func NewMessage(author, role, body string) Message {
+	return Message{ID: primitive.NewObjectID(), Author: author, Role: role, Body: body, CreatedAt: time.Now()}
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuarantinedUpload is the audit entry of an upload the malware scanner
+// flagged. The submission it came with was rejected and the file is kept,
+// unreachable from the API, in the quarantine directory.
+type QuarantinedUpload struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       string             `bson:"user_id" json:"user_id"`
+	KYCID        primitive.ObjectID `bson:"kyc_id,omitempty" json:"kyc_id,omitempty"` // Set for supplements to an existing request
+	Field        string             `bson:"field" json:"field"`                       // Form field: images or selfie
+	OriginalName string             `bson:"original_name" json:"original_name"`
+	Size         int64              `bson:"size" json:"size"`
+	SHA256       string             `bson:"sha256" json:"sha256"`
+	Scanner      string             `bson:"scanner" json:"scanner"`
+	Signature    string             `bson:"signature" json:"signature"`
+	Path         string             `bson:"path,omitempty" json:"path,omitempty"` // Empty if the file could not be kept
+	IP           string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent    string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	At           time.Time          `bson:"at" json:"at"`
+}
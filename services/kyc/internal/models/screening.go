@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FlagWatchlistHit is set while a request has an open screening case. It
+// blocks approval until a reviewer clears the case.
+const FlagWatchlistHit = "WATCHLIST_HIT"
+
+// Kinds of watchlist.
+const (
+	WatchlistSanctions = "SANCTIONS"
+	WatchlistPEP       = "PEP" // Politically exposed persons
+)
+
+type ScreeningStatus string
+
+const (
+	ScreeningOpen      ScreeningStatus = "OPEN"
+	ScreeningCleared   ScreeningStatus = "CLEARED"   // False positive
+	ScreeningConfirmed ScreeningStatus = "CONFIRMED" // The user is the listed person
+)
+
+// What started a screening.
+const (
+	ScreeningOnSubmission = "SUBMISSION"
+	ScreeningOnRescreen   = "RESCREEN"
+	ScreeningOnAppeal     = "APPEAL"
+)
+
+// How a listed date of birth compares with the subject's.
+const (
+	DOBExact    = "EXACT"
+	DOBYear     = "YEAR"
+	DOBMismatch = "MISMATCH"
+	DOBUnknown  = "UNKNOWN" // Either side has no date of birth
+)
+
+// ScreeningSubject is who was screened.
+type ScreeningSubject struct {
+	Names       []string `bson:"names" json:"names"` // Extracted and profile name
+	DateOfBirth string   `bson:"date_of_birth,omitempty" json:"date_of_birth,omitempty"`
+	Country     string   `bson:"country,omitempty" json:"country,omitempty"`
+}
+
+// ScreeningHit is one watchlist entry that matched the subject.
+type ScreeningHit struct {
+	List        string   `bson:"list" json:"list"` // e.g. UN, OFAC_SDN
+	Kind        string   `bson:"kind" json:"kind"`
+	EntryID     string   `bson:"entry_id" json:"entry_id"` // Reference in the list
+	ListedName  string   `bson:"listed_name" json:"listed_name"`
+	MatchedName string   `bson:"matched_name" json:"matched_name"` // Name or alias that matched
+	NameScore   float64  `bson:"name_score" json:"name_score"`
+	DOBMatch    string   `bson:"dob_match" json:"dob_match"`
+	Score       float64  `bson:"score" json:"score"`
+	Programs    []string `bson:"programs,omitempty" json:"programs,omitempty"`
+}
+
+// Key identifies the listed entry across screenings.
+func (h ScreeningHit) Key() string {
+	return h.List + ":" + h.EntryID
+}
+
+// ScreeningCase groups the hits of one screening for a reviewer.
+type ScreeningCase struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"user_id"`
+	KYCID      primitive.ObjectID `bson:"kyc_id" json:"kyc_id"`
+	Trigger    string             `bson:"trigger" json:"trigger"` // SUBMISSION, RESCREEN or APPEAL
+	Subject    ScreeningSubject   `bson:"subject" json:"subject"`
+	Hits       []ScreeningHit     `bson:"hits" json:"hits"`
+	Status     ScreeningStatus    `bson:"status" json:"status"`
+	ResolvedBy string             `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	Note       string             `bson:"note,omitempty" json:"note,omitempty"`
+	ResolvedAt time.Time          `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
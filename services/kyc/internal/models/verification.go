@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+type Verdict string
+
+const (
+	VerdictValid        Verdict = "VALID"
+	VerdictInvalid      Verdict = "INVALID"
+	VerdictManualReview Verdict = "MANUAL_REVIEW"
+)
+
+// Flags raised on a KYC request for the reviewer's attention.
+const (
+	FlagDocumentTypeMismatch   = "DOCUMENT_TYPE_MISMATCH"
+	FlagDocumentNumberMismatch = "DOCUMENT_NUMBER_MISMATCH"
+	FlagNameMismatch           = "NAME_MISMATCH"
+	FlagDocumentExpired        = "DOCUMENT_EXPIRED"
+	FlagMRZUnavailable         = "MRZ_UNAVAILABLE"
+	FlagDuplicateDocument      = "DUPLICATE_DOCUMENT"
+	FlagDuplicateImage         = "DUPLICATE_IMAGE"
+	FlagAutoRejected           = "AUTO_REJECTED" // Rejected by the verifier, without a reviewer
+	FlagAppealed               = "APPEALED"
+	FlagUnanalyzedFormat       = "UNANALYZED_FORMAT" // A HEIC or PDF upload skipped the model, hashing and forensics
+)
+
+// VerificationResult is the outcome of verifying a single document image.
+type VerificationResult struct {
+	Image         string     `bson:"image" json:"image"`
+	Provider      string     `bson:"provider" json:"provider"`
+	ModelID       string     `bson:"model_id,omitempty" json:"model_id,omitempty"`
+	PromptVersion string     `bson:"prompt_version,omitempty" json:"prompt_version,omitempty"`
+	Verdict       Verdict    `bson:"verdict" json:"verdict"`
+	DocumentType  string     `bson:"document_type,omitempty" json:"document_type,omitempty"` // e.g. NID, PASSPORT
+	Label         string     `bson:"label,omitempty" json:"label,omitempty"`                 // raw label, e.g. VALID_NID
+	Confidence    float64    `bson:"confidence" json:"confidence"`
+	Reason        string     `bson:"reason,omitempty" json:"reason,omitempty"`
+	RawOutput     string     `bson:"raw_output,omitempty" json:"raw_output,omitempty"`
+	LatencyMS     int64      `bson:"latency_ms" json:"latency_ms"`
+	VerifiedAt    time.Time  `bson:"verified_at" json:"verified_at"`
+	ImageSHA256   string     `bson:"image_sha256,omitempty" json:"image_sha256,omitempty"` // of the image as sent to the model
+	CacheHit      bool       `bson:"cache_hit,omitempty" json:"cache_hit,omitempty"`       // reused from the verdict cache; VerifiedAt is the original time
+	Forensics     *Forensics `bson:"forensics,omitempty" json:"forensics,omitempty"`
+}
+
+// IsValid reports whether the image may proceed to the review queue.
+func (r *VerificationResult) IsValid() bool {
+	return r.Verdict == VerdictValid || r.Verdict == VerdictManualReview
+}
+
+// ExtractedFields holds the fields read from a document image.
+// Dates are formatted as YYYY-MM-DD.
+type ExtractedFields struct {
+	FullName       string    `bson:"full_name,omitempty" json:"full_name,omitempty"`
+	DateOfBirth    string    `bson:"date_of_birth,omitempty" json:"date_of_birth,omitempty"`
+	DocumentNumber string    `bson:"document_number,omitempty" json:"document_number,omitempty"`
+	ExpiryDate     string    `bson:"expiry_date,omitempty" json:"expiry_date,omitempty"`
+	MRZ            string    `bson:"mrz,omitempty" json:"mrz,omitempty"`
+	Provider       string    `bson:"provider" json:"provider"`
+	ModelID        string    `bson:"model_id,omitempty" json:"model_id,omitempty"`
+	PromptVersion  string    `bson:"prompt_version,omitempty" json:"prompt_version,omitempty"`
+	RawOutput      string    `bson:"raw_output,omitempty" json:"raw_output,omitempty"`
+	ExtractedAt    time.Time `bson:"extracted_at" json:"extracted_at"`
+}
+
+// Merge fills empty fields from other, e.g. the back side of an NID.
+func (f *ExtractedFields) Merge(other *ExtractedFields) {
+	if f.FullName == "" {
+		f.FullName = other.FullName
+	}
+	if f.DateOfBirth == "" {
+		f.DateOfBirth = other.DateOfBirth
+	}
+	if f.DocumentNumber == "" {
+		f.DocumentNumber = other.DocumentNumber
+	}
+	if f.ExpiryDate == "" {
+		f.ExpiryDate = other.ExpiryDate
+	}
+	if f.MRZ == "" {
+		f.MRZ = other.MRZ
+	}
+}
+
+// FieldMatch scores extracted fields against the submitted data, from 0 to 1.
+// A nil score means the field could not be compared.
+type FieldMatch struct {
+	DocumentNumberScore *float64 `bson:"document_number_score,omitempty" json:"document_number_score,omitempty"`
+	NameScore           *float64 `bson:"name_score,omitempty" json:"name_score,omitempty"`
+	ProfileName         string   `bson:"profile_name,omitempty" json:"profile_name,omitempty"`
+}
@@ -0,0 +1,191 @@
+// Package modelstub is a local stand-in for an OpenAI-compatible chat
+// completions endpoint with vision support. It answers from canned texts
+// keyed by the SHA-256 of the image in the request, so evaluations can run
+// without network access. Script plays a fixed sequence of replies,
+// including provider failures, for tests of the retry paths.
+package modelstub
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultAnswer is returned for images without a canned answer.
+const DefaultAnswer = `{"label": "IRRELEVANT", "confidence": 0, "reason": "no canned answer for this image"}`
+
+// Handler serves POST requests to any path as chat completions.
+type Handler struct {
+	Answers map[string]string // By ImageKey of the image sent
+	Default string            // DefaultAnswer if empty
+
+	requests atomic.Int64
+}
+
+// ImageKey identifies an image by the bytes sent to the model.
+func ImageKey(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Requests returns the number of requests served.
+func (h *Handler) Requests() int64 {
+	return h.requests.Load()
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.requests.Add(1)
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Messages []struct {
+			Content []struct {
+				Type     string `json:"type"`
+				ImageURL struct {
+					URL string `json:"url"`
+				} `json:"image_url"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error": "invalid JSON"}`, http.StatusBadRequest)
+		return
+	}
+
+	answer := h.Default
+	if answer == "" {
+		answer = DefaultAnswer
+	}
+	for _, m := range req.Messages {
+		for _, part := range m.Content {
+			if part.Type != "image_url" {
+				continue
+			}
+			_, encoded, _ := strings.Cut(part.ImageURL.URL, ";base64,")
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				http.Error(w, `{"error": "invalid image data URI"}`, http.StatusBadRequest)
+				return
+			}
+			if a, ok := h.Answers[ImageKey(data)]; ok {
+				answer = a
+			}
+		}
+	}
+	WriteAnswer(w, answer)
+}
+
+// WriteAnswer writes text as the content of a chat completion.
+func WriteAnswer(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, completion(text))
+}
+
+func completion(text string) string {
+	data, _ := json.Marshal(map[string]any{
+		"object": "chat.completion",
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": text},
+			"finish_reason": "stop",
+		}},
+	})
+	return string(data)
+}
+
+// Reply is one scripted response of a Script.
+type Reply struct {
+	Status int
+	Header map[string]string
+	Body   string
+}
+
+// Answer is a successful completion with text as the model's answer.
+func Answer(text string) Reply {
+	return Reply{Status: http.StatusOK, Header: map[string]string{"Content-Type": "application/json"}, Body: completion(text)}
+}
+
+// Chatty is a successful completion that wraps text in prose and a code
+// fence, as chat models tend to do despite the prompt.
+func Chatty(text string) Reply {
+	return Answer("Sure! Here is my analysis of the document.\n\n```json\n" + text + "\n```\n\nLet me know if you need anything else.")
+}
+
+// RateLimited is a 429 response with the given Retry-After header.
+func RateLimited(retryAfter string) Reply {
+	return Reply{
+		Status: http.StatusTooManyRequests,
+		Header: map[string]string{"Content-Type": "application/json", "Retry-After": retryAfter},
+		Body:   `{"error": "Rate limit reached"}`,
+	}
+}
+
+// ModelLoading is the 503 the Hugging Face router returns while a model is
+// being loaded.
+func ModelLoading(estimatedSeconds float64) Reply {
+	return Reply{
+		Status: http.StatusServiceUnavailable,
+		Header: map[string]string{"Content-Type": "application/json"},
+		Body:   fmt.Sprintf(`{"error": "Model is currently loading", "estimated_time": %g}`, estimatedSeconds),
+	}
+}
+
+// Malformed is a 200 response whose body is cut off mid-JSON.
+func Malformed() Reply {
+	return Reply{Status: http.StatusOK, Header: map[string]string{"Content-Type": "application/json"}, Body: `{"choices": [{"message": {"content": "VALID_N`}
+}
+
+// Script replies to successive requests with its replies in order,
+// repeating the last one when they run out.
+type Script struct {
+	mu       sync.Mutex
+	replies  []Reply
+	requests [][]byte
+}
+
+func NewScript(replies ...Reply) *Script {
+	return &Script{replies: replies}
+}
+
+// NewServer starts an httptest server playing replies. The caller must
+// close it.
+func NewServer(replies ...Reply) (*httptest.Server, *Script) {
+	script := NewScript(replies...)
+	return httptest.NewServer(script), script
+}
+
+// Requests returns the bodies of the requests received so far.
+func (s *Script) Requests() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *Script) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, body)
+	reply := Reply{Status: http.StatusInternalServerError, Body: `{"error": "script is empty"}`}
+	if len(s.replies) > 0 {
+		reply = s.replies[min(len(s.requests), len(s.replies))-1]
+	}
+	s.mu.Unlock()
+
+	for k, v := range reply.Header {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(reply.Status)
+	io.WriteString(w, reply.Body)
+}
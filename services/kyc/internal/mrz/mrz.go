@@ -0,0 +1,289 @@
+// Package mrz parses the machine-readable zone of travel documents as
+// specified in ICAO Doc 9303 and validates its check digits.
+package mrz
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Format is the MRZ layout.
+type Format string
+
+const (
+	TD1 Format = "TD1" // ID cards: 3 lines of 30 characters
+	TD2 Format = "TD2" // Older ID cards and visas: 2 lines of 36 characters
+	TD3 Format = "TD3" // Passports: 2 lines of 44 characters
+)
+
+// ErrNotFound is returned when the text contains no MRZ.
+var ErrNotFound = errors.New("no machine-readable zone found")
+
+// ChecksumError lists the fields whose check digit did not match.
+type ChecksumError struct {
+	Fields []string
+}
+
+func (e *ChecksumError) Error() string {
+	return "MRZ check digit mismatch: " + strings.Join(e.Fields, ", ")
+}
+
+// MRZ holds the fields of a parsed machine-readable zone. Dates are
+// formatted as YYYY-MM-DD.
+type MRZ struct {
+	Format         Format   `bson:"format" json:"format"`
+	DocumentCode   string   `bson:"document_code" json:"document_code"`
+	IssuingState   string   `bson:"issuing_state" json:"issuing_state"`
+	Surname        string   `bson:"surname" json:"surname"`
+	GivenNames     string   `bson:"given_names" json:"given_names"`
+	DocumentNumber string   `bson:"document_number" json:"document_number"`
+	Nationality    string   `bson:"nationality" json:"nationality"`
+	DateOfBirth    string   `bson:"date_of_birth" json:"date_of_birth"`
+	Sex            string   `bson:"sex" json:"sex"`
+	ExpiryDate     string   `bson:"expiry_date" json:"expiry_date"`
+	OptionalData   string   `bson:"optional_data,omitempty" json:"optional_data,omitempty"`
+	OptionalData2  string   `bson:"optional_data_2,omitempty" json:"optional_data_2,omitempty"`
+	Lines          []string `bson:"lines" json:"lines"`
+}
+
+// FullName returns the given names followed by the surname.
+func (m *MRZ) FullName() string {
+	return strings.TrimSpace(m.GivenNames + " " + m.Surname)
+}
+
+// Parse finds an MRZ in free text, such as OCR or model output, and parses
+// it. If the MRZ is well formed but a check digit fails, the parsed MRZ is
+// returned together with a *ChecksumError.
+func Parse(text string) (*MRZ, error) {
+	lines := findLines(text)
+	if lines == nil {
+		return nil, ErrNotFound
+	}
+
+	var m *MRZ
+	var failed []string
+	switch len(lines[0]) {
+	case 44:
+		m, failed = parseTD3(lines)
+	case 36:
+		m, failed = parseTD2(lines)
+	default:
+		m, failed = parseTD1(lines)
+	}
+	m.Lines = lines
+	if len(failed) > 0 {
+		return m, &ChecksumError{Fields: failed}
+	}
+	return m, nil
+}
+
+// findLines returns the first run of consecutive lines forming a TD1, TD2 or TD3 zone.
+func findLines(text string) []string {
+	var candidates []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.ToUpper(strings.Join(strings.Fields(line), ""))
+		line = strings.NewReplacer("«", "<", "‹", "<", "`", "").Replace(line)
+		line = strings.Trim(line, "\"'")
+		if line != "" && strings.Trim(line, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789<") == "" {
+			candidates = append(candidates, line)
+		} else {
+			candidates = append(candidates, "")
+		}
+	}
+
+	for i := range candidates {
+		n := len(candidates[i])
+		want := map[int]int{44: 2, 36: 2, 30: 3}[n]
+		if want == 0 || i+want > len(candidates) {
+			continue
+		}
+		ok := true
+		for _, l := range candidates[i : i+want] {
+			ok = ok && len(l) == n
+		}
+		if ok {
+			return candidates[i : i+want]
+		}
+	}
+	return nil
+}
+
+func parseTD3(lines []string) (*MRZ, []string) {
+	l1, l2 := lines[0], lines[1]
+	m := &MRZ{
+		Format:         TD3,
+		DocumentCode:   field(l1[0:2]),
+		IssuingState:   field(l1[2:5]),
+		DocumentNumber: field(l2[0:9]),
+		Nationality:    field(l2[10:13]),
+		Sex:            field(l2[20:21]),
+		OptionalData:   field(l2[28:42]),
+	}
+	m.Surname, m.GivenNames = names(l1[5:44])
+	m.DateOfBirth = birthDate(numeric(l2[13:19]))
+	m.ExpiryDate = expiryDate(numeric(l2[21:27]))
+
+	c := checker{}
+	c.check("document_number", l2[0:9], l2[9])
+	c.check("date_of_birth", numeric(l2[13:19]), l2[19])
+	c.check("expiry_date", numeric(l2[21:27]), l2[27])
+	if strings.Trim(l2[28:42], "<") != "" || (l2[42] != '<' && l2[42] != '0') {
+		c.check("personal_number", l2[28:42], l2[42])
+	}
+	c.check("composite", l2[0:10]+numeric(l2[13:20])+numeric(l2[21:28])+l2[28:43], l2[43])
+	return m, c.failed
+}
+
+func parseTD2(lines []string) (*MRZ, []string) {
+	l1, l2 := lines[0], lines[1]
+	m := &MRZ{
+		Format:         TD2,
+		DocumentCode:   field(l1[0:2]),
+		IssuingState:   field(l1[2:5]),
+		DocumentNumber: field(l2[0:9]),
+		Nationality:    field(l2[10:13]),
+		Sex:            field(l2[20:21]),
+		OptionalData:   field(l2[28:35]),
+	}
+	m.Surname, m.GivenNames = names(l1[5:36])
+	m.DateOfBirth = birthDate(numeric(l2[13:19]))
+	m.ExpiryDate = expiryDate(numeric(l2[21:27]))
+
+	c := checker{}
+	numberCheck := l2[9]
+	numberData := l2[0:9]
+	if numberCheck == '<' {
+		// Long document numbers continue in the optional data field,
+		// terminated by their check digit.
+		numberData, numberCheck, m.OptionalData = extendedNumber(l2[0:9], l2[28:35])
+		m.DocumentNumber = field(numberData)
+	}
+	c.check("document_number", numberData, numberCheck)
+	c.check("date_of_birth", numeric(l2[13:19]), l2[19])
+	c.check("expiry_date", numeric(l2[21:27]), l2[27])
+	c.check("composite", l2[0:10]+numeric(l2[13:20])+numeric(l2[21:28])+l2[28:35], l2[35])
+	return m, c.failed
+}
+
+func parseTD1(lines []string) (*MRZ, []string) {
+	l1, l2, l3 := lines[0], lines[1], lines[2]
+	m := &MRZ{
+		Format:         TD1,
+		DocumentCode:   field(l1[0:2]),
+		IssuingState:   field(l1[2:5]),
+		DocumentNumber: field(l1[5:14]),
+		OptionalData:   field(l1[15:30]),
+		Sex:            field(l2[7:8]),
+		Nationality:    field(l2[15:18]),
+		OptionalData2:  field(l2[18:29]),
+	}
+	m.Surname, m.GivenNames = names(l3)
+	m.DateOfBirth = birthDate(numeric(l2[0:6]))
+	m.ExpiryDate = expiryDate(numeric(l2[8:14]))
+
+	c := checker{}
+	numberCheck := l1[14]
+	numberData := l1[5:14]
+	if numberCheck == '<' {
+		numberData, numberCheck, m.OptionalData = extendedNumber(l1[5:14], l1[15:30])
+		m.DocumentNumber = field(numberData)
+	}
+	c.check("document_number", numberData, numberCheck)
+	c.check("date_of_birth", numeric(l2[0:6]), l2[6])
+	c.check("expiry_date", numeric(l2[8:14]), l2[14])
+	c.check("composite", l1[5:30]+numeric(l2[0:7])+numeric(l2[8:15])+l2[18:29], l2[29])
+	return m, c.failed
+}
+
+// extendedNumber joins a document number longer than 9 characters with its
+// continuation in the optional data and returns the check digit that ends it.
+func extendedNumber(head, optional string) (string, byte, string) {
+	end := strings.IndexByte(optional, '<')
+	if end <= 0 {
+		return head, '<', field(optional)
+	}
+	return head + optional[:end-1], optional[end-1], field(optional[end:])
+}
+
+type checker struct {
+	failed []string
+}
+
+func (c *checker) check(name, data string, digit byte) {
+	if CheckDigit(data) != numeric(string(digit))[0] {
+		c.failed = append(c.failed, name)
+	}
+}
+
+// CheckDigit computes the ICAO 9303 check digit of data: characters are
+// valued 0-9 for digits, 10-35 for A-Z and 0 for '<', weighted 7, 3, 1.
+func CheckDigit(data string) byte {
+	weights := [3]int{7, 3, 1}
+	sum := 0
+	for i := 0; i < len(data); i++ {
+		ch := data[i]
+		var v int
+		switch {
+		case ch >= '0' && ch <= '9':
+			v = int(ch - '0')
+		case ch >= 'A' && ch <= 'Z':
+			v = int(ch-'A') + 10
+		}
+		sum += v * weights[i%3]
+	}
+	return byte('0' + sum%10)
+}
+
+// numeric fixes letters that OCR commonly confuses with digits, for fields
+// that may only contain digits.
+func numeric(s string) string {
+	return strings.NewReplacer("O", "0", "Q", "0", "D", "0", "I", "1", "L", "1", "Z", "2", "S", "5", "B", "8").Replace(s)
+}
+
+func field(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(strings.TrimRight(s, "<"), "<", " "))
+}
+
+func names(s string) (surname, given string) {
+	parts := strings.SplitN(strings.TrimRight(s, "<"), "<<", 2)
+	surname = field(parts[0])
+	if len(parts) == 2 {
+		given = field(parts[1])
+	}
+	return surname, given
+}
+
+// birthDate resolves a YYMMDD birth date, assuming it is not in the future.
+func birthDate(yymmdd string) string {
+	t, err := time.Parse("060102", yymmdd)
+	if err != nil {
+		return ""
+	}
+	if t.After(time.Now()) {
+		t = t.AddDate(-100, 0, 0)
+	}
+	return t.Format("2006-01-02")
+}
+
+// expiryDate resolves a YYMMDD expiry date. Documents are valid for at most
+// a few decades, so the date is placed within 50 years of today.
+func expiryDate(yymmdd string) string {
+	t, err := time.Parse("060102", yymmdd)
+	if err != nil {
+		return ""
+	}
+	now := time.Now()
+	for t.Before(now.AddDate(-50, 0, 0)) {
+		t = t.AddDate(100, 0, 0)
+	}
+	for t.After(now.AddDate(50, 0, 0)) {
+		t = t.AddDate(-100, 0, 0)
+	}
+	return t.Format("2006-01-02")
+}
+
+// String returns the MRZ lines joined by newlines.
+func (m *MRZ) String() string {
+	return strings.Join(m.Lines, "\n")
+}
@@ -0,0 +1,173 @@
+package mrz
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Specimens from ICAO Doc 9303 parts 4, 5 and 6.
+const (
+	td1 = "I<UTOD231458907<<<<<<<<<<<<<<<\n" +
+		"7408122F1204159UTO<<<<<<<<<<<6\n" +
+		"ERIKSSON<<ANNA<MARIA<<<<<<<<<<"
+	td1Long = "I<UTOD23145890<7349<<<<<<<<<<<\n" +
+		"7408122F1204159UTO<<<<<<<<<<<6\n" +
+		"ERIKSSON<<ANNA<MARIA<<<<<<<<<<"
+	td2 = "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<\n" +
+		"D231458907UTO7408122F1204159<<<<<<<6"
+	td3 = "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+		"L898902C36UTO7408122F1204159ZE184226B<<<<<10"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want MRZ
+	}{
+		{"TD1", td1, MRZ{Format: TD1, DocumentCode: "I", DocumentNumber: "D23145890"}},
+		{"TD1 long number", td1Long, MRZ{Format: TD1, DocumentCode: "I", DocumentNumber: "D23145890734"}},
+		{"TD2", td2, MRZ{Format: TD2, DocumentCode: "I", DocumentNumber: "D23145890"}},
+		{"TD3", td3, MRZ{Format: TD3, DocumentCode: "P", DocumentNumber: "L898902C3", OptionalData: "ZE184226B"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.text)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got.Format != tt.want.Format || got.DocumentCode != tt.want.DocumentCode ||
+			got.DocumentNumber != tt.want.DocumentNumber || got.OptionalData != tt.want.OptionalData {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+		if got.IssuingState != "UTO" || got.Nationality != "UTO" || got.Sex != "F" {
+			t.Errorf("%s: state %q, nationality %q, sex %q", tt.name, got.IssuingState, got.Nationality, got.Sex)
+		}
+		if got.Surname != "ERIKSSON" || got.GivenNames != "ANNA MARIA" || got.FullName() != "ANNA MARIA ERIKSSON" {
+			t.Errorf("%s: name %q / %q", tt.name, got.Surname, got.GivenNames)
+		}
+		if got.DateOfBirth != "1974-08-12" || got.ExpiryDate != "2012-04-15" {
+			t.Errorf("%s: born %s, expires %s", tt.name, got.DateOfBirth, got.ExpiryDate)
+		}
+		if got.String() != tt.text {
+			t.Errorf("%s: String() = %q", tt.name, got.String())
+		}
+	}
+}
+
+// corrupt replaces the character at pos on the given line.
+func corrupt(text string, line, pos int, ch byte) string {
+	lines := strings.Split(text, "\n")
+	b := []byte(lines[line])
+	b[pos] = ch
+	lines[line] = string(b)
+	return strings.Join(lines, "\n")
+}
+
+func TestParseCheckDigits(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"TD1 document number", corrupt(td1, 0, 14, '8'), []string{"document_number", "composite"}},
+		{"TD1 long document number", corrupt(td1Long, 0, 18, '8'), []string{"document_number", "composite"}},
+		{"TD1 date of birth", corrupt(td1, 1, 6, '3'), []string{"date_of_birth", "composite"}},
+		{"TD1 expiry date", corrupt(td1, 1, 14, '0'), []string{"expiry_date", "composite"}},
+		{"TD1 composite", corrupt(td1, 1, 29, '7'), []string{"composite"}},
+		{"TD1 number digit", corrupt(td1, 0, 6, '3'), []string{"document_number", "composite"}},
+		{"TD2 document number", corrupt(td2, 1, 9, '8'), []string{"document_number", "composite"}},
+		{"TD2 date of birth", corrupt(td2, 1, 19, '3'), []string{"date_of_birth", "composite"}},
+		{"TD2 expiry date", corrupt(td2, 1, 27, '0'), []string{"expiry_date", "composite"}},
+		{"TD2 composite", corrupt(td2, 1, 35, '7'), []string{"composite"}},
+		{"TD3 document number", corrupt(td3, 1, 9, '7'), []string{"document_number", "composite"}},
+		{"TD3 date of birth", corrupt(td3, 1, 19, '3'), []string{"date_of_birth", "composite"}},
+		{"TD3 expiry date", corrupt(td3, 1, 27, '0'), []string{"expiry_date", "composite"}},
+		{"TD3 personal number", corrupt(td3, 1, 42, '2'), []string{"personal_number", "composite"}},
+		{"TD3 composite", corrupt(td3, 1, 43, '1'), []string{"composite"}},
+		{"TD3 birth date digit", corrupt(td3, 1, 14, '5'), []string{"date_of_birth", "composite"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.text)
+		var ce *ChecksumError
+		if !errors.As(err, &ce) {
+			t.Errorf("%s: err = %v, want a *ChecksumError", tt.name, err)
+			continue
+		}
+		if !slices.Equal(ce.Fields, tt.want) {
+			t.Errorf("%s: failed fields %v, want %v", tt.name, ce.Fields, tt.want)
+		}
+		if got == nil || got.Surname != "ERIKSSON" {
+			t.Errorf("%s: the parsed MRZ was not returned with the error: %+v", tt.name, got)
+		}
+	}
+}
+
+func TestParseOCRNoise(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"surrounding text", "The machine-readable zone reads:\n\n" + td3 + "\n\nEnd of document."},
+		{"lower case and spaces", strings.ToLower(strings.ReplaceAll(td3, "<<<<<", "< < < < < "))},
+		{"guillemets", strings.ReplaceAll(td3, "<<", "««")},
+		{"quotes and backticks", "```\n\"" + strings.ReplaceAll(td3, "\n", "\"\n\"") + "\"\n```"},
+		{"letters in dates", corrupt(corrupt(td3, 1, 15, 'O'), 1, 21, 'I')},
+		{"CRLF", strings.ReplaceAll(td3, "\n", "\r\n")},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.text)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got.DocumentNumber != "L898902C3" || got.DateOfBirth != "1974-08-12" || got.ExpiryDate != "2012-04-15" {
+			t.Errorf("%s: got %+v", tt.name, got)
+		}
+	}
+}
+
+func TestParseNotFound(t *testing.T) {
+	lines1 := strings.Split(td1, "\n")
+	lines3 := strings.Split(td3, "\n")
+	tests := map[string]string{
+		"empty":           "",
+		"prose":           "This image does not contain a machine-readable zone.",
+		"one TD3 line":    lines3[0],
+		"two TD1 lines":   lines1[0] + "\n" + lines1[1],
+		"mixed lengths":   lines3[0] + "\n" + lines1[1],
+		"separated lines": lines3[0] + "\nnoise\n" + lines3[1],
+		"invalid chars":   lines3[0] + "\n" + strings.Replace(lines3[1], "UTO", "U#O", 1),
+		"short line":      lines3[0] + "\n" + lines3[1][:43],
+		"binary":          "\x00\xff\xfe<<<\x01",
+		"filler only":     strings.Repeat("<", 44) + "\n" + strings.Repeat("<", 43),
+	}
+	for name, text := range tests {
+		if got, err := Parse(text); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: Parse = %+v, %v, want ErrNotFound", name, got, err)
+		}
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		data string
+		want byte
+	}{
+		{"", '0'},
+		{"<<<<<<", '0'},
+		{"L898902C3", '6'},
+		{"740812", '2'},
+		{"120415", '9'},
+		{"D23145890", '7'},
+		{"ZE184226B<<<<<", '1'},
+		{"520727", '3'},
+	}
+	for _, tt := range tests {
+		if got := CheckDigit(tt.data); got != tt.want {
+			t.Errorf("CheckDigit(%q) = %c, want %c", tt.data, got, tt.want)
+		}
+	}
+}
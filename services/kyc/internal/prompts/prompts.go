@@ -0,0 +1,325 @@
+// Package prompts is the registry of versioned model prompts. Each prompt is
+// a text file with a header and a text/template body:
+//
+//	version: classify-v3
+//	task: classify
+//	document_type: PASSPORT
+//	max_tokens: 150
+//	labels: VALID_PASSPORT=PASSPORT, IRRELEVANT=
+//	---
+//	Is this the data page of a passport? ... one of: {{.Labels}}.
+//
+// A version may have one prompt per document type besides the default one
+// without document_type. The built-in prompts are always loaded; files in
+// a prompt directory add versions. A version never changes once defined,
+// since stored results and cached verdicts refer to prompts by version.
+package prompts
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Tasks a prompt can be used for.
+const (
+	TaskClassify  = "classify"
+	TaskExtract   = "extract"
+	TaskFaceMatch = "face_match"
+)
+
+// Versions used when none is configured.
+const (
+	DefaultClassify  = "classify-v3"
+	DefaultExtract   = "extract-v1"
+	DefaultFaceMatch = "face-v1"
+)
+
+var ErrUnknownVersion = errors.New("unknown prompt version")
+
+//go:embed builtin/*.txt
+var builtin embed.FS
+
+// Label is one answer a classification prompt allows, and the
+// KYCRequest.Type it stands for. An empty DocumentType means the image is
+// not an accepted document.
+type Label struct {
+	Name         string
+	DocumentType string
+}
+
+// Template is one loaded prompt.
+type Template struct {
+	Version      string
+	Task         string
+	DocumentType string // Empty for the default prompt of the version
+	MaxTokens    int
+	Labels       []Label // Output schema of classification prompts
+	Text         string  // Rendered prompt
+	Source       string  // File it was loaded from
+}
+
+// ID identifies the exact prompt in stored results: the version, followed
+// by the document type for type-specific prompts.
+func (t *Template) ID() string {
+	if t.DocumentType == "" {
+		return t.Version
+	}
+	return t.Version + "/" + t.DocumentType
+}
+
+// LabelType returns the document type for a label of the prompt's schema.
+func (t *Template) LabelType(label string) (string, bool) {
+	for _, l := range t.Labels {
+		if l.Name == label {
+			return l.DocumentType, true
+		}
+	}
+	return "", false
+}
+
+// Arm is one version of an A/B split with its relative weight.
+type Arm struct {
+	Version string
+	Weight  int
+}
+
+// Split selects between prompt versions.
+type Split []Arm
+
+// ParseSplit parses "classify-v3" or "classify-v3:90,classify-v4:10".
+// A version without a weight has weight 1.
+func ParseSplit(s string) (Split, error) {
+	var split Split
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		arm := Arm{Version: part, Weight: 1}
+		if version, weight, ok := strings.Cut(part, ":"); ok {
+			w, err := strconv.Atoi(strings.TrimSpace(weight))
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight in %q", part)
+			}
+			arm = Arm{Version: strings.TrimSpace(version), Weight: w}
+		}
+		split = append(split, arm)
+	}
+	if len(split) == 0 {
+		return nil, errors.New("no prompt version given")
+	}
+	return split, nil
+}
+
+// pick returns the version for key. The same key always gets the same
+// version, so a retried or cached image does not switch arms.
+func (s Split) pick(key string) string {
+	total := 0
+	for _, arm := range s {
+		total += arm.Weight
+	}
+	if total == 0 {
+		return s[0].Version
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	n := int(h.Sum32() % uint32(total))
+	for _, arm := range s {
+		if n < arm.Weight {
+			return arm.Version
+		}
+		n -= arm.Weight
+	}
+	return s[len(s)-1].Version
+}
+
+// Registry holds the loaded prompts and the active split for each task.
+type Registry struct {
+	templates map[string]*Template // By version and document type
+	active    map[string]Split     // By task
+}
+
+// Load reads the built-in prompts and then those in dir, which may be empty.
+// The defaults are active until Activate is called.
+func Load(dir string) (*Registry, error) {
+	r := &Registry{templates: map[string]*Template{}, active: map[string]Split{}}
+	if err := r.loadFS(builtin, "builtin"); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := r.loadFS(os.DirFS(dir), "."); err != nil {
+			return nil, err
+		}
+	}
+	r.active[TaskClassify] = Split{{Version: DefaultClassify, Weight: 1}}
+	r.active[TaskExtract] = Split{{Version: DefaultExtract, Weight: 1}}
+	r.active[TaskFaceMatch] = Split{{Version: DefaultFaceMatch, Weight: 1}}
+	return r, nil
+}
+
+// Default returns a registry with only the built-in prompts.
+func Default() *Registry {
+	r, err := Load("")
+	if err != nil {
+		panic(err) // The built-in prompts are part of the binary
+	}
+	return r
+}
+
+func (r *Registry) loadFS(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.txt"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		t, err := parse(strings.ReplaceAll(string(data), "\r\n", "\n"))
+		if err != nil {
+			return fmt.Errorf("prompt %s: %w", file, err)
+		}
+		t.Source = file
+		key := t.Version + "|" + t.DocumentType
+		if prev, ok := r.templates[key]; ok {
+			return fmt.Errorf("prompt %s: %s is already defined in %s; give the changed prompt a new version", file, t.ID(), prev.Source)
+		}
+		r.templates[key] = t
+	}
+	return nil
+}
+
+func parse(data string) (*Template, error) {
+	header, body, ok := strings.Cut(data, "\n---\n")
+	if !ok {
+		return nil, errors.New("missing --- after the header")
+	}
+	t := &Template{}
+	scanner := bufio.NewScanner(strings.NewReader(header))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header line %q", line)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "version":
+			t.Version = value
+		case "task":
+			t.Task = value
+		case "document_type":
+			t.DocumentType = strings.ToUpper(value)
+		case "max_tokens":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid max_tokens %q", value)
+			}
+			t.MaxTokens = n
+		case "labels":
+			for _, l := range strings.Split(value, ",") {
+				name, docType, _ := strings.Cut(strings.TrimSpace(l), "=")
+				if name == "" {
+					continue
+				}
+				t.Labels = append(t.Labels, Label{Name: strings.ToUpper(name), DocumentType: strings.TrimSpace(docType)})
+			}
+		default:
+			return nil, fmt.Errorf("unknown header %q", key)
+		}
+	}
+
+	switch {
+	case t.Version == "":
+		return nil, errors.New("version is required")
+	case t.Task != TaskClassify && t.Task != TaskExtract && t.Task != TaskFaceMatch:
+		return nil, fmt.Errorf("unknown task %q", t.Task)
+	case t.Task == TaskClassify && len(t.Labels) == 0:
+		return nil, errors.New("classify prompts need labels")
+	}
+	if t.MaxTokens == 0 {
+		t.MaxTokens = 300
+	}
+
+	tmpl, err := template.New(t.Version).Option("missingkey=error").Parse(strings.TrimSpace(body))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(t.Labels))
+	for i, l := range t.Labels {
+		names[i] = l.Name
+	}
+	var text strings.Builder
+	err = tmpl.Execute(&text, map[string]string{
+		"Labels":       strings.Join(names, ", "),
+		"DocumentType": t.DocumentType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.Text = text.String()
+	return t, nil
+}
+
+// Activate sets the versions used for task. Every version must have a
+// default prompt for the task.
+func (r *Registry) Activate(task string, split Split) error {
+	for _, arm := range split {
+		t, ok := r.templates[arm.Version+"|"]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownVersion, arm.Version)
+		}
+		if t.Task != task {
+			return fmt.Errorf("prompt %s is for %s, not %s", arm.Version, t.Task, task)
+		}
+	}
+	r.active[task] = split
+	return nil
+}
+
+// Select returns the prompt for task and the declared document type. key
+// places the caller in the A/B split. The version's prompt for the
+// document type is preferred over its default prompt.
+func (r *Registry) Select(task, documentType, key string) (*Template, error) {
+	split, ok := r.active[task]
+	if !ok {
+		return nil, fmt.Errorf("no prompt active for %s", task)
+	}
+	version := split.pick(key)
+	if t, ok := r.templates[version+"|"+strings.ToUpper(documentType)]; ok && documentType != "" && t.Task == task {
+		return t, nil
+	}
+	if t, ok := r.templates[version+"|"]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownVersion, version)
+}
+
+// Templates lists the loaded prompts, ordered by version and document type.
+func (r *Registry) Templates() []*Template {
+	out := make([]*Template, 0, len(r.templates))
+	for _, t := range r.templates {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID() < out[j].ID() })
+	return out
+}
+
+// Active returns the split in use for task.
+func (r *Registry) Active(task string) Split {
+	return r.active[task]
+}
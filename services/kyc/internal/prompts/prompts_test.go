@@ -0,0 +1,233 @@
+package prompts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"kyc/internal/doctypes"
+)
+
+func TestParse(t *testing.T) {
+	tmpl, err := parse(`# Comment
+version: classify-v9
+task: classify
+document_type: passport
+max_tokens: 120
+labels: valid_passport=PASSPORT, IRRELEVANT=, ,
+---
+Is this a {{.DocumentType}}? One of: {{.Labels}}.
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Label{{"VALID_PASSPORT", "PASSPORT"}, {"IRRELEVANT", ""}}
+	if tmpl.Version != "classify-v9" || tmpl.Task != TaskClassify || tmpl.DocumentType != "PASSPORT" || tmpl.MaxTokens != 120 || !slices.Equal(tmpl.Labels, want) {
+		t.Errorf("parsed %+v", tmpl)
+	}
+	if tmpl.Text != "Is this a PASSPORT? One of: VALID_PASSPORT, IRRELEVANT." {
+		t.Errorf("text = %q", tmpl.Text)
+	}
+	if tmpl.ID() != "classify-v9/PASSPORT" {
+		t.Errorf("ID = %q", tmpl.ID())
+	}
+	if typ, ok := tmpl.LabelType("IRRELEVANT"); !ok || typ != "" {
+		t.Errorf("LabelType(IRRELEVANT) = %q, %v", typ, ok)
+	}
+	if _, ok := tmpl.LabelType("VALID_NID"); ok {
+		t.Error("LabelType(VALID_NID) found a label outside the schema")
+	}
+
+	tmpl, err = parse("version: extract-v9\ntask: extract\n---\nRead it.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.MaxTokens != 300 || tmpl.ID() != "extract-v9" {
+		t.Errorf("defaults: %+v", tmpl)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"no separator":    "version: v1\ntask: extract\nRead it.",
+		"no version":      "task: extract\n---\nRead it.",
+		"unknown task":    "version: v1\ntask: translate\n---\nRead it.",
+		"classify labels": "version: v1\ntask: classify\n---\nIs it valid?",
+		"bad max_tokens":  "version: v1\ntask: extract\nmax_tokens: many\n---\nRead it.",
+		"zero max_tokens": "version: v1\ntask: extract\nmax_tokens: 0\n---\nRead it.",
+		"unknown header":  "version: v1\ntask: extract\nmodel: gpt\n---\nRead it.",
+		"header syntax":   "version: v1\ntask extract\n---\nRead it.",
+		"template syntax": "version: v1\ntask: extract\n---\nRead {{.Labels",
+		"unknown field":   "version: v1\ntask: extract\n---\nRead {{.Language}}.",
+	}
+	for name, data := range tests {
+		if _, err := parse(data); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestParseSplit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Split
+	}{
+		{"classify-v2", Split{{"classify-v2", 1}}},
+		{" classify-v2:90 , classify-v3:10 ", Split{{"classify-v2", 90}, {"classify-v3", 10}}},
+		{"classify-v2,classify-v3:0,", Split{{"classify-v2", 1}, {"classify-v3", 0}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSplit(tt.in)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("ParseSplit(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", " , ", "classify-v2:x", "classify-v2:-1"} {
+		if _, err := ParseSplit(in); err == nil {
+			t.Errorf("ParseSplit(%q): no error", in)
+		}
+	}
+}
+
+func TestSplitPick(t *testing.T) {
+	split := Split{{"a", 90}, {"b", 10}}
+	counts := map[string]int{}
+	for i := range 2000 {
+		key := strings.Repeat("k", i%7) + string(rune('a'+i%26)) + strings.Repeat("x", i/26)
+		v := split.pick(key)
+		if again := split.pick(key); again != v {
+			t.Fatalf("pick(%q) = %s, then %s", key, v, again)
+		}
+		counts[v]++
+	}
+	if counts["a"] < 1600 || counts["b"] < 100 {
+		t.Errorf("counts = %v, want about 90%%/10%%", counts)
+	}
+
+	if got := (Split{{"a", 0}, {"b", 0}}).pick("key"); got != "a" {
+		t.Errorf("zero weights picked %s, want the first version", got)
+	}
+	for _, key := range []string{"1", "2", "3", "4", "5"} {
+		if got := (Split{{"off", 0}, {"on", 1}}).pick(key); got != "on" {
+			t.Errorf("pick(%q) = %s, want the only weighted version", key, got)
+		}
+	}
+}
+
+func writePrompts(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSelect(t *testing.T) {
+	dir := writePrompts(t, map[string]string{
+		"v4.txt":          "version: classify-v4\ntask: classify\nlabels: VALID_NID=NID, IRRELEVANT=\n---\nDefault v4",
+		"v4-passport.txt": "version: classify-v4\ntask: classify\ndocument_type: PASSPORT\nlabels: VALID_PASSPORT=PASSPORT, IRRELEVANT=\n---\nPassport v4",
+		"v4-extract.txt":  "version: classify-v4\ntask: extract\ndocument_type: VISA\n---\nExtract, not classify",
+	})
+	r, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Activate(TaskClassify, Split{{"classify-v4", 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		docType string
+		want    string
+	}{
+		{"PASSPORT", "classify-v4/PASSPORT"},
+		{"passport", "classify-v4/PASSPORT"},
+		{"NID", "classify-v4"},  // No NID prompt: the default
+		{"", "classify-v4"},     // Undeclared type
+		{"VISA", "classify-v4"}, // The VISA prompt is for another task
+	}
+	for _, tt := range tests {
+		got, err := r.Select(TaskClassify, tt.docType, "key")
+		if err != nil || got.ID() != tt.want {
+			t.Errorf("Select(%q) = %v, %v, want %s", tt.docType, got, err, tt.want)
+		}
+	}
+
+	// The built-in defaults of the other tasks are still active.
+	if got, err := r.Select(TaskExtract, "NID", "key"); err != nil || got.Version != DefaultExtract {
+		t.Errorf("Select(extract) = %v, %v", got, err)
+	}
+	if _, err := r.Select("translate", "", "key"); err == nil {
+		t.Error("Select of an unknown task: no error")
+	}
+}
+
+func TestActivate(t *testing.T) {
+	r := Default()
+	if err := r.Activate(TaskClassify, Split{{"classify-v7", 1}}); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("unknown version: err = %v", err)
+	}
+	if err := r.Activate(TaskClassify, Split{{DefaultExtract, 1}}); err == nil {
+		t.Error("extract prompt activated for classify")
+	}
+	if got := r.Active(TaskClassify); !slices.Equal(got, Split{{DefaultClassify, 1}}) {
+		t.Errorf("a failed Activate changed the split to %v", got)
+	}
+}
+
+func TestLoadRejectsRedefinedVersion(t *testing.T) {
+	tests := map[string]map[string]string{
+		"built-in": {
+			"classify-v2.txt": "version: classify-v2\ntask: classify\nlabels: VALID_NID=NID, IRRELEVANT=\n---\nChanged wording",
+		},
+		"built-in type": {
+			"bill.txt": "version: classify-v3\ntask: classify\ndocument_type: utility_bill\nlabels: VALID_UTILITY_BILL=UTILITY_BILL, IRRELEVANT=\n---\nChanged wording",
+		},
+		"within directory": {
+			"a.txt": "version: extract-v9\ntask: extract\n---\nRead it.",
+			"b.txt": "version: extract-v9\ntask: extract\n---\nRead it again.",
+		},
+	}
+	for name, files := range tests {
+		if _, err := Load(writePrompts(t, files)); err == nil || !strings.Contains(err.Error(), "already defined") {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+
+	// A new type-specific prompt for a built-in version is an addition.
+	r, err := Load(writePrompts(t, map[string]string{
+		"v3-license.txt": "version: classify-v3\ntask: classify\ndocument_type: DRIVING_LICENSE\nlabels: VALID_LICENSE=DRIVING_LICENSE, IRRELEVANT=\n---\nLicence",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.Select(TaskClassify, "DRIVING_LICENSE", "key"); got == nil || got.ID() != "classify-v3/DRIVING_LICENSE" {
+		t.Errorf("Select(DRIVING_LICENSE) = %v", got)
+	}
+}
+
+func TestBuiltinLabelsAreKnownTypes(t *testing.T) {
+	for _, tmpl := range Default().Templates() {
+		for _, l := range tmpl.Labels {
+			if l.DocumentType != "" && !doctypes.Known(l.DocumentType) {
+				t.Errorf("%s: label %s maps to unregistered type %q", tmpl.ID(), l.Name, l.DocumentType)
+			}
+		}
+	}
+}
+
+func TestBuiltinAddressProofPrompts(t *testing.T) {
+	r := Default()
+	for _, docType := range []string{doctypes.UtilityBill, doctypes.BankStatement} {
+		got, err := r.Select(TaskClassify, docType, "key")
+		if err != nil || got.ID() != DefaultClassify+"/"+docType {
+			t.Errorf("Select(%s) = %v, %v, want %s/%s", docType, got, err, DefaultClassify, docType)
+		}
+	}
+}
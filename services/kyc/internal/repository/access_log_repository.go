@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"kyc/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AccessLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAccessLogRepository(db *mongo.Database) *AccessLogRepository {
+	return &AccessLogRepository{
+		collection: db.Collection("document_access_log"),
+	}
+}
+
+func (r *AccessLogRepository) Create(ctx context.Context, entry *models.DocumentAccess) error {
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+	res, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+	entry.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByKYCID returns the access log of a request, newest first.
+func (r *AccessLogRepository) GetByKYCID(ctx context.Context, kycID primitive.ObjectID) ([]models.DocumentAccess, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"kyc_id": kycID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.DocumentAccess{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *AccessLogRepository) EnsureIndices(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "kyc_id", Value: 1}, {Key: "at", Value: -1}}},
+		{Keys: bson.D{{Key: "reviewer", Value: 1}, {Key: "at", Value: -1}}},
+	})
+	return err
+}
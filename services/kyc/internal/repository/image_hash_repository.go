@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"kyc/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ImageHashRepository struct {
+	collection *mongo.Collection
+}
+
+func NewImageHashRepository(db *mongo.Database) *ImageHashRepository {
+	return &ImageHashRepository{
+		collection: db.Collection("image_hashes"),
+	}
+}
+
+func (r *ImageHashRepository) CreateMany(ctx context.Context, hashes []models.ImageHash) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(hashes))
+	for i := range hashes {
+		hashes[i].CreatedAt = time.Now()
+		docs[i] = hashes[i]
+	}
+	_, err := r.collection.InsertMany(ctx, docs)
+	return err
+}
+
+// FindCandidates returns hashes of other users sharing at least one band
+// with the given bands. Callers still have to check the Hamming distance.
+func (r *ImageHashRepository) FindCandidates(ctx context.Context, bands []int64, excludeUserID string) ([]models.ImageHash, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"bands":   bson.M{"$in": bands},
+		"user_id": bson.M{"$ne": excludeUserID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var hashes []models.ImageHash
+	if err = cursor.All(ctx, &hashes); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// FindWithBandBelow returns hashes with any band value below the given one.
+func (r *ImageHashRepository) FindWithBandBelow(ctx context.Context, below int64) ([]models.ImageHash, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"bands": bson.M{"$lt": below}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var hashes []models.ImageHash
+	if err = cursor.All(ctx, &hashes); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+func (r *ImageHashRepository) SetBands(ctx context.Context, id primitive.ObjectID, bands []int64) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"bands": bands}})
+	return err
+}
+
+func (r *ImageHashRepository) EnsureIndices(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "bands", Value: 1}}},
+		{Keys: bson.D{{Key: "kyc_id", Value: 1}}},
+	})
+	return err
+}
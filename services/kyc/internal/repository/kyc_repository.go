@@ -3,9 +3,13 @@ package repository
 import (
 	"context"
 	"errors"
+	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"kyc/internal/models"
+	"kyc/internal/mrz"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,6 +17,13 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	ErrNotFound = errors.New("KYC request not found")
+	// ErrVersionConflict means the request was changed by someone else
+	// since it was read.
+	ErrVersionConflict = errors.New("KYC request was modified concurrently")
+)
+
 type KYCRepository struct {
 	collection *mongo.Collection
 }
@@ -26,7 +37,14 @@ func NewKYCRepository(db *mongo.Database) *KYCRepository {
 func (r *KYCRepository) Create(ctx context.Context, kyc *models.KYCRequest) error {
 	kyc.CreatedAt = time.Now()
 	kyc.UpdatedAt = time.Now()
-	kyc.Status = models.StatusPending
+	if kyc.Status == "" {
+		kyc.Status = models.StatusPending
+	}
+	kyc.Version = 1
+	if slices.Contains(models.ReviewStatuses, kyc.Status) {
+		kyc.QueuedAt = kyc.CreatedAt
+	}
+	kyc.StatusHistory = []models.StatusChange{{To: kyc.Status, Actor: models.ActorSystem, Reason: kyc.Clarification, At: kyc.CreatedAt}}
 
 	res, err := r.collection.InsertOne(ctx, kyc)
 	if err != nil {
@@ -36,9 +54,11 @@ func (r *KYCRepository) Create(ctx context.Context, kyc *models.KYCRequest) erro
 	return nil
 }
 
+// GetByUserID returns the user's latest KYC attempt.
 func (r *KYCRepository) GetByUserID(ctx context.Context, userID string) (*models.KYCRequest, error) {
 	var kyc models.KYCRequest
-	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&kyc)
+	opts := options.FindOne().SetSort(bson.D{{Key: "attempt", Value: -1}, {Key: "created_at", Value: -1}})
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&kyc)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil // Return nil if no KYC found
@@ -48,8 +68,63 @@ func (r *KYCRepository) GetByUserID(ctx context.Context, userID string) (*models
 	return &kyc, nil
 }
 
-func (r *KYCRepository) GetPending(ctx context.Context) ([]models.KYCRequest, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{"status": models.StatusPending})
+// GetHistory returns all of the user's KYC attempts, newest first.
+func (r *KYCRepository) GetHistory(ctx context.Context, userID string) ([]models.KYCRequest, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "attempt", Value: -1}, {Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	requests := []models.KYCRequest{}
+	if err = cursor.All(ctx, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// CountByUserID returns the number of the user's attempts that count
+// towards the attempt limit. Automatic rejections do not, unless appealed.
+func (r *KYCRepository) CountByUserID(ctx context.Context, userID string) (int, error) {
+	n, err := r.collection.CountDocuments(ctx, bson.M{
+		"user_id": userID,
+		"$or": bson.A{
+			bson.M{"auto_rejected": bson.M{"$ne": true}},
+			bson.M{"appeal": bson.M{"$exists": true}},
+		},
+	})
+	return int(n), err
+}
+
+// ClaimIdentity makes the stored request kyc the holder of its document in
+// the unique document index, taking it over from the user's earlier
+// attempts. Those are only released once kyc exists, so a submission that
+// fails to store leaves them holding the document.
+func (r *KYCRepository) ClaimIdentity(ctx context.Context, kyc *models.KYCRequest) error {
+	if _, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": kyc.UserID, "identity_owner": true, "_id": bson.M{"$ne": kyc.ID}},
+		bson.M{"$set": bson.M{"identity_owner": false}},
+	); err != nil {
+		return err
+	}
+	if _, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": kyc.ID},
+		bson.M{"$set": bson.M{"identity_owner": true}},
+	); err != nil {
+		return err
+	}
+	kyc.IdentityOwner = true
+	return nil
+}
+
+// GetByDocument returns other users' requests for the same normalized document.
+func (r *KYCRepository) GetByDocument(ctx context.Context, docType, documentNumber, excludeUserID string) ([]models.KYCRequest, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"type":            docType,
+		"document_number": documentNumber,
+		"user_id":         bson.M{"$ne": excludeUserID},
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -62,28 +137,339 @@ func (r *KYCRepository) GetPending(ctx context.Context) ([]models.KYCRequest, er
 	return requests, nil
 }
 
-func (r *KYCRepository) UpdateStatus(ctx context.Context, id string, status models.KYCStatus, clarification string) error {
+func (r *KYCRepository) GetByStatus(ctx context.Context, status models.KYCStatus) ([]models.KYCRequest, error) {
+	return r.find(ctx, bson.M{"status": status})
+}
+
+func (r *KYCRepository) GetByID(ctx context.Context, id string) (*models.KYCRequest, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return errors.New("invalid id")
+		return nil, ErrNotFound
+	}
+	var kyc models.KYCRequest
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&kyc)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &kyc, nil
+}
+
+// Transition applies a status change to kyc, which must be the version read
+// from the database. The update only matches if nobody changed the request
+// in the meantime; otherwise ErrVersionConflict is returned. On success kyc
+// is updated in place.
+func (r *KYCRepository) Transition(ctx context.Context, kyc *models.KYCRequest, change models.StatusChange) error {
+	return r.transition(ctx, kyc, change, bson.M{}, bson.M{})
+}
+
+// RequestInfo moves kyc to NEEDS_INFO, recording the requested items and
+// the reviewer's message.
+func (r *KYCRepository) RequestInfo(ctx context.Context, kyc *models.KYCRequest, change models.StatusChange, items []models.InfoItem, msg models.Message) error {
+	err := r.transition(ctx, kyc, change,
+		bson.M{"requested_items": items},
+		bson.M{"messages": msg},
+	)
+	if err == nil {
+		kyc.RequestedItems = items
+		kyc.Messages = append(kyc.Messages, msg)
 	}
+	return err
+}
 
-	update := bson.M{
-		"$set": bson.M{
-			"status":        status,
-			"clarification": clarification,
-			"updated_at":    time.Now(),
-		},
+// Supplement is the user's answer to an information request.
+type Supplement struct {
+	Images        []string
+	ImageRoles    []string                    // One per image, or none for requests without roles
+	Verifications []models.VerificationResult // One per image
+	Flags         []string
+	FraudMatches  []models.FraudMatch
+	Message       models.Message
+	// Set when the supplement replaces them; empty otherwise.
+	AddressProofType string
+	Extracted        *models.ExtractedFields
+	FieldMatch       *models.FieldMatch
+	MRZ              *mrz.MRZ
+	Selfie           string
+	FaceMatch        *models.FaceMatch
+}
+
+// Supplement adds the user's answer to an information request and sends the
+// request back to the review queue.
+func (r *KYCRepository) Supplement(ctx context.Context, kyc *models.KYCRequest, change models.StatusChange, supp Supplement) error {
+	push := bson.M{
+		"images":        bson.M{"$each": supp.Images},
+		"verifications": bson.M{"$each": supp.Verifications},
+		"messages":      supp.Message,
+	}
+	if len(supp.FraudMatches) > 0 {
+		push["fraud_matches"] = bson.M{"$each": supp.FraudMatches}
+	}
+	if len(supp.ImageRoles) > 0 {
+		push["image_roles"] = bson.M{"$each": supp.ImageRoles}
+	}
+	set := bson.M{"requested_items": bson.A{}}
+	if len(supp.Flags) > 0 {
+		set["flags"] = supp.Flags
+	}
+	if supp.AddressProofType != "" {
+		set["address_proof_type"] = supp.AddressProofType
+	}
+	if supp.Extracted != nil {
+		set["extracted"] = supp.Extracted
+		set["field_match"] = supp.FieldMatch
+	}
+	if supp.MRZ != nil {
+		set["mrz"] = supp.MRZ
+	}
+	if supp.Selfie != "" {
+		set["selfie"] = supp.Selfie
+	}
+	if supp.FaceMatch != nil {
+		set["face_match"] = supp.FaceMatch
 	}
+	err := r.transition(ctx, kyc, change, set, push)
+	if err == nil {
+		kyc.RequestedItems = nil
+		kyc.Images = append(kyc.Images, supp.Images...)
+		kyc.ImageRoles = append(kyc.ImageRoles, supp.ImageRoles...)
+		kyc.Verifications = append(kyc.Verifications, supp.Verifications...)
+		kyc.FraudMatches = append(kyc.FraudMatches, supp.FraudMatches...)
+		if len(supp.Flags) > 0 {
+			kyc.Flags = supp.Flags
+		}
+		kyc.Messages = append(kyc.Messages, supp.Message)
+		if supp.AddressProofType != "" {
+			kyc.AddressProofType = supp.AddressProofType
+		}
+		if supp.Extracted != nil {
+			kyc.Extracted, kyc.FieldMatch = supp.Extracted, supp.FieldMatch
+		}
+		if supp.MRZ != nil {
+			kyc.MRZ = supp.MRZ
+		}
+		if supp.Selfie != "" {
+			kyc.Selfie = supp.Selfie
+		}
+		if supp.FaceMatch != nil {
+			kyc.FaceMatch = supp.FaceMatch
+		}
+	}
+	return err
+}
 
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+// FileAppeal records the user's appeal of an automatic rejection and puts
+// the request in the review queue.
+func (r *KYCRepository) FileAppeal(ctx context.Context, kyc *models.KYCRequest, change models.StatusChange, appeal models.Appeal, msg models.Message, flags []string) error {
+	err := r.transition(ctx, kyc, change,
+		bson.M{"appeal": appeal, "flags": flags},
+		bson.M{"messages": msg},
+	)
+	if err == nil {
+		kyc.Appeal = &appeal
+		kyc.Flags = flags
+		kyc.Messages = append(kyc.Messages, msg)
+	}
+	return err
+}
+
+// Escalate moves kyc to a review status and replaces its flags, e.g. when
+// rescreening finds an approved user on a watchlist.
+func (r *KYCRepository) Escalate(ctx context.Context, kyc *models.KYCRequest, change models.StatusChange, flags []string) error {
+	err := r.transition(ctx, kyc, change, bson.M{"flags": flags}, bson.M{})
+	if err == nil {
+		kyc.Flags = flags
+	}
 	return err
 }
 
+// AddFlag adds flag to the request without changing its version.
+func (r *KYCRepository) AddFlag(ctx context.Context, id primitive.ObjectID, flag string) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$addToSet": bson.M{"flags": flag}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RemoveFlag removes flag from the request without changing its version.
+func (r *KYCRepository) RemoveFlag(ctx context.Context, id primitive.ObjectID, flag string) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{"flags": flag}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// IsImageReferenced reports whether any request uses the stored file.
+func (r *KYCRepository) IsImageReferenced(ctx context.Context, path string) (bool, error) {
+	filter := bson.M{"$or": bson.A{bson.M{"images": path}, bson.M{"selfie": path}}}
+	n, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// AddMessage appends a message to the request's thread. Messages do not
+// change the request version.
+func (r *KYCRepository) AddMessage(ctx context.Context, id primitive.ObjectID, msg models.Message) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$push": bson.M{"messages": msg}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *KYCRepository) transition(ctx context.Context, kyc *models.KYCRequest, change models.StatusChange, set, push bson.M) error {
+	change.From = kyc.Status
+	if change.At.IsZero() {
+		change.At = time.Now()
+	}
+	set["status"] = change.To
+	set["updated_at"] = change.At
+	// The clarification tells the user about a decision; moving back to
+	// PENDING and appealing are the user's own doing.
+	decision := change.Reason != "" && change.To != models.StatusPending && change.Actor != kyc.UserID
+	if decision {
+		set["clarification"] = change.Reason
+	}
+	push["status_history"] = change
+	if slices.Contains(models.ReviewStatuses, change.To) {
+		set["queued_at"] = change.At
+	}
+
+	filter := bson.M{"_id": kyc.ID, "status": kyc.Status, "version": kyc.Version}
+	if kyc.Version == 0 {
+		// Requests stored before versioning have no version field.
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	res, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$set":  set,
+		"$inc":  bson.M{"version": 1},
+		"$push": push,
+		// A status change ends any reviewer's claim.
+		"$unset": bson.M{"claim": ""},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		if _, err := r.GetByID(ctx, kyc.ID.Hex()); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+
+	kyc.Status = change.To
+	kyc.UpdatedAt = change.At
+	if decision {
+		kyc.Clarification = change.Reason
+	}
+	if slices.Contains(models.ReviewStatuses, change.To) {
+		kyc.QueuedAt = change.At
+	}
+	kyc.Claim = nil
+	kyc.Version++
+	kyc.StatusHistory = append(kyc.StatusHistory, change)
+	return nil
+}
+
+// GetNeedsInfoBefore returns requests waiting for the user since before t.
+func (r *KYCRepository) GetNeedsInfoBefore(ctx context.Context, t time.Time) ([]models.KYCRequest, error) {
+	return r.find(ctx, bson.M{"status": models.StatusNeedsInfo, "updated_at": bson.M{"$lt": t}})
+}
+
+// GetApprovedExpiringBefore returns approved requests whose document expiry
+// date (YYYY-MM-DD) is before date.
+func (r *KYCRepository) GetApprovedExpiringBefore(ctx context.Context, date string) ([]models.KYCRequest, error) {
+	return r.find(ctx, bson.M{
+		"status":                models.StatusApproved,
+		"extracted.expiry_date": bson.M{"$gt": "", "$lt": date},
+	})
+}
+
+func (r *KYCRepository) find(ctx context.Context, filter bson.M) ([]models.KYCRequest, error) {
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var requests []models.KYCRequest
+	if err = cursor.All(ctx, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// documentIndexName is the unique (type, document_number) index. Only the
+// first owner of a document is in it; later duplicates go to fraud review.
+const documentIndexName = "type_document_number_unique"
+
+// IsDuplicateDocument reports whether err is a violation of the unique
+// (type, document_number) index.
+func IsDuplicateDocument(err error) bool {
+	return mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), documentIndexName)
+}
+
+// IsDuplicateAttempt reports whether err is a violation of the unique
+// (user_id, attempt) index, i.e. a concurrent submission by the same user.
+func IsDuplicateAttempt(err error) bool {
+	return mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), attemptIndexName)
+}
+
+const attemptIndexName = "user_id_attempt_unique"
+
 func (r *KYCRepository) EnsureIndices(ctx context.Context) error {
-	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D{{Key: "user_id", Value: 1}},
-		Options: options.Index().SetUnique(true),
+	// Before resubmissions, each user could only have one request.
+	if _, err := r.collection.Indexes().DropOne(ctx, "user_id_1"); err != nil {
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || cmdErr.Name != "IndexNotFound" {
+			log.Printf("Warning: failed to drop legacy user_id index: %v", err)
+		}
+	}
+
+	// Requests queued before SLA tracking count from their creation.
+	if _, err := r.collection.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$in": models.ReviewStatuses}, "queued_at": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"queued_at": "$created_at"}}}},
+	); err != nil {
+		log.Printf("Warning: failed to backfill queued_at: %v", err)
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "attempt", Value: 1}},
+			Options: options.Index().SetName(attemptIndexName).SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "type", Value: 1}, {Key: "document_number", Value: 1}},
+			Options: options.Index().
+				SetName(documentIndexName).
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"identity_owner": true}),
+		},
+		{
+			// Janitor lookups of stored files
+			Keys: bson.D{{Key: "images", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "selfie", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			// Review queue order
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "queued_at", Value: 1}, {Key: "_id", Value: 1}},
+		},
 	})
 	return err
 }
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"kyc/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QuarantineRepository struct {
+	collection *mongo.Collection
+}
+
+func NewQuarantineRepository(db *mongo.Database) *QuarantineRepository {
+	return &QuarantineRepository{
+		collection: db.Collection("upload_quarantine"),
+	}
+}
+
+func (r *QuarantineRepository) Create(ctx context.Context, entry *models.QuarantinedUpload) error {
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+	res, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+	entry.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// List returns the newest entries, optionally only those of one user.
+func (r *QuarantineRepository) List(ctx context.Context, userID string, limit int64) ([]models.QuarantinedUpload, error) {
+	filter := bson.M{}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.QuarantinedUpload{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *QuarantineRepository) EnsureIndices(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "at", Value: -1}}},
+	})
+	return err
+}
@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"kyc/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrClaimed       = errors.New("KYC request is claimed by another reviewer")
+	ErrNotInQueue    = errors.New("KYC request is not waiting for review")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrCursorMismatch is returned for a cursor issued for another sort
+	// order or other filters, which would skip or repeat items.
+	ErrCursorMismatch = errors.New("cursor does not match the sort and filters")
+)
+
+// QueueCursor is the position after the last item of a queue page, bound
+// to the query that produced the page.
+type QueueCursor struct {
+	QueuedAt time.Time
+	ID       primitive.ObjectID
+	Query    string // QueueFilter.Key of the query
+}
+
+// Encode returns the cursor as an opaque string for clients.
+func (c QueueCursor) Encode() string {
+	raw := strconv.FormatInt(c.QueuedAt.UnixMilli(), 10) + ":" + c.ID.Hex() + ":" + c.Query
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeQueueCursor parses a cursor and checks that it was issued for the
+// sort and filters of f.
+func DecodeQueueCursor(s string, f QueueFilter) (*QueueCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 {
+		return nil, ErrInvalidCursor
+	}
+	millis, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(parts[1])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if parts[2] != f.Key() {
+		return nil, ErrCursorMismatch
+	}
+	return &QueueCursor{QueuedAt: time.UnixMilli(millis).UTC(), ID: id, Query: parts[2]}, nil
+}
+
+// QueueFilter selects and orders a page of the review queue.
+type QueueFilter struct {
+	Statuses      []models.KYCStatus
+	Type          string
+	SubmittedFrom time.Time // Zero means unbounded
+	SubmittedTo   time.Time
+	Verdict       models.Verdict // Any image with this verdict
+	Flags         []string       // All of these flags
+	// ExcludeClaimedBy hides requests that are claimed by another reviewer
+	// than this one at Now.
+	ExcludeClaimedBy string
+	Now              time.Time
+	Newest           bool // Newest first instead of oldest first
+	Limit            int
+	After            *QueueCursor
+}
+
+// Key identifies the sort and filters of f. The limit, the cursor and Now
+// are left out: they change between pages of the same query.
+func (f QueueFilter) Key() string {
+	statuses := make([]string, len(f.Statuses))
+	for i, s := range f.Statuses {
+		statuses[i] = string(s)
+	}
+	slices.Sort(statuses)
+	flags := slices.Compact(slices.Sorted(slices.Values(f.Flags)))
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+	query := strings.Join([]string{
+		strings.Join(statuses, ","),
+		f.Type,
+		formatTime(f.SubmittedFrom),
+		formatTime(f.SubmittedTo),
+		string(f.Verdict),
+		strings.Join(flags, ","),
+		f.ExcludeClaimedBy,
+		strconv.FormatBool(f.Newest),
+	}, "\n")
+	sum := sha256.Sum256([]byte(query))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// Queue returns one page of the review queue and the cursor of the next
+// page, which is nil on the last page.
+func (r *KYCRepository) Queue(ctx context.Context, f QueueFilter) ([]models.KYCRequest, *QueueCursor, error) {
+	filter := bson.M{"status": bson.M{"$in": f.Statuses}}
+	if f.Type != "" {
+		filter["type"] = f.Type
+	}
+	created := bson.M{}
+	if !f.SubmittedFrom.IsZero() {
+		created["$gte"] = f.SubmittedFrom
+	}
+	if !f.SubmittedTo.IsZero() {
+		created["$lt"] = f.SubmittedTo
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+	if f.Verdict != "" {
+		filter["verifications.verdict"] = f.Verdict
+	}
+	if len(f.Flags) > 0 {
+		filter["flags"] = bson.M{"$all": f.Flags}
+	}
+
+	var and bson.A
+	if f.ExcludeClaimedBy != "" {
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"claim": nil},
+			bson.M{"claim.expires_at": bson.M{"$lte": f.Now}},
+			bson.M{"claim.reviewer": f.ExcludeClaimedBy},
+		}})
+	}
+	order, cmp := 1, "$gt"
+	if f.Newest {
+		order, cmp = -1, "$lt"
+	}
+	if f.After != nil {
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"queued_at": bson.M{cmp: f.After.QueuedAt}},
+			bson.M{"queued_at": f.After.QueuedAt, "_id": bson.M{cmp: f.After.ID}},
+		}})
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "queued_at", Value: order}, {Key: "_id", Value: order}}).
+		SetLimit(int64(f.Limit + 1))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	requests := []models.KYCRequest{}
+	if err = cursor.All(ctx, &requests); err != nil {
+		return nil, nil, err
+	}
+	if len(requests) <= f.Limit {
+		return requests, nil, nil
+	}
+	requests = requests[:f.Limit]
+	last := requests[len(requests)-1]
+	return requests, &QueueCursor{QueuedAt: last.QueuedAt, ID: last.ID, Query: f.Key()}, nil
+}
+
+// Claim gives reviewer a lease on a queued request until expiresAt. A
+// reviewer can renew their own claim and take over an expired one.
+func (r *KYCRepository) Claim(ctx context.Context, id, reviewer string, now, expiresAt time.Time) (*models.KYCRequest, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	filter := bson.M{
+		"_id":    objID,
+		"status": bson.M{"$in": models.ReviewStatuses},
+		"$or": bson.A{
+			bson.M{"claim": nil},
+			bson.M{"claim.expires_at": bson.M{"$lte": now}},
+			bson.M{"claim.reviewer": reviewer},
+		},
+	}
+	update := bson.M{"$set": bson.M{"claim": models.Claim{Reviewer: reviewer, ClaimedAt: now, ExpiresAt: expiresAt}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var kyc models.KYCRequest
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&kyc)
+	if err == mongo.ErrNoDocuments {
+		current, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if current.Claim.ActiveFor(reviewer, now) {
+			return nil, ErrClaimed
+		}
+		return nil, ErrNotInQueue
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &kyc, nil
+}
+
+// Release gives up reviewer's claim on a request.
+func (r *KYCRepository) Release(ctx context.Context, id, reviewer string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objID, "claim.reviewer": reviewer},
+		bson.M{"$unset": bson.M{"claim": ""}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return ErrClaimed
+	}
+	return nil
+}
+
+// QueueSummary is the size and age of one status queue.
+type QueueSummary struct {
+	Status   models.KYCStatus `bson:"_id" json:"status"`
+	Count    int              `bson:"count" json:"count"`
+	Oldest   time.Time        `bson:"oldest" json:"oldest"`
+	Breached int              `bson:"breached" json:"sla_breached"`
+}
+
+// QueueSummaries returns the size of each review queue, its oldest item and
+// how many items have waited since before slaDeadline.
+func (r *KYCRepository) QueueSummaries(ctx context.Context, slaDeadline time.Time) ([]QueueSummary, error) {
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": bson.M{"$in": models.ReviewStatuses}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$status",
+			"count":  bson.M{"$sum": 1},
+			"oldest": bson.M{"$min": "$queued_at"},
+			"breached": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$lt": bson.A{"$queued_at", slaDeadline}}, 1, 0,
+			}}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	summaries := []QueueSummary{}
+	if err = cursor.All(ctx, &summaries); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// ReviewerStats counts one reviewer's decisions.
+type ReviewerStats struct {
+	Reviewer  string `bson:"_id" json:"reviewer"`
+	Approved  int    `bson:"approved" json:"approved"`
+	Rejected  int    `bson:"rejected" json:"rejected"`
+	NeedsInfo int    `bson:"needs_info" json:"needs_info"`
+	Total     int    `bson:"total" json:"total"`
+	// AvgWaitSeconds is the average time the decided requests waited in the queue.
+	AvgWaitSeconds float64 `bson:"avg_wait_seconds" json:"avg_wait_seconds"`
+}
+
+// ReviewerStats returns the decisions made by each reviewer since t, taken
+// from the status history.
+func (r *KYCRepository) ReviewerStats(ctx context.Context, since time.Time) ([]ReviewerStats, error) {
+	decisions := bson.A{models.StatusApproved, models.StatusRejected, models.StatusNeedsInfo}
+	count := func(status models.KYCStatus) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status_history.to", status}}, 1, 0}}}
+	}
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status_history": bson.M{"$elemMatch": bson.M{"at": bson.M{"$gte": since}}}}}},
+		// Pair each history entry with the previous one to measure wait time.
+		{{Key: "$project", Value: bson.M{"status_history": bson.M{"$map": bson.M{
+			"input": bson.M{"$range": bson.A{0, bson.M{"$size": "$status_history"}}},
+			"as":    "i",
+			"in": bson.M{
+				"to":    bson.M{"$arrayElemAt": bson.A{"$status_history.to", "$$i"}},
+				"actor": bson.M{"$arrayElemAt": bson.A{"$status_history.actor", "$$i"}},
+				"at":    bson.M{"$arrayElemAt": bson.A{"$status_history.at", "$$i"}},
+				"prev":  bson.M{"$arrayElemAt": bson.A{"$status_history.at", bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$$i", 1}}}}}},
+			},
+		}}}}},
+		{{Key: "$unwind", Value: "$status_history"}},
+		{{Key: "$match", Value: bson.M{
+			"status_history.at":    bson.M{"$gte": since},
+			"status_history.to":    bson.M{"$in": decisions},
+			"status_history.actor": bson.M{"$ne": models.ActorSystem},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        "$status_history.actor",
+			"approved":   count(models.StatusApproved),
+			"rejected":   count(models.StatusRejected),
+			"needs_info": count(models.StatusNeedsInfo),
+			"total":      bson.M{"$sum": 1},
+			"avg_wait_seconds": bson.M{"$avg": bson.M{"$divide": bson.A{
+				bson.M{"$subtract": bson.A{"$status_history.at", "$status_history.prev"}}, 1000,
+			}}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"total": -1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	stats := []ReviewerStats{}
+	if err = cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
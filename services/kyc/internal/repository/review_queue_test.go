@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"kyc/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func queueFilter() QueueFilter {
+	return QueueFilter{
+		Statuses:      []models.KYCStatus{models.StatusPending},
+		Type:          "NID",
+		SubmittedFrom: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Verdict:       models.VerdictValid,
+		Flags:         []string{models.FlagDuplicateImage, models.FlagForensicRisk},
+		Now:           time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC),
+		Limit:         20,
+	}
+}
+
+func TestQueueCursorRoundTrip(t *testing.T) {
+	f := queueFilter()
+	cursor := QueueCursor{
+		QueuedAt: time.Date(2026, 3, 2, 8, 30, 15, 123_000_000, time.UTC),
+		ID:       primitive.NewObjectID(),
+		Query:    f.Key(),
+	}
+
+	// The next page may use another limit and is requested at another time.
+	next := f
+	next.Limit = 50
+	next.Now = next.Now.Add(time.Minute)
+	next.Flags = []string{models.FlagForensicRisk, models.FlagDuplicateImage}
+	got, err := DecodeQueueCursor(cursor.Encode(), next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.QueuedAt.Equal(cursor.QueuedAt) || got.ID != cursor.ID || got.Query != cursor.Query {
+		t.Errorf("DecodeQueueCursor = %+v, want %+v", got, cursor)
+	}
+}
+
+func TestQueueCursorRejectsOtherQuery(t *testing.T) {
+	f := queueFilter()
+	encoded := QueueCursor{QueuedAt: time.Now(), ID: primitive.NewObjectID(), Query: f.Key()}.Encode()
+
+	changes := map[string]func(*QueueFilter){
+		"sort":           func(f *QueueFilter) { f.Newest = true },
+		"status":         func(f *QueueFilter) { f.Statuses = []models.KYCStatus{models.StatusFraudReview} },
+		"type":           func(f *QueueFilter) { f.Type = "PASSPORT" },
+		"submitted_from": func(f *QueueFilter) { f.SubmittedFrom = f.SubmittedFrom.Add(time.Hour) },
+		"submitted_to":   func(f *QueueFilter) { f.SubmittedTo = f.SubmittedFrom.AddDate(0, 1, 0) },
+		"verdict":        func(f *QueueFilter) { f.Verdict = models.VerdictManualReview },
+		"fewer flags":    func(f *QueueFilter) { f.Flags = f.Flags[:1] },
+		"unclaimed":      func(f *QueueFilter) { f.ExcludeClaimedBy = "reviewer-1" },
+	}
+	for name, change := range changes {
+		other := queueFilter()
+		change(&other)
+		if _, err := DecodeQueueCursor(encoded, other); !errors.Is(err, ErrCursorMismatch) {
+			t.Errorf("%s changed: err = %v, want ErrCursorMismatch", name, err)
+		}
+	}
+}
+
+func TestQueueCursorRejectsTampering(t *testing.T) {
+	f := queueFilter()
+	id := primitive.NewObjectID()
+	valid := QueueCursor{QueuedAt: time.Now(), ID: id, Query: f.Key()}.Encode()
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name    string
+		cursor  string
+		wantErr error
+	}{
+		{"not base64", "!" + valid[1:], ErrInvalidCursor},
+		{"truncated", valid[:len(valid)/2], ErrInvalidCursor},
+		{"old format without query", encode("1700000000000:" + id.Hex()), ErrInvalidCursor},
+		{"extra field", encode("1700000000000:" + id.Hex() + ":" + f.Key() + ":x"), ErrInvalidCursor},
+		{"time not a number", encode("yesterday:" + id.Hex() + ":" + f.Key()), ErrInvalidCursor},
+		{"bad ID", encode("1700000000000:" + strings.Repeat("z", 24) + ":" + f.Key()), ErrInvalidCursor},
+		{"query replaced", encode("1700000000000:" + id.Hex() + ":" + QueueFilter{}.Key()), ErrCursorMismatch},
+		{"query removed", encode("1700000000000:" + id.Hex() + ":"), ErrCursorMismatch},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeQueueCursor(tt.cursor, f); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kyc/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrCaseNotFound = errors.New("screening case not found")
+
+type ScreeningRepository struct {
+	collection *mongo.Collection
+}
+
+func NewScreeningRepository(db *mongo.Database) *ScreeningRepository {
+	return &ScreeningRepository{
+		collection: db.Collection("screening_cases"),
+	}
+}
+
+func (r *ScreeningRepository) Create(ctx context.Context, sc *models.ScreeningCase) error {
+	sc.CreatedAt = time.Now()
+	if sc.Status == "" {
+		sc.Status = models.ScreeningOpen
+	}
+	res, err := r.collection.InsertOne(ctx, sc)
+	if err != nil {
+		return err
+	}
+	sc.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ScreeningRepository) GetByID(ctx context.Context, id string) (*models.ScreeningCase, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrCaseNotFound
+	}
+	var sc models.ScreeningCase
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&sc)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// List returns cases oldest first, optionally only those with status.
+func (r *ScreeningRepository) List(ctx context.Context, status models.ScreeningStatus, limit int64) ([]models.ScreeningCase, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(limit)
+	return r.find(ctx, filter, opts)
+}
+
+// GetByUserID returns all of the user's cases.
+func (r *ScreeningRepository) GetByUserID(ctx context.Context, userID string) ([]models.ScreeningCase, error) {
+	return r.find(ctx, bson.M{"user_id": userID}, options.Find())
+}
+
+// Resolve records the reviewer's decision on an open case. It returns
+// ErrCaseNotFound if the case is missing or already resolved.
+func (r *ScreeningRepository) Resolve(ctx context.Context, sc *models.ScreeningCase, status models.ScreeningStatus, reviewer, note string) error {
+	now := time.Now()
+	res, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": sc.ID, "status": models.ScreeningOpen},
+		bson.M{"$set": bson.M{"status": status, "resolved_by": reviewer, "note": note, "resolved_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrCaseNotFound
+	}
+	sc.Status = status
+	sc.ResolvedBy = reviewer
+	sc.Note = note
+	sc.ResolvedAt = now
+	return nil
+}
+
+func (r *ScreeningRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// HasBlocking reports whether the request has open or confirmed cases.
+func (r *ScreeningRepository) HasBlocking(ctx context.Context, kycID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"kyc_id": kycID,
+		"status": bson.M{"$in": bson.A{models.ScreeningOpen, models.ScreeningConfirmed}},
+	}
+	n, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+func (r *ScreeningRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.ScreeningCase, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	cases := []models.ScreeningCase{}
+	if err = cursor.All(ctx, &cases); err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+func (r *ScreeningRepository) EnsureIndices(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "kyc_id", Value: 1}}},
+	})
+	return err
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kyc/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CachedVerdict is a verification result stored under the image hash, the
+// model and the prompt version it was produced with.
+type CachedVerdict struct {
+	Key           string                    `bson:"_id"`
+	SHA256        string                    `bson:"sha256"`
+	ModelID       string                    `bson:"model_id"`
+	PromptVersion string                    `bson:"prompt_version"`
+	Result        models.VerificationResult `bson:"result"`
+	CreatedAt     time.Time                 `bson:"created_at"`
+}
+
+// VerdictCacheFilter selects cache entries to invalidate. Empty fields match
+// any value; an empty filter matches every entry.
+type VerdictCacheFilter struct {
+	SHA256        string
+	ModelID       string
+	PromptVersion string
+}
+
+type VerdictCacheRepository struct {
+	collection *mongo.Collection
+	ttl        time.Duration
+}
+
+func NewVerdictCacheRepository(db *mongo.Database, ttl time.Duration) *VerdictCacheRepository {
+	return &VerdictCacheRepository{
+		collection: db.Collection("verdict_cache"),
+		ttl:        ttl,
+	}
+}
+
+// Get returns the entry stored under key, or nil if there is none. Expired
+// entries are returned until Mongo's TTL monitor removes them; callers check
+// CreatedAt.
+func (r *VerdictCacheRepository) Get(ctx context.Context, key string) (*CachedVerdict, error) {
+	var entry CachedVerdict
+	err := r.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Put stores the entry, replacing an earlier one under the same key.
+func (r *VerdictCacheRepository) Put(ctx context.Context, entry *CachedVerdict) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": entry.Key}, entry, opts)
+	return err
+}
+
+// Delete removes the entries matching f and returns how many were removed.
+func (r *VerdictCacheRepository) Delete(ctx context.Context, f VerdictCacheFilter) (int64, error) {
+	filter := bson.M{}
+	if f.SHA256 != "" {
+		filter["sha256"] = f.SHA256
+	}
+	if f.ModelID != "" {
+		filter["model_id"] = f.ModelID
+	}
+	if f.PromptVersion != "" {
+		filter["prompt_version"] = f.PromptVersion
+	}
+	res, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// Count returns the number of stored entries, including expired ones that
+// Mongo has not removed yet.
+func (r *VerdictCacheRepository) Count(ctx context.Context) (int64, error) {
+	return r.collection.EstimatedDocumentCount(ctx)
+}
+
+// EnsureIndices creates the TTL index, replacing it if the TTL has changed.
+func (r *VerdictCacheRepository) EnsureIndices(ctx context.Context) error {
+	ttl := mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetName("created_at_ttl").SetExpireAfterSeconds(int32(r.ttl.Seconds())),
+	}
+	indexes := []mongo.IndexModel{
+		ttl,
+		{Keys: bson.D{{Key: "sha256", Value: 1}}},
+		{Keys: bson.D{{Key: "model_id", Value: 1}, {Key: "prompt_version", Value: 1}}},
+	}
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Name == "IndexOptionsConflict" {
+		if _, err := r.collection.Indexes().DropOne(ctx, "created_at_ttl"); err != nil {
+			return err
+		}
+		_, err = r.collection.Indexes().CreateMany(ctx, indexes)
+		return err
+	}
+	return err
+}
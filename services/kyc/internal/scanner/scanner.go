@@ -0,0 +1,158 @@
+// Package scanner checks uploaded files for malware before they are stored.
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Result is the verdict of a scan.
+type Result struct {
+	Infected  bool
+	Signature string // Name of the detected malware
+}
+
+// Scanner scans file contents.
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, data []byte) (Result, error)
+}
+
+// Pinger is implemented by scanners backed by a service that can be down.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Nop accepts every file. It is used when no scanner is configured.
+type Nop struct{}
+
+func (Nop) Name() string { return "none" }
+
+func (Nop) Scan(context.Context, []byte) (Result, error) { return Result{}, nil }
+
+var ErrScanFailed = errors.New("malware scan failed")
+
+// Clamd talks to a clamd daemon, streaming files with the INSTREAM command
+// so that clamd does not need access to our file system.
+type Clamd struct {
+	Network   string // tcp or unix
+	Address   string
+	Timeout   time.Duration // For a whole scan, including the connection
+	ChunkSize int
+}
+
+// NewClamd parses an address such as tcp://localhost:3310,
+// unix:///run/clamav/clamd.ctl or localhost:3310.
+func NewClamd(address string, timeout time.Duration) (*Clamd, error) {
+	network, addr := "tcp", address
+	if scheme, rest, ok := strings.Cut(address, "://"); ok {
+		network, addr = scheme, rest
+	}
+	if network != "tcp" && network != "unix" {
+		return nil, fmt.Errorf("unsupported clamd address %q", address)
+	}
+	if addr == "" {
+		return nil, errors.New("clamd address is empty")
+	}
+	return &Clamd{Network: network, Address: addr, Timeout: timeout, ChunkSize: 64 << 10}, nil
+}
+
+func (c *Clamd) Name() string { return "clamd" }
+
+// Scan sends data as INSTREAM chunks, each prefixed with its length, and an
+// empty chunk to end the stream. clamd answers "stream: OK" or
+// "stream: <signature> FOUND".
+func (c *Clamd) Scan(ctx context.Context, data []byte) (Result, error) {
+	reply, err := c.command(ctx, "zINSTREAM\x00", func(w *bufio.Writer) error {
+		var size [4]byte
+		for len(data) > 0 {
+			n := min(len(data), c.ChunkSize)
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err := w.Write(size[:]); err != nil {
+				return err
+			}
+			if _, err := w.Write(data[:n]); err != nil {
+				return err
+			}
+			data = data[n:]
+		}
+		binary.BigEndian.PutUint32(size[:], 0)
+		_, err := w.Write(size[:])
+		return err
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return parseReply(reply)
+}
+
+func parseReply(reply string) (Result, error) {
+	_, status, _ := strings.Cut(reply, ": ")
+	switch {
+	case status == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(status, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(status, " FOUND")}, nil
+	}
+	// e.g. "INSTREAM size limit exceeded. ERROR"
+	return Result{}, fmt.Errorf("%w: clamd replied %q", ErrScanFailed, reply)
+}
+
+// Ping checks that clamd is up.
+func (c *Clamd) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, "zPING\x00", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("%w: clamd replied %q to PING", ErrScanFailed, reply)
+	}
+	return nil
+}
+
+// command sends a null-terminated command, lets body write its payload and
+// returns the null-terminated reply.
+func (c *Clamd) command(ctx context.Context, cmd string, body func(*bufio.Writer) error) (string, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.Network, c.Address)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	w := bufio.NewWriter(conn)
+	_, werr := w.WriteString(cmd)
+	if werr == nil && body != nil {
+		werr = body(w)
+	}
+	if werr == nil {
+		werr = w.Flush()
+	}
+
+	// clamd may answer and close the connection before the stream ends,
+	// e.g. when it exceeds StreamMaxLength, so the reply is read even after
+	// a failed write.
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if len(reply) == 0 {
+		if werr != nil {
+			err = werr
+		}
+		return "", fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	return string(bytes.TrimSpace(bytes.TrimRight(reply, "\x00"))), nil
+}
@@ -0,0 +1,176 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eicar is the standard antivirus test file.
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd is a stand-in for clamd that speaks the PING and INSTREAM
+// commands and detects the EICAR string.
+func fakeClamd(t *testing.T, maxStream int) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, maxStream)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func serveClamd(conn net.Conn, maxStream int) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	cmd, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	switch cmd {
+	case "zPING\x00":
+		conn.Write([]byte("PONG\x00"))
+	case "zINSTREAM\x00":
+		var stream []byte
+		for {
+			var size [4]byte
+			if _, err := io.ReadFull(r, size[:]); err != nil {
+				return
+			}
+			n := int(binary.BigEndian.Uint32(size[:]))
+			if n == 0 {
+				break
+			}
+			if len(stream)+n > maxStream {
+				conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+				return
+			}
+			chunk := make([]byte, n)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return
+			}
+			stream = append(stream, chunk...)
+		}
+		if bytes.Contains(stream, []byte(eicar)) {
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+		} else {
+			conn.Write([]byte("stream: OK\x00"))
+		}
+	default:
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+	}
+}
+
+func TestClamdScan(t *testing.T) {
+	addr := fakeClamd(t, 1<<20)
+	tests := []struct {
+		name          string
+		data          []byte
+		wantInfected  bool
+		wantSignature string
+		wantErr       bool
+	}{
+		{name: "clean file", data: []byte("\xff\xd8\xff\xe0 a harmless JPEG")},
+		{name: "empty file", data: nil},
+		{name: "test virus", data: []byte("%PDF-1.4\n" + eicar), wantInfected: true, wantSignature: "Eicar-Test-Signature"},
+		{name: "virus across chunks", data: []byte(strings.Repeat("a", 30) + eicar), wantInfected: true, wantSignature: "Eicar-Test-Signature"},
+		{name: "stream too long", data: make([]byte, 2<<20), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClamd("tcp://"+addr, 5*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.ChunkSize = 16
+			if len(tt.data) > 1<<20 {
+				c.ChunkSize = 64 << 10
+			}
+			res, err := c.Scan(context.Background(), tt.data)
+			if tt.wantErr {
+				if !errors.Is(err, ErrScanFailed) {
+					t.Fatalf("err = %v, want ErrScanFailed", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Infected != tt.wantInfected || res.Signature != tt.wantSignature {
+				t.Errorf("got %+v, want infected=%v signature=%q", res, tt.wantInfected, tt.wantSignature)
+			}
+		})
+	}
+}
+
+func TestClamdPing(t *testing.T) {
+	c, err := NewClamd(fakeClamd(t, 1<<20), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClamdUnavailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	c, err := NewClamd(addr, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Scan(context.Background(), []byte("data")); !errors.Is(err, ErrScanFailed) {
+		t.Errorf("Scan: err = %v, want ErrScanFailed", err)
+	}
+	if err := c.Ping(context.Background()); !errors.Is(err, ErrScanFailed) {
+		t.Errorf("Ping: err = %v, want ErrScanFailed", err)
+	}
+}
+
+func TestNewClamd(t *testing.T) {
+	tests := []struct {
+		address, network, addr string
+		wantErr                bool
+	}{
+		{"localhost:3310", "tcp", "localhost:3310", false},
+		{"tcp://clamav:3310", "tcp", "clamav:3310", false},
+		{"unix:///run/clamav/clamd.ctl", "unix", "/run/clamav/clamd.ctl", false},
+		{"http://clamav:3310", "", "", true},
+		{"", "", "", true},
+	}
+	for _, tt := range tests {
+		c, err := NewClamd(tt.address, time.Second)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NewClamd(%q): expected an error", tt.address)
+			}
+			continue
+		}
+		if err != nil || c.Network != tt.network || c.Address != tt.addr {
+			t.Errorf("NewClamd(%q) = %+v, %v", tt.address, c, err)
+		}
+	}
+}
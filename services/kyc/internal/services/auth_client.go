@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrUserNotFound is returned when the auth service does not know a user.
+var ErrUserNotFound = errors.New("user not found")
+
+// AuthClient looks users up in the auth service on behalf of callers that
+// have no user token, such as other services asking for a KYC tier.
+type AuthClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewAuthClient creates an AuthClient authenticating with the auth
+// service's SERVICE_API_TOKEN.
+func NewAuthClient(baseURL, token string) *AuthClient {
+	return &AuthClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// PhoneVerified reports whether the user has confirmed a phone number.
+func (a *AuthClient) PhoneVerified(ctx context.Context, userID string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+"/internal/users/"+url.PathEscape(userID), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("auth service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, ErrUserNotFound
+	default:
+		return false, fmt.Errorf("auth service returned status %d", resp.StatusCode)
+	}
+	var user struct {
+		PhoneVerified bool `json:"phone_verified"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return false, fmt.Errorf("failed to decode auth response: %w", err)
+	}
+	return user.PhoneVerified, nil
+}
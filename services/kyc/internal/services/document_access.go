@@ -0,0 +1,161 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"kyc/internal/models"
+	"kyc/internal/repository"
+	"kyc/internal/uploads"
+	"kyc/internal/watermark"
+)
+
+var (
+	ErrLinkExpired      = errors.New("document link has expired")
+	ErrInvalidSignature = errors.New("invalid document link signature")
+	ErrNoSuchDocument   = errors.New("no such document")
+	// ErrNoDocumentSecret is returned without a signing secret. A random
+	// one would break issued links on restart and across replicas.
+	ErrNoDocumentSecret = errors.New("DOCUMENT_URL_SECRET is not set")
+)
+
+// DocumentAccess issues short-lived signed URLs for reviewers to view the
+// images of a KYC request, and serves them. Every issued link and every
+// view is written to the access log.
+type DocumentAccess struct {
+	secret    []byte
+	ttl       time.Duration
+	uploadDir string
+	log       *repository.AccessLogRepository
+	// Watermark stamps the reviewer's ID and the time on served JPEG/PNG images.
+	Watermark bool
+}
+
+// NewDocumentAccess creates a DocumentAccess signing links with secret.
+func NewDocumentAccess(secret string, ttl time.Duration, uploadDir string, accessLog *repository.AccessLogRepository, watermark bool) (*DocumentAccess, error) {
+	if secret == "" {
+		return nil, ErrNoDocumentSecret
+	}
+	return &DocumentAccess{secret: []byte(secret), ttl: ttl, uploadDir: uploadDir, log: accessLog, Watermark: watermark}, nil
+}
+
+// SignedURL is a link to one document of a request.
+type SignedURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// documentPath returns the stored file of image index of kyc, or of its
+// selfie for models.SelfieIndex.
+func documentPath(kyc *models.KYCRequest, index int) (string, bool) {
+	if index == models.SelfieIndex {
+		return kyc.Selfie, kyc.Selfie != ""
+	}
+	if index < 0 || index >= len(kyc.Images) {
+		return "", false
+	}
+	return kyc.Images[index], true
+}
+
+// Issue returns a signed URL for image index of kyc, valid for the
+// configured TTL and bound to reviewer.
+func (d *DocumentAccess) Issue(ctx context.Context, kyc *models.KYCRequest, index int, reviewer string, entry models.DocumentAccess) (*SignedURL, error) {
+	if _, ok := documentPath(kyc, index); !ok {
+		return nil, ErrNoSuchDocument
+	}
+	expires := time.Now().Add(d.ttl).Truncate(time.Second)
+	query := url.Values{}
+	query.Set("reviewer", reviewer)
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("signature", d.sign(kyc.ID.Hex(), index, reviewer, expires.Unix()))
+
+	entry.KYCID, entry.Image, entry.Reviewer, entry.Action = kyc.ID, index, reviewer, models.AccessIssued
+	if err := d.log.Create(ctx, &entry); err != nil {
+		return nil, err
+	}
+	return &SignedURL{
+		URL:       fmt.Sprintf("/kyc/documents/%s/%d?%s", kyc.ID.Hex(), index, query.Encode()),
+		ExpiresAt: expires,
+	}, nil
+}
+
+// Verify checks the signature and expiry of a document link and returns
+// the reviewer it was issued to.
+func (d *DocumentAccess) Verify(kycID string, index int, query url.Values, now time.Time) (string, error) {
+	reviewer := query.Get("reviewer")
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || reviewer == "" {
+		return "", ErrInvalidSignature
+	}
+	expected := d.sign(kycID, index, reviewer, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return "", ErrInvalidSignature
+	}
+	if now.Unix() > expires {
+		return "", ErrLinkExpired
+	}
+	return reviewer, nil
+}
+
+func (d *DocumentAccess) sign(kycID string, index int, reviewer string, expires int64) string {
+	mac := hmac.New(sha256.New, d.secret)
+	fmt.Fprintf(mac, "%s\n%d\n%s\n%d", kycID, index, reviewer, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Open logs the view and returns the content of image index of kyc with its
+// content type, watermarked for reviewer if enabled.
+func (d *DocumentAccess) Open(ctx context.Context, kyc *models.KYCRequest, index int, reviewer string, entry models.DocumentAccess) ([]byte, string, error) {
+	stored, ok := documentPath(kyc, index)
+	if !ok {
+		return nil, "", ErrNoSuchDocument
+	}
+	path := filepath.Clean(stored)
+	if !strings.HasPrefix(path, filepath.Clean(d.uploadDir)+string(filepath.Separator)) {
+		return nil, "", ErrNoSuchDocument
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	kind, ok := uploads.Sniff(data)
+	if !ok {
+		return nil, "", uploads.ErrUnsupported
+	}
+
+	entry.KYCID, entry.Image, entry.Reviewer, entry.Action = kyc.ID, index, reviewer, models.AccessViewed
+	if err := d.log.Create(ctx, &entry); err != nil {
+		return nil, "", err
+	}
+
+	if !d.Watermark || (kind != uploads.KindJPEG && kind != uploads.KindPNG) {
+		return data, kind.MIME(), nil
+	}
+	img, err := uploads.DecodeForAnalysis(data, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	stamped := watermark.Apply(img, reviewer+" "+entry.At.UTC().Format("2006-01-02 15:04"))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, stamped, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), uploads.KindJPEG.MIME(), nil
+}
+
+// AccessLog returns the access log of a request, newest first.
+func (d *DocumentAccess) AccessLog(ctx context.Context, kyc *models.KYCRequest) ([]models.DocumentAccess, error) {
+	return d.log.GetByKYCID(ctx, kyc.ID)
+}
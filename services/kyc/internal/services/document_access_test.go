@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"kyc/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDocumentAccessVerify(t *testing.T) {
+	d, err := NewDocumentAccess("test-secret", 10*time.Minute, t.TempDir(), nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const kycID = "65f1c0ffee0000000000abcd"
+	now := time.Date(2026, 4, 1, 10, 0, 0, 0, time.UTC)
+	expires := now.Add(10 * time.Minute).Unix()
+	link := func(index int, reviewer string, expires int64) url.Values {
+		return url.Values{
+			"reviewer":  {reviewer},
+			"expires":   {strconv.FormatInt(expires, 10)},
+			"signature": {d.sign(kycID, index, reviewer, expires)},
+		}
+	}
+	with := func(q url.Values, key, value string) url.Values {
+		changed := maps.Clone(q)
+		changed.Set(key, value)
+		return changed
+	}
+	valid := link(1, "reviewer-1", expires)
+
+	tests := []struct {
+		name    string
+		kycID   string
+		index   int
+		query   url.Values
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", kycID, 1, valid, now, nil},
+		{"valid until the last second", kycID, 1, valid, time.Unix(expires, 0), nil},
+		{"selfie", kycID, models.SelfieIndex, link(models.SelfieIndex, "reviewer-1", expires), now, nil},
+		{"expired", kycID, 1, valid, time.Unix(expires+1, 0), ErrLinkExpired},
+		{"other reviewer", kycID, 1, with(valid, "reviewer", "reviewer-2"), now, ErrInvalidSignature},
+		{"other index", kycID, 2, valid, now, ErrInvalidSignature},
+		{"other request", "65f1c0ffee0000000000abce", 1, valid, now, ErrInvalidSignature},
+		{"extended expiry", kycID, 1, with(valid, "expires", strconv.FormatInt(expires+3600, 10)), now, ErrInvalidSignature},
+		{"expiry not a number", kycID, 1, with(valid, "expires", "soon"), now, ErrInvalidSignature},
+		{"no reviewer", kycID, 1, link(1, "", expires), now, ErrInvalidSignature},
+		{"no signature", kycID, 1, with(valid, "signature", ""), now, ErrInvalidSignature},
+		{"other secret", kycID, 1, with(valid, "signature", (&DocumentAccess{secret: []byte("other")}).sign(kycID, 1, "reviewer-1", expires)), now, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		reviewer, err := d.Verify(tt.kycID, tt.index, tt.query, tt.now)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && reviewer != tt.query.Get("reviewer") {
+			t.Errorf("%s: reviewer = %q, want %q", tt.name, reviewer, tt.query.Get("reviewer"))
+		}
+	}
+}
+
+func TestNewDocumentAccessRequiresSecret(t *testing.T) {
+	if _, err := NewDocumentAccess("", time.Minute, t.TempDir(), nil, false); !errors.Is(err, ErrNoDocumentSecret) {
+		t.Errorf("err = %v, want ErrNoDocumentSecret", err)
+	}
+}
+
+func TestDocumentAccessOpenStaysInUploadDir(t *testing.T) {
+	root := t.TempDir()
+	uploadDir := filepath.Join(root, "uploads")
+	d, err := NewDocumentAccess("test-secret", time.Minute, uploadDir, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kyc := &models.KYCRequest{
+		ID: primitive.NewObjectID(),
+		Images: []string{
+			"/etc/passwd",
+			filepath.Join(uploadDir, "..", "secret.png"),
+			filepath.Join(root, "uploads-old", "front.png"),
+			uploadDir,
+		},
+	}
+	for i, path := range kyc.Images {
+		if _, _, err := d.Open(context.Background(), kyc, i, "reviewer-1", models.DocumentAccess{}); !errors.Is(err, ErrNoSuchDocument) {
+			t.Errorf("Open(%s) err = %v, want ErrNoSuchDocument", path, err)
+		}
+	}
+	for _, index := range []int{-1, len(kyc.Images), models.SelfieIndex} {
+		if _, _, err := d.Open(context.Background(), kyc, index, "reviewer-1", models.DocumentAccess{}); !errors.Is(err, ErrNoSuchDocument) {
+			t.Errorf("Open(index %d) err = %v, want ErrNoSuchDocument", index, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"os"
+
+	"kyc/internal/imagehash"
+	"kyc/internal/models"
+	"kyc/internal/repository"
+	"kyc/internal/uploads"
+)
+
+// Images whose pHash or dHash differ by at most this many bits are treated
+// as the same photo. It must stay below 8 for imagehash.Bands to find every
+// such pair.
+const maxImageHashDistance = 6
+
+// hashSourceDimension is the size images are reduced to before hashing;
+// the hashes only look at 32x32 pixels anyway.
+const hashSourceDimension = 256
+
+// DuplicateDetector looks for other users' submissions that reuse the same
+// document image.
+type DuplicateDetector struct {
+	hashes *repository.ImageHashRepository
+}
+
+func NewDuplicateDetector(hashes *repository.ImageHashRepository) *DuplicateDetector {
+	return &DuplicateDetector{hashes: hashes}
+}
+
+// HashImage computes the perceptual hashes of a stored JPEG or PNG. It
+// returns nil for formats that cannot be decoded (HEIC, PDF).
+func HashImage(path string) (*models.ImageHash, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	img, err := uploads.DecodeForAnalysis(data, hashSourceDimension)
+	if err != nil {
+		return nil, nil
+	}
+	p, d := imagehash.PHash(img), imagehash.DHash(img)
+	return &models.ImageHash{
+		Image: path,
+		PHash: int64(p),
+		DHash: int64(d),
+		Bands: append(imagehash.Bands(p), dHashBands(d)...),
+	}, nil
+}
+
+// dHash bands are offset so they never collide with pHash bands.
+func dHashBands(h imagehash.Hash) []int64 {
+	bands := imagehash.Bands(h)
+	for i := range bands {
+		bands[i] |= 1 << 32
+	}
+	return bands
+}
+
+// RebuildBands recomputes the bands of hashes stored with an older band
+// layout, which FindCandidates would otherwise miss. It returns how many
+// were updated.
+func (d *DuplicateDetector) RebuildBands(ctx context.Context) (int, error) {
+	stale, err := d.hashes.FindWithBandBelow(ctx, imagehash.BandTag)
+	if err != nil {
+		return 0, err
+	}
+	for i, h := range stale {
+		bands := append(imagehash.Bands(imagehash.Hash(h.PHash)), dHashBands(imagehash.Hash(h.DHash))...)
+		if err := d.hashes.SetBands(ctx, h.ID, bands); err != nil {
+			return i, err
+		}
+	}
+	return len(stale), nil
+}
+
+// FindImageMatches returns near-duplicates of the given hashes that belong
+// to other users.
+func (d *DuplicateDetector) FindImageMatches(ctx context.Context, userID string, hashes []models.ImageHash) ([]models.FraudMatch, error) {
+	var matches []models.FraudMatch
+	seen := map[string]bool{}
+	for _, h := range hashes {
+		candidates, err := d.hashes.FindCandidates(ctx, h.Bands, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candidates {
+			dist := min(
+				imagehash.Distance(imagehash.Hash(h.PHash), imagehash.Hash(c.PHash)),
+				imagehash.Distance(imagehash.Hash(h.DHash), imagehash.Hash(c.DHash)),
+			)
+			key := h.Image + "|" + c.KYCID.Hex()
+			if dist > maxImageHashDistance || seen[key] {
+				continue
+			}
+			seen[key] = true
+			matches = append(matches, models.FraudMatch{
+				Kind:     models.MatchImage,
+				KYCID:    c.KYCID,
+				UserID:   c.UserID,
+				Image:    h.Image,
+				Distance: dist,
+			})
+		}
+	}
+	return matches, nil
+}
+
+// SaveHashes records the hashes of an accepted submission.
+func (d *DuplicateDetector) SaveHashes(ctx context.Context, kyc *models.KYCRequest, hashes []models.ImageHash) error {
+	for i := range hashes {
+		hashes[i].KYCID = kyc.ID
+		hashes[i].UserID = kyc.UserID
+	}
+	return d.hashes.CreateMany(ctx, hashes)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"kyc/internal/models"
+	"kyc/internal/repository"
+)
+
+// Expirer moves requests to EXPIRED: approvals whose document has expired,
+// and NEEDS_INFO requests the user did not answer within NeedsInfoTTL.
+type Expirer struct {
+	repo         *repository.KYCRepository
+	NeedsInfoTTL time.Duration
+}
+
+func NewExpirer(repo *repository.KYCRepository, needsInfoTTL time.Duration) *Expirer {
+	return &Expirer{repo: repo, NeedsInfoTTL: needsInfoTTL}
+}
+
+// Run expires requests every interval until ctx is cancelled.
+func (e *Expirer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := e.ExpireOnce(ctx, time.Now()); err != nil {
+			log.Printf("Expiry run failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Expired %d KYC requests", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireOnce expires every request that is due at now and returns how many
+// were changed.
+func (e *Expirer) ExpireOnce(ctx context.Context, now time.Time) (int, error) {
+	unanswered, err := e.repo.GetNeedsInfoBefore(ctx, now.Add(-e.NeedsInfoTTL))
+	if err != nil {
+		return 0, err
+	}
+	expired, err := e.repo.GetApprovedExpiringBefore(ctx, now.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, kyc := range append(unanswered, expired...) {
+		reason := "Document expired"
+		if kyc.Status == models.StatusNeedsInfo {
+			reason = "No response to the information request"
+		}
+		change := models.StatusChange{To: models.StatusExpired, Actor: models.ActorSystem, Reason: reason, At: now}
+		if err := CheckTransition(&kyc, change, now); err != nil {
+			continue
+		}
+		// A conflict means a reviewer or the user acted first; skip it.
+		if err := e.repo.Transition(ctx, &kyc, change); err != nil {
+			if err != repository.ErrVersionConflict {
+				log.Printf("Failed to expire KYC request %s: %v", kyc.ID.Hex(), err)
+			}
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
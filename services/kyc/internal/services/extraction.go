@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"kyc/internal/matching"
+	"kyc/internal/models"
+)
+
+// Minimum similarity scores below which a request is flagged for the reviewer.
+const (
+	minDocumentNumberScore = 0.9
+	minNameScore           = 0.85
+)
+
+// FieldExtractor reads structured fields from a document image.
+type FieldExtractor interface {
+	ExtractFields(ctx context.Context, imagePath string) (*models.ExtractedFields, error)
+}
+
+// ExtractFields asks each verifier in the chain that supports extraction,
+// falling back to the next one on failure.
+func (c *ChainVerifier) ExtractFields(ctx context.Context, imagePath string) (*models.ExtractedFields, error) {
+	var errs []error
+	for _, v := range c.verifiers {
+		extractor, ok := v.(FieldExtractor)
+		if !ok {
+			continue
+		}
+		fields, err := extractor.ExtractFields(ctx, imagePath)
+		if err == nil {
+			return fields, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("Extractor %s failed, trying next: %v", v.Name(), err)
+		errs = append(errs, fmt.Errorf("%s: %w", v.Name(), err))
+	}
+	if len(errs) == 0 {
+		return nil, ErrNotConfigured
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ParseExtractedFields decodes the JSON answer to an extraction prompt.
+func ParseExtractedFields(text string) (*models.ExtractedFields, error) {
+	var answer struct {
+		FullName       string `json:"full_name"`
+		DateOfBirth    string `json:"date_of_birth"`
+		DocumentNumber string `json:"document_number"`
+		ExpiryDate     string `json:"expiry_date"`
+		MRZ            string `json:"mrz"`
+	}
+	if err := json.Unmarshal([]byte(jsonObject(text)), &answer); err != nil {
+		return nil, fmt.Errorf("failed to parse extracted fields: %w", err)
+	}
+
+	return &models.ExtractedFields{
+		FullName:       strings.TrimSpace(answer.FullName),
+		DateOfBirth:    normalizeDate(answer.DateOfBirth),
+		DocumentNumber: strings.TrimSpace(answer.DocumentNumber),
+		ExpiryDate:     normalizeDate(answer.ExpiryDate),
+		MRZ:            strings.TrimSpace(answer.MRZ),
+		RawOutput:      text,
+	}, nil
+}
+
+var dateLayouts = []string{"2006-01-02", "02 Jan 2006", "2 Jan 2006", "02 January 2006", "Jan 2, 2006"}
+
+// numericDateLayouts print the day first. They are only trusted when the day
+// cannot be read as a month, since 03/04/1990 is 3 April in Bangladesh but
+// 4 March on a US licence.
+var numericDateLayouts = []string{"02/01/2006", "02-01-2006", "02.01.2006"}
+
+// normalizeDate converts common printed date formats to YYYY-MM-DD.
+// Dates that cannot be parsed, or that read differently day-first and
+// month-first, are dropped rather than stored ambiguously.
+func normalizeDate(s string) string {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	for _, layout := range numericDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			if t.Day() <= 12 && t.Day() != int(t.Month()) {
+				return ""
+			}
+			return t.Format("2006-01-02")
+		}
+	}
+	return ""
+}
+
+// MatchFields compares the extracted fields with the submitted document
+// number and the user's profile name, and returns any flags to raise.
+func MatchFields(fields *models.ExtractedFields, documentNumber, profileName string) (*models.FieldMatch, []string) {
+	match := &models.FieldMatch{ProfileName: profileName}
+	var flags []string
+
+	if fields.DocumentNumber != "" {
+		score := matching.DocumentNumberSimilarity(fields.DocumentNumber, documentNumber)
+		match.DocumentNumberScore = &score
+		if score < minDocumentNumberScore {
+			flags = append(flags, models.FlagDocumentNumberMismatch)
+		}
+	}
+
+	if fields.FullName != "" && profileName != "" {
+		score := matching.NameSimilarity(fields.FullName, profileName)
+		match.NameScore = &score
+		if score < minNameScore {
+			flags = append(flags, models.FlagNameMismatch)
+		}
+	}
+
+	if fields.ExpiryDate != "" {
+		if expiry, err := time.Parse("2006-01-02", fields.ExpiryDate); err == nil && expiry.Before(time.Now()) {
+			flags = append(flags, models.FlagDocumentExpired)
+		}
+	}
+
+	return match, flags
+}
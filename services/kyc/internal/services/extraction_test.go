@@ -0,0 +1,27 @@
+package services
+
+import "testing"
+
+func TestNormalizeDate(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"1990-04-03", "1990-04-03"},
+		{"25/12/1990", "1990-12-25"},
+		{"25-12-1990", "1990-12-25"},
+		{"13.01.1990", "1990-01-13"},
+		{"05/05/1990", "1990-05-05"},
+		{"03/04/1990", ""}, // 3 April or 4 March
+		{"12.11.1990", ""},
+		{"12/25/1990", ""},
+		{"3 Apr 1990", "1990-04-03"},
+		{"03 April 1990", "1990-04-03"},
+		{"Apr 3, 1990", "1990-04-03"},
+		{"unknown", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeDate(tt.in); got != tt.want {
+			t.Errorf("normalizeDate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"kyc/internal/config"
+	"kyc/internal/imagehash"
+	"kyc/internal/models"
+	"kyc/internal/prompts"
+)
+
+// FaceMatcher compares a selfie with the portrait on a document image.
+type FaceMatcher interface {
+	Name() string
+	MatchFaces(ctx context.Context, selfiePath, documentPath string) (*models.FaceMatch, error)
+}
+
+// stubFaceMatchDistance is the pHash distance up to which the stub matcher
+// reports the same person. Unrelated images are about 32 apart.
+const stubFaceMatchDistance = 16
+
+// StubFaceMatcher is a deterministic matcher for tests and local
+// development. It does not look for faces: it compares the perceptual
+// hashes of the two images, so the outcome depends only on their content.
+// A selfie closer than maxSelfieDocumentDist also fails liveness as a copy
+// of the document.
+type StubFaceMatcher struct{}
+
+func NewStubFaceMatcher() *StubFaceMatcher {
+	return &StubFaceMatcher{}
+}
+
+func (m *StubFaceMatcher) Name() string { return "stub" }
+
+func (m *StubFaceMatcher) MatchFaces(ctx context.Context, selfiePath, documentPath string) (*models.FaceMatch, error) {
+	selfie, err := HashImage(selfiePath)
+	if err != nil {
+		return nil, err
+	}
+	document, err := HashImage(documentPath)
+	if err != nil {
+		return nil, err
+	}
+	if selfie == nil || document == nil {
+		return nil, errors.New("stub face matcher needs JPEG or PNG images")
+	}
+	dist := imagehash.Distance(imagehash.Hash(selfie.PHash), imagehash.Hash(document.PHash))
+	return &models.FaceMatch{
+		Provider:   m.Name(),
+		Document:   documentPath,
+		Similarity: 1 - float64(dist)/64,
+		Match:      dist <= stubFaceMatchDistance,
+		Reason:     fmt.Sprintf("perceptual hash distance %d", dist),
+		CheckedAt:  time.Now(),
+	}, nil
+}
+
+// MatchFaces asks the model whether the selfie and the document portrait
+// show the same person. Both images are sent in one request.
+func (s *VerificationService) MatchFaces(ctx context.Context, selfiePath, documentPath string) (*models.FaceMatch, error) {
+	if !s.configured() {
+		return nil, ErrNotConfigured
+	}
+	selfie, err := loadModelImage(selfiePath, s.maxDimension)
+	if err != nil {
+		return nil, err
+	}
+	document, err := loadModelImage(documentPath, s.maxDimension)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := s.prompts.Select(prompts.TaskFaceMatch, documentTypeFrom(ctx), selfie.sha256)
+	if err != nil {
+		return nil, err
+	}
+	text, err := s.chat(ctx, prompt.Text, prompt.MaxTokens, selfie, document)
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := ParseFaceMatch(text)
+	if err != nil {
+		return nil, err
+	}
+	match.Provider = s.name
+	match.ModelID = s.modelID
+	match.PromptVersion = prompt.ID()
+	match.Document = documentPath
+	match.CheckedAt = time.Now()
+	return match, nil
+}
+
+// ParseFaceMatch decodes the JSON answer to a face match prompt.
+func ParseFaceMatch(text string) (*models.FaceMatch, error) {
+	var answer struct {
+		SamePerson bool    `json:"same_person"`
+		Similarity float64 `json:"similarity"`
+		Reason     string  `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(jsonObject(text)), &answer); err != nil {
+		return nil, fmt.Errorf("failed to parse face match: %w", err)
+	}
+	if answer.Similarity < 0 || answer.Similarity > 1 {
+		answer.Similarity = 0
+	}
+	return &models.FaceMatch{
+		Similarity: answer.Similarity,
+		Match:      answer.SamePerson,
+		Reason:     answer.Reason,
+		RawOutput:  text,
+	}, nil
+}
+
+// NewFaceMatcher builds the matcher named in cfg.FaceMatcher, or returns nil
+// when face matching is off.
+func NewFaceMatcher(cfg *config.Config, registry *prompts.Registry) (FaceMatcher, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.FaceMatcher)) {
+	case "", "none":
+		return nil, nil
+	case "stub", "mock":
+		return NewStubFaceMatcher(), nil
+	case "huggingface", "hf":
+		v := NewHuggingFaceVerifier(cfg.HuggingFaceAPIKey, cfg.HuggingFaceModelURL, cfg.HuggingFaceModelID)
+		v.configure(cfg)
+		v.prompts = registry
+		return v, nil
+	case "openai":
+		v := NewOpenAICompatibleVerifier(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.OpenAIModelID)
+		v.configure(cfg)
+		v.prompts = registry
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unknown face matcher %q in FACE_MATCHER", cfg.FaceMatcher)
+	}
+}
+
+// FaceCheck is the optional selfie step of a submission.
+type FaceCheck struct {
+	Matcher   FaceMatcher // nil runs only the liveness checks
+	Threshold float64     // Minimum similarity to count as a match
+	Required  bool        // Submissions without a selfie are refused
+}
+
+// Check runs the liveness checks on the selfie and compares it with the
+// portrait on the first document image. Failures never block the
+// submission; they are raised as flags for the reviewer.
+func (f *FaceCheck) Check(ctx context.Context, selfiePath string, documentPaths []string) (*models.FaceMatch, []string) {
+	var flags []string
+	match := &models.FaceMatch{Provider: "none", CheckedAt: time.Now()}
+	if f.Matcher != nil && len(documentPaths) > 0 {
+		m, err := f.Matcher.MatchFaces(ctx, selfiePath, documentPaths[0])
+		if err != nil {
+			log.Printf("Face match with %s failed: %v", f.Matcher.Name(), err)
+			match.Provider = f.Matcher.Name()
+			flags = append(flags, models.FlagFaceMatchUnavailable)
+		} else {
+			match = m
+			match.Match = m.Match && m.Similarity >= f.Threshold
+			if !match.Match {
+				flags = append(flags, models.FlagFaceMismatch)
+			}
+		}
+	}
+
+	liveness, err := CheckLiveness(selfiePath, documentPaths)
+	if err != nil {
+		log.Printf("Liveness check of %s failed: %v", selfiePath, err)
+		liveness = &models.Liveness{}
+	}
+	match.Liveness = liveness
+	if !liveness.Passed {
+		flags = append(flags, models.FlagLivenessFailed)
+	}
+	return match, flags
+}
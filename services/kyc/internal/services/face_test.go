@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"kyc/internal/config"
+	"kyc/internal/models"
+	"kyc/internal/modelstub"
+)
+
+// fakeMatcher returns a fixed answer for any pair of images.
+type fakeMatcher struct {
+	match *models.FaceMatch
+	err   error
+}
+
+func (m *fakeMatcher) Name() string { return "fake" }
+
+func (m *fakeMatcher) MatchFaces(ctx context.Context, selfiePath, documentPath string) (*models.FaceMatch, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	match := *m.match
+	match.Provider, match.Document = m.Name(), documentPath
+	return &match, nil
+}
+
+// writePNG writes a w x h image whose luma is drawn from [lo, hi) with the
+// given seed; lo == hi gives a flat image.
+func writePNG(t *testing.T, name string, w, h int, lo, hi uint8, seed int64) string {
+	t.Helper()
+	rng := rand.New(rand.NewSource(seed))
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = lo
+		if hi > lo {
+			img.Pix[i] += uint8(rng.Intn(int(hi - lo)))
+		}
+	}
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseFaceMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		similarity float64
+		match      bool
+		wantErr    bool
+	}{
+		{"same person", `{"same_person": true, "similarity": 0.91, "reason": "same face shape"}`, 0.91, true, false},
+		{"different person", `{"same_person": false, "similarity": 0.2}`, 0.2, false, false},
+		{"code fence", "Here you go:\n```json\n{\"same_person\": true, \"similarity\": 0.8}\n```", 0.8, true, false},
+		{"similarity above 1", `{"same_person": true, "similarity": 91}`, 0, true, false},
+		{"negative similarity", `{"same_person": false, "similarity": -0.5}`, 0, false, false},
+		{"missing fields", `{}`, 0, false, false},
+		{"bare text", "Yes, this is the same person.", 0, false, true},
+		{"wrong type", `{"same_person": "yes", "similarity": 0.9}`, 0, false, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFaceMatch(tt.text)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got.Similarity != tt.similarity || got.Match != tt.match || got.RawOutput != tt.text {
+			t.Errorf("%s: got %+v, want similarity %v, match %v", tt.name, got, tt.similarity, tt.match)
+		}
+	}
+}
+
+func TestCheckLiveness(t *testing.T) {
+	selfie := writePNG(t, "selfie.png", 400, 400, 40, 220, 1)
+	otherDocument := writePNG(t, "document.png", 600, 400, 40, 220, 2)
+	tests := []struct {
+		name      string
+		selfie    string
+		documents []string
+		want      []string
+	}{
+		{"good selfie", selfie, []string{otherDocument}, nil},
+		{"small", writePNG(t, "small.png", 200, 300, 40, 220, 3), nil, []string{models.LivenessLowResolution}},
+		{"flat", writePNG(t, "flat.png", 400, 400, 128, 128, 0), nil, []string{models.LivenessBlurry, models.LivenessLowContrast}},
+		{"dark", writePNG(t, "dark.png", 400, 400, 0, 40, 4), nil, []string{models.LivenessUnderexposed}},
+		{"bright", writePNG(t, "bright.png", 400, 400, 225, 255, 5), nil, []string{models.LivenessOverexposed}},
+		{"copy of the document", selfie, []string{otherDocument, selfie}, []string{models.LivenessSameAsDocument}},
+		{"undecodable document", selfie, []string{filepath.Join(t.TempDir(), "missing.png")}, nil},
+	}
+	for _, tt := range tests {
+		got, err := CheckLiveness(tt.selfie, tt.documents)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(got.Signals, tt.want) || got.Passed != (len(tt.want) == 0) {
+			t.Errorf("%s: signals %v (passed %v), want %v", tt.name, got.Signals, got.Passed, tt.want)
+		}
+		if wantScore := 1 - 0.25*float64(len(tt.want)); got.Score != wantScore {
+			t.Errorf("%s: score %v, want %v", tt.name, got.Score, wantScore)
+		}
+	}
+
+	text := filepath.Join(t.TempDir(), "selfie.txt")
+	os.WriteFile(text, []byte("not an image"), 0o600)
+	if _, err := CheckLiveness(text, nil); err == nil {
+		t.Error("undecodable selfie: no error")
+	}
+}
+
+func TestFaceCheck(t *testing.T) {
+	selfie := writePNG(t, "selfie.png", 400, 400, 40, 220, 1)
+	blurry := writePNG(t, "blurry.png", 400, 400, 128, 128, 0)
+	documents := []string{writePNG(t, "front.png", 600, 400, 40, 220, 2), writePNG(t, "back.png", 600, 400, 40, 220, 3)}
+
+	tests := []struct {
+		name         string
+		matcher      FaceMatcher
+		selfie       string
+		documents    []string
+		wantProvider string
+		wantMatch    bool
+		wantFlags    []string
+	}{
+		{
+			name:         "match",
+			matcher:      &fakeMatcher{match: &models.FaceMatch{Similarity: 0.9, Match: true}},
+			selfie:       selfie,
+			documents:    documents,
+			wantProvider: "fake",
+			wantMatch:    true,
+		},
+		{
+			name:         "below threshold",
+			matcher:      &fakeMatcher{match: &models.FaceMatch{Similarity: 0.7, Match: true}},
+			selfie:       selfie,
+			documents:    documents,
+			wantProvider: "fake",
+			wantFlags:    []string{models.FlagFaceMismatch},
+		},
+		{
+			name:         "different person",
+			matcher:      &fakeMatcher{match: &models.FaceMatch{Similarity: 0.95, Match: false}},
+			selfie:       selfie,
+			documents:    documents,
+			wantProvider: "fake",
+			wantFlags:    []string{models.FlagFaceMismatch},
+		},
+		{
+			name:         "matcher down",
+			matcher:      &fakeMatcher{err: errors.New("connection refused")},
+			selfie:       selfie,
+			documents:    documents,
+			wantProvider: "fake",
+			wantFlags:    []string{models.FlagFaceMatchUnavailable},
+		},
+		{
+			name:         "no matcher",
+			selfie:       selfie,
+			documents:    documents,
+			wantProvider: "none",
+		},
+		{
+			name:         "no document image",
+			matcher:      &fakeMatcher{match: &models.FaceMatch{Similarity: 0.9, Match: true}},
+			selfie:       selfie,
+			wantProvider: "none",
+		},
+		{
+			name:         "blurry selfie",
+			matcher:      &fakeMatcher{match: &models.FaceMatch{Similarity: 0.9, Match: true}},
+			selfie:       blurry,
+			documents:    documents,
+			wantProvider: "fake",
+			wantMatch:    true,
+			wantFlags:    []string{models.FlagLivenessFailed},
+		},
+		{
+			name:         "undecodable selfie",
+			matcher:      &fakeMatcher{err: errors.New("image cannot be sent to the model")},
+			selfie:       filepath.Join(t.TempDir(), "missing.heic"),
+			documents:    documents,
+			wantProvider: "fake",
+			wantFlags:    []string{models.FlagFaceMatchUnavailable, models.FlagLivenessFailed},
+		},
+	}
+	for _, tt := range tests {
+		check := &FaceCheck{Matcher: tt.matcher, Threshold: 0.75}
+		got, flags := check.Check(context.Background(), tt.selfie, tt.documents)
+		if got.Provider != tt.wantProvider || got.Match != tt.wantMatch || !slices.Equal(flags, tt.wantFlags) {
+			t.Errorf("%s: provider %s, match %v, flags %v, want %s, %v, %v", tt.name, got.Provider, got.Match, flags, tt.wantProvider, tt.wantMatch, tt.wantFlags)
+		}
+		if got.Provider == "fake" && !slices.Contains(flags, models.FlagFaceMatchUnavailable) && got.Document != tt.documents[0] {
+			t.Errorf("%s: compared with %s, want the first document image", tt.name, got.Document)
+		}
+		if got.Liveness == nil {
+			t.Errorf("%s: no liveness result", tt.name)
+		}
+	}
+}
+
+func TestMatchFacesSendsBothImages(t *testing.T) {
+	server, script := modelstub.NewServer(modelstub.Chatty(`{"same_person": true, "similarity": 0.88, "reason": "same person"}`))
+	defer server.Close()
+	v := NewOpenAICompatibleVerifier(server.URL, "", "test-model")
+	v.retry = fastRetries
+
+	selfie := writePNG(t, "selfie.png", 64, 64, 40, 220, 1)
+	document := writeTestImage(t)
+	got, err := v.MatchFaces(context.Background(), selfie, document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Match || got.Similarity != 0.88 || got.Document != document || got.PromptVersion == "" || got.ModelID != "test-model" {
+		t.Errorf("got %+v", got)
+	}
+
+	var req struct {
+		Messages []struct {
+			Content []struct {
+				Type string `json:"type"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	requests := script.Requests()
+	if len(requests) != 1 || json.Unmarshal(requests[0], &req) != nil || len(req.Messages) == 0 {
+		t.Fatalf("requests: %d", len(requests))
+	}
+	images := 0
+	for _, part := range req.Messages[0].Content {
+		if part.Type == "image_url" {
+			images++
+		}
+	}
+	if images != 2 {
+		t.Errorf("%d images sent, want the selfie and the document", images)
+	}
+}
+
+func TestStubFaceMatcher(t *testing.T) {
+	document := writePNG(t, "front.png", 240, 160, 20, 220, 1)
+	brighter := writePNG(t, "mismatch-other.png", 240, 160, 30, 230, 1) // Same photo, brighter
+	unrelated := writePNG(t, "selfie.png", 240, 160, 20, 220, 2)
+	notAnImage := filepath.Join(t.TempDir(), "selfie.heic")
+	if err := os.WriteFile(notAnImage, []byte("\x00\x00\x00\x18ftypheic"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewFaceMatcher(&config.Config{FaceMatcher: "stub"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		selfie    string
+		wantMatch bool
+		wantErr   bool
+	}{
+		{"same image", document, true, false},
+		{"same photo, name says mismatch", brighter, true, false},
+		{"unrelated photo", unrelated, false, false},
+		{"undecodable selfie", notAnImage, false, true},
+	}
+	for _, tt := range tests {
+		match, err := m.MatchFaces(context.Background(), tt.selfie, document)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if match.Match != tt.wantMatch || match.Provider != "stub" || match.Document != document {
+			t.Errorf("%s: got %+v, want match %v", tt.name, match, tt.wantMatch)
+		}
+		if tt.wantMatch && match.Similarity < 0.75 || !tt.wantMatch && match.Similarity >= 0.75 {
+			t.Errorf("%s: similarity %v on the wrong side of the default threshold", tt.name, match.Similarity)
+		}
+		again, _ := m.MatchFaces(context.Background(), tt.selfie, document)
+		if again.Similarity != match.Similarity {
+			t.Errorf("%s: similarity changed between calls: %v, %v", tt.name, match.Similarity, again.Similarity)
+		}
+	}
+}
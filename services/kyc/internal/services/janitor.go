@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kyc/internal/repository"
+)
+
+// Janitor deletes files in the upload directory that no KYC request refers
+// to, such as uploads of submissions that failed half-way. Files younger
+// than Grace are kept so that submissions in progress are not affected.
+type Janitor struct {
+	repo  *repository.KYCRepository
+	dir   string
+	Grace time.Duration
+}
+
+func NewJanitor(repo *repository.KYCRepository, dir string, grace time.Duration) *Janitor {
+	return &Janitor{repo: repo, dir: dir, Grace: grace}
+}
+
+// Run cleans the upload directory every interval until ctx is cancelled.
+func (j *Janitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := j.CleanOnce(ctx, time.Now()); err != nil {
+			log.Printf("Upload janitor failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Upload janitor removed %d orphaned files", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CleanOnce removes the orphaned files older than Grace at now and returns
+// how many were removed.
+func (j *Janitor) CleanOnce(ctx context.Context, now time.Time) (int, error) {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return removed, ctx.Err()
+		}
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < j.Grace {
+			continue
+		}
+		// Requests store the path as it was built on upload.
+		path := filepath.Join(j.dir, entry.Name())
+		if !strings.HasSuffix(path, ".tmp") {
+			referenced, err := j.repo.IsImageReferenced(ctx, path)
+			if err != nil {
+				return removed, err
+			}
+			if referenced {
+				continue
+			}
+		}
+		if err := os.Remove(path); err != nil {
+			log.Printf("Failed to remove orphaned upload %s: %v", path, err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+
+	"kyc/internal/imagehash"
+	"kyc/internal/models"
+	"kyc/internal/uploads"
+)
+
+// Thresholds of the passive liveness checks. Sharpness and exposure are
+// measured on the selfie downscaled to livenessDimension.
+const (
+	livenessDimension     = 512
+	minSelfieSide         = 320
+	minSharpness          = 30.0
+	minBrightness         = 45.0
+	maxBrightness         = 215.0
+	minContrast           = 20.0
+	maxSelfieDocumentDist = 10 // pHash distance below which the selfie is a copy of a document image
+)
+
+// CheckLiveness runs passive checks on a selfie: resolution, focus and
+// exposure, and whether it is a copy of one of the document images. These
+// catch low-effort replays such as photos of a printout or the ID itself;
+// they are signals for the reviewer, not proof of a live person.
+func CheckLiveness(selfiePath string, documentPaths []string) (*models.Liveness, error) {
+	data, err := os.ReadFile(selfiePath)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("selfie cannot be decoded: %w", err)
+	}
+	img, err := uploads.DecodeForAnalysis(data, livenessDimension)
+	if err != nil {
+		return nil, fmt.Errorf("selfie cannot be decoded: %w", err)
+	}
+
+	l := &models.Liveness{}
+	if min(cfg.Width, cfg.Height) < minSelfieSide {
+		l.Signals = append(l.Signals, models.LivenessLowResolution)
+	}
+
+	luma := lumaPlane(img)
+	l.Brightness, l.Contrast = meanStdDev(luma)
+	l.Sharpness = laplacianVariance(luma)
+	if l.Sharpness < minSharpness {
+		l.Signals = append(l.Signals, models.LivenessBlurry)
+	}
+	switch {
+	case l.Brightness < minBrightness:
+		l.Signals = append(l.Signals, models.LivenessUnderexposed)
+	case l.Brightness > maxBrightness:
+		l.Signals = append(l.Signals, models.LivenessOverexposed)
+	case l.Contrast < minContrast:
+		l.Signals = append(l.Signals, models.LivenessLowContrast)
+	}
+
+	selfieHash := imagehash.PHash(img)
+	for _, path := range documentPaths {
+		doc, err := HashImage(path)
+		if err != nil || doc == nil {
+			continue
+		}
+		if imagehash.Distance(selfieHash, imagehash.Hash(doc.PHash)) <= maxSelfieDocumentDist {
+			l.Signals = append(l.Signals, models.LivenessSameAsDocument)
+			break
+		}
+	}
+
+	l.Passed = len(l.Signals) == 0
+	l.Score = math.Max(0, 1-0.25*float64(len(l.Signals)))
+	return l, nil
+}
+
+// lumaPlane returns the grayscale values of img, row by row.
+func lumaPlane(img image.Image) [][]float64 {
+	b := img.Bounds()
+	plane := make([][]float64, b.Dy())
+	for y := range plane {
+		plane[y] = make([]float64, b.Dx())
+		for x := range plane[y] {
+			plane[y][x] = float64(color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y)
+		}
+	}
+	return plane
+}
+
+func meanStdDev(plane [][]float64) (float64, float64) {
+	var sum, sumSq float64
+	n := 0
+	for _, row := range plane {
+		for _, v := range row {
+			sum += v
+			sumSq += v * v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	mean := sum / float64(n)
+	return mean, math.Sqrt(math.Max(0, sumSq/float64(n)-mean*mean))
+}
+
+// laplacianVariance measures focus: blurred images have few edges, so the
+// response of the Laplacian kernel varies little.
+func laplacianVariance(plane [][]float64) float64 {
+	var lap []float64
+	for y := 1; y < len(plane)-1; y++ {
+		for x := 1; x < len(plane[y])-1; x++ {
+			lap = append(lap, plane[y-1][x]+plane[y+1][x]+plane[y][x-1]+plane[y][x+1]-4*plane[y][x])
+		}
+	}
+	_, sd := meanStdDev([][]float64{lap})
+	return sd * sd
+}
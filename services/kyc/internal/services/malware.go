@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kyc/internal/config"
+	"kyc/internal/models"
+	"kyc/internal/repository"
+	"kyc/internal/scanner"
+)
+
+// MalwareGuard scans uploads before they are stored. Infected files are
+// moved to a quarantine directory outside uploads/, where neither the API
+// nor the upload janitor touches them, and recorded in the audit log.
+type MalwareGuard struct {
+	scanner scanner.Scanner
+	dir     string
+	audit   *repository.QuarantineRepository
+}
+
+func NewMalwareGuard(s scanner.Scanner, dir string, audit *repository.QuarantineRepository) *MalwareGuard {
+	return &MalwareGuard{scanner: s, dir: dir, audit: audit}
+}
+
+// NewScanner builds the scanner named in MALWARE_SCANNER.
+func NewScanner(cfg *config.Config) (scanner.Scanner, error) {
+	switch cfg.MalwareScanner {
+	case "", "none":
+		return scanner.Nop{}, nil
+	case "clamd":
+		return scanner.NewClamd(cfg.ClamdAddress, cfg.ClamdTimeout)
+	}
+	return nil, fmt.Errorf("unknown malware scanner %q", cfg.MalwareScanner)
+}
+
+// Check scans data. A clean file returns nil. An infected one is
+// quarantined and its audit entry, which the caller fills with who sent
+// it, is stored and returned. An error means the file could not be scanned
+// and must not be stored either.
+func (g *MalwareGuard) Check(ctx context.Context, data []byte, entry models.QuarantinedUpload) (*models.QuarantinedUpload, error) {
+	res, err := g.scanner.Scan(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Infected {
+		return nil, nil
+	}
+
+	sum := sha256.Sum256(data)
+	entry.SHA256 = hex.EncodeToString(sum[:])
+	entry.Size = int64(len(data))
+	entry.Scanner = g.scanner.Name()
+	entry.Signature = res.Signature
+	entry.At = time.Now()
+
+	// The extension keeps the file from being opened by accident.
+	path := filepath.Join(g.dir, fmt.Sprintf("%d_%s.quarantine", entry.At.Unix(), entry.SHA256))
+	if err := quarantine(path, data); err != nil {
+		fmt.Printf("Failed to quarantine %s from user %s: %v\n", entry.OriginalName, entry.UserID, err)
+	} else {
+		entry.Path = path
+	}
+	if err := g.audit.Create(ctx, &entry); err != nil {
+		fmt.Printf("Failed to record quarantined upload %s from user %s: %v\n", entry.SHA256, entry.UserID, err)
+	}
+	return &entry, nil
+}
+
+func quarantine(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o400)
+}
+
+// QuarantineLog lists audit entries of quarantined uploads.
+func (g *MalwareGuard) QuarantineLog(ctx context.Context, userID string, limit int64) ([]models.QuarantinedUpload, error) {
+	return g.audit.List(ctx, userID, limit)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kyc/internal/models"
+)
+
+// VerifyLimits bounds the verification of one submission.
+type VerifyLimits struct {
+	Concurrency int           // Images verified at the same time
+	Timeout     time.Duration // Shared deadline for all images
+}
+
+// VerifyAll verifies the images concurrently within limits and returns the
+// results in the order of imagePaths. docTypes, if not nil, holds the
+// document type declared for each image; otherwise the one in ctx applies.
+// The first error cancels the calls still running and is returned.
+func VerifyAll(ctx context.Context, verifier DocumentVerifier, imagePaths []string, docTypes []string, limits VerifyLimits) ([]*models.VerificationResult, error) {
+	if limits.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, limits.Timeout)
+		defer cancelTimeout()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*models.VerificationResult, len(imagePaths))
+	sem := make(chan struct{}, max(limits.Concurrency, 1))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i, path := range imagePaths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				once.Do(func() { firstErr = ctx.Err() })
+				return
+			}
+			imageCtx := ctx
+			if docTypes != nil {
+				imageCtx = WithDocumentType(ctx, docTypes[i])
+			}
+			res, err := verifier.VerifyImage(imageCtx, path)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"kyc/internal/models"
+)
+
+// funcVerifier runs verify for each image and tracks how many calls run at once.
+type funcVerifier struct {
+	verify func(ctx context.Context, imagePath string) (*models.VerificationResult, error)
+
+	mu      sync.Mutex
+	running int
+	peak    int
+	calls   int
+}
+
+func (f *funcVerifier) Name() string { return "func" }
+
+func (f *funcVerifier) VerifyImage(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+	f.mu.Lock()
+	f.calls++
+	f.running++
+	f.peak = max(f.peak, f.running)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.running--
+		f.mu.Unlock()
+	}()
+	return f.verify(ctx, imagePath)
+}
+
+func imagePaths(n int) []string {
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = strconv.Itoa(i)
+	}
+	return paths
+}
+
+func TestVerifyAllKeepsOrder(t *testing.T) {
+	paths := imagePaths(5)
+	docTypes := []string{"NID", "NID", "PASSPORT", "DRIVING_LICENSE", "UTILITY_BILL"}
+	// Later images finish first.
+	v := &funcVerifier{verify: func(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+		i, _ := strconv.Atoi(imagePath)
+		time.Sleep(time.Duration(len(paths)-i) * 5 * time.Millisecond)
+		return &models.VerificationResult{Image: imagePath, DocumentType: documentTypeFrom(ctx)}, nil
+	}}
+
+	results, err := VerifyAll(context.Background(), v, paths, docTypes, VerifyLimits{Concurrency: len(paths)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(paths) {
+		t.Fatalf("got %d results, want %d", len(results), len(paths))
+	}
+	for i, res := range results {
+		if res.Image != paths[i] || res.DocumentType != docTypes[i] {
+			t.Errorf("results[%d] = %s %s, want %s %s", i, res.Image, res.DocumentType, paths[i], docTypes[i])
+		}
+	}
+}
+
+func TestVerifyAllDocumentTypeFromContext(t *testing.T) {
+	v := &funcVerifier{verify: func(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+		return &models.VerificationResult{Image: imagePath, DocumentType: documentTypeFrom(ctx)}, nil
+	}}
+	ctx := WithDocumentType(context.Background(), "PASSPORT")
+	results, err := VerifyAll(ctx, v, imagePaths(2), nil, VerifyLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, res := range results {
+		if res.DocumentType != "PASSPORT" {
+			t.Errorf("results[%d].DocumentType = %q, want PASSPORT", i, res.DocumentType)
+		}
+	}
+}
+
+func TestVerifyAllConcurrency(t *testing.T) {
+	for _, limit := range []int{0, 1, 3, 10} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			v := &funcVerifier{verify: func(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+				time.Sleep(5 * time.Millisecond)
+				return &models.VerificationResult{Image: imagePath}, nil
+			}}
+			if _, err := VerifyAll(context.Background(), v, imagePaths(8), nil, VerifyLimits{Concurrency: limit}); err != nil {
+				t.Fatal(err)
+			}
+			if v.calls != 8 {
+				t.Errorf("calls = %d, want 8", v.calls)
+			}
+			if want := max(limit, 1); v.peak > want {
+				t.Errorf("%d calls ran at once, want at most %d", v.peak, want)
+			}
+		})
+	}
+}
+
+func TestVerifyAllFirstErrorCancels(t *testing.T) {
+	errBad := errors.New("model rejected the request")
+	paths := imagePaths(4)
+	var (
+		started   sync.WaitGroup
+		mu        sync.Mutex
+		cancelled int
+	)
+	started.Add(len(paths) - 1)
+	v := &funcVerifier{verify: func(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+		if imagePath == "0" {
+			started.Wait()
+			return nil, errBad
+		}
+		started.Done()
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			cancelled++
+			mu.Unlock()
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return &models.VerificationResult{Image: imagePath}, nil
+		}
+	}}
+
+	start := time.Now()
+	results, err := VerifyAll(context.Background(), v, paths, nil, VerifyLimits{Concurrency: len(paths)})
+	if !errors.Is(err, errBad) || results != nil {
+		t.Fatalf("VerifyAll = %v, %v; want %v", results, err, errBad)
+	}
+	if cancelled != len(paths)-1 {
+		t.Errorf("%d calls were cancelled, want %d", cancelled, len(paths)-1)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("VerifyAll took %s after the first error", elapsed)
+	}
+}
+
+func TestVerifyAllTimeout(t *testing.T) {
+	const timeout = 20 * time.Millisecond
+	v := &funcVerifier{verify: func(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > timeout {
+			t.Errorf("image %s: deadline = %v, %v; want one within %s", imagePath, deadline, ok, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return &models.VerificationResult{Image: imagePath}, nil
+		}
+	}}
+
+	start := time.Now()
+	// One call at a time: the images still queued share the deadline too.
+	_, err := VerifyAll(context.Background(), v, imagePaths(3), nil, VerifyLimits{Concurrency: 1, Timeout: timeout})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("VerifyAll took %s with a %s timeout", elapsed, timeout)
+	}
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"kyc/internal/matching"
+	"kyc/internal/mrz"
+)
+
+// ErrMRZNumberMismatch is returned when the passport number in the MRZ
+// differs from the number the user submitted.
+var ErrMRZNumberMismatch = errors.New("passport number in MRZ does not match the submitted document number")
+
+// CheckPassportMRZ parses the MRZ in text and validates it against the
+// submitted document number. Every check digit must be correct and the
+// numbers must match exactly once separators are ignored.
+func CheckPassportMRZ(text, documentNumber string) (*mrz.MRZ, error) {
+	m, err := mrz.Parse(text)
+	if err != nil {
+		return m, err
+	}
+	if m.Format != mrz.TD3 || m.DocumentCode == "" || m.DocumentCode[0] != 'P' {
+		return m, fmt.Errorf("MRZ is a %s document of type %q, not a passport", m.Format, m.DocumentCode)
+	}
+	if matching.NormalizeDocumentNumber(m.DocumentNumber) != matching.NormalizeDocumentNumber(documentNumber) {
+		return m, ErrMRZNumberMismatch
+	}
+	return m, nil
+}
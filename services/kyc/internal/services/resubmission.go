@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"kyc/internal/models"
+)
+
+var (
+	// ErrSubmissionOpen is returned while the latest attempt is still being
+	// processed or has been approved.
+	ErrSubmissionOpen = errors.New("a KYC submission is already in progress or approved")
+	// ErrMaxAttempts is returned when the user has used all attempts.
+	ErrMaxAttempts = errors.New("maximum number of KYC attempts reached")
+)
+
+// CooldownError is returned when a rejected user resubmits too early.
+type CooldownError struct {
+	Until time.Time
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("resubmission allowed after %s", e.Until.Format(time.RFC3339))
+}
+
+// ResubmissionPolicy decides when a user may start a new KYC attempt.
+type ResubmissionPolicy struct {
+	Cooldown    time.Duration // wait after a rejection
+	MaxAttempts int           // 0 means unlimited
+}
+
+// Check returns nil if a new attempt may be submitted now. An expired
+// request can be replaced at any time; a rejected one after the cooldown. latest is the
+// user's most recent attempt (nil if none) and attempts the number made so far.
+func (p ResubmissionPolicy) Check(latest *models.KYCRequest, attempts int, now time.Time) error {
+	if latest == nil {
+		return nil
+	}
+	if latest.Status == models.StatusExpired {
+		return nil
+	}
+	if latest.Status != models.StatusRejected {
+		return ErrSubmissionOpen
+	}
+	if p.MaxAttempts > 0 && attempts >= p.MaxAttempts {
+		return ErrMaxAttempts
+	}
+	if latest.AutoRejected && latest.Appeal == nil {
+		// The user can retry with a better photo straight away.
+		return nil
+	}
+	if until := latest.UpdatedAt.Add(p.Cooldown); now.Before(until) {
+		return &CooldownError{Until: until}
+	}
+	return nil
+}
+
+// NextAttemptAt returns when a rejected user may resubmit, or nil if there
+// is no cooldown to wait for.
+func (p ResubmissionPolicy) NextAttemptAt(latest *models.KYCRequest, attempts int) *time.Time {
+	if latest == nil || latest.Status == models.StatusExpired || (latest.AutoRejected && latest.Appeal == nil) {
+		return nil
+	}
+	if latest.Status != models.StatusRejected || (p.MaxAttempts > 0 && attempts >= p.MaxAttempts) {
+		return nil
+	}
+	until := latest.UpdatedAt.Add(p.Cooldown)
+	return &until
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"kyc/internal/models"
+)
+
+func TestResubmissionPolicyCheck(t *testing.T) {
+	rejectedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	policy := ResubmissionPolicy{Cooldown: 24 * time.Hour, MaxAttempts: 3}
+	rejected := &models.KYCRequest{Status: models.StatusRejected, UpdatedAt: rejectedAt}
+	autoRejected := &models.KYCRequest{Status: models.StatusRejected, AutoRejected: true, UpdatedAt: rejectedAt}
+	appealed := &models.KYCRequest{Status: models.StatusRejected, AutoRejected: true, Appeal: &models.Appeal{}, UpdatedAt: rejectedAt}
+	cooldownEnd := rejectedAt.Add(24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		policy   ResubmissionPolicy
+		latest   *models.KYCRequest
+		attempts int
+		now      time.Time
+		want     error // nil, a sentinel, or a *CooldownError
+	}{
+		{"first attempt", policy, nil, 0, rejectedAt, nil},
+		{"pending", policy, &models.KYCRequest{Status: models.StatusPending}, 1, rejectedAt, ErrSubmissionOpen},
+		{"needs info", policy, &models.KYCRequest{Status: models.StatusNeedsInfo}, 1, rejectedAt, ErrSubmissionOpen},
+		{"fraud review", policy, &models.KYCRequest{Status: models.StatusFraudReview}, 1, rejectedAt, ErrSubmissionOpen},
+		{"approved", policy, &models.KYCRequest{Status: models.StatusApproved}, 1, rejectedAt, ErrSubmissionOpen},
+		{"expired", policy, &models.KYCRequest{Status: models.StatusExpired}, 3, rejectedAt, nil},
+		{"just rejected", policy, rejected, 1, rejectedAt, &CooldownError{Until: cooldownEnd}},
+		{"cooldown almost over", policy, rejected, 1, cooldownEnd.Add(-time.Nanosecond), &CooldownError{Until: cooldownEnd}},
+		{"cooldown over", policy, rejected, 1, cooldownEnd, nil},
+		{"last attempt left", policy, rejected, 2, cooldownEnd, nil},
+		{"attempts used", policy, rejected, 3, cooldownEnd, ErrMaxAttempts},
+		{"attempts used before the cooldown ends", policy, rejected, 3, rejectedAt, ErrMaxAttempts},
+		{"unlimited attempts", ResubmissionPolicy{Cooldown: time.Hour}, rejected, 100, cooldownEnd, nil},
+		{"no cooldown", ResubmissionPolicy{MaxAttempts: 3}, rejected, 1, rejectedAt, nil},
+		{"auto-rejected", policy, autoRejected, 1, rejectedAt, nil},
+		{"auto-rejected, attempts used", policy, autoRejected, 3, rejectedAt, ErrMaxAttempts},
+		{"appeal rejected", policy, appealed, 1, rejectedAt, &CooldownError{Until: cooldownEnd}},
+	}
+	for _, tt := range tests {
+		err := tt.policy.Check(tt.latest, tt.attempts, tt.now)
+		var cooldown *CooldownError
+		switch want := tt.want.(type) {
+		case nil:
+			if err != nil {
+				t.Errorf("%s: err = %v, want nil", tt.name, err)
+			}
+		case *CooldownError:
+			if !errors.As(err, &cooldown) || !cooldown.Until.Equal(want.Until) {
+				t.Errorf("%s: err = %v, want cooldown until %s", tt.name, err, want.Until)
+			}
+		default:
+			if !errors.Is(err, want) {
+				t.Errorf("%s: err = %v, want %v", tt.name, err, want)
+			}
+		}
+
+		next := tt.policy.NextAttemptAt(tt.latest, tt.attempts)
+		if _, isCooldown := tt.want.(*CooldownError); isCooldown && (next == nil || !next.Equal(cooldown.Until)) {
+			t.Errorf("%s: NextAttemptAt = %v, want %s", tt.name, next, cooldown.Until)
+		}
+		if errors.Is(tt.want, ErrMaxAttempts) || errors.Is(tt.want, ErrSubmissionOpen) {
+			if next != nil {
+				t.Errorf("%s: NextAttemptAt = %v, want nil", tt.name, next)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy bounds calls to a model provider.
+type RetryPolicy struct {
+	MaxAttempts    int
+	AttemptTimeout time.Duration // Per HTTP request
+	CallTimeout    time.Duration // For all attempts together, including waits
+	BaseDelay      time.Duration // First backoff, doubled on every retry
+	MaxDelay       time.Duration
+}
+
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	AttemptTimeout: 30 * time.Second,
+	CallTimeout:    60 * time.Second,
+	BaseDelay:      500 * time.Millisecond,
+	MaxDelay:       8 * time.Second,
+}
+
+// Backoff returns the wait before retry number attempt (0 for the first
+// retry): exponential with full jitter, or the server's Retry-After when it
+// asks for longer.
+func (p RetryPolicy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	ceiling := min(p.BaseDelay<<attempt, p.MaxDelay)
+	if ceiling <= 0 {
+		ceiling = p.MaxDelay
+	}
+	delay := time.Duration(rand.Int64N(int64(ceiling) + 1))
+	return max(delay, retryAfter)
+}
+
+// APIError is a non-200 response from a provider.
+type APIError struct {
+	Status     int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.Status, e.Body)
+}
+
+// Temporary reports whether the request may succeed when retried.
+func (e *APIError) Temporary() bool {
+	switch e.Status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
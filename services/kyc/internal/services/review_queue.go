@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"kyc/internal/models"
+	"kyc/internal/repository"
+)
+
+// ReviewQueue hands out queued requests to reviewers. A claim is a lease:
+// it expires after Lease so that abandoned requests return to the queue.
+type ReviewQueue struct {
+	repo  *repository.KYCRepository
+	Lease time.Duration
+	SLA   time.Duration // Target time from queueing to a decision
+}
+
+func NewReviewQueue(repo *repository.KYCRepository, lease, sla time.Duration) *ReviewQueue {
+	return &ReviewQueue{repo: repo, Lease: lease, SLA: sla}
+}
+
+func (q *ReviewQueue) Claim(ctx context.Context, id, reviewer string) (*models.KYCRequest, error) {
+	now := time.Now()
+	return q.repo.Claim(ctx, id, reviewer, now, now.Add(q.Lease))
+}
+
+func (q *ReviewQueue) Release(ctx context.Context, id, reviewer string) error {
+	return q.repo.Release(ctx, id, reviewer)
+}
+
+// Age returns how long kyc has been waiting for review at now.
+func (q *ReviewQueue) Age(kyc *models.KYCRequest, now time.Time) time.Duration {
+	queued := kyc.QueuedAt
+	if queued.IsZero() {
+		queued = kyc.CreatedAt
+	}
+	return now.Sub(queued)
+}
+
+// Breached reports whether kyc has waited longer than the SLA.
+func (q *ReviewQueue) Breached(kyc *models.KYCRequest, now time.Time) bool {
+	return q.SLA > 0 && q.Age(kyc, now) > q.SLA
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"kyc/internal/models"
+	"kyc/internal/repository"
+	"kyc/internal/watchlist"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrCaseResolved = errors.New("screening case is already resolved")
+
+// holdReason is what the user sees when rescreening puts them on hold. It
+// must not disclose the watchlist match.
+const holdReason = "Your verification needs an additional review"
+
+// Screener checks KYC subjects against the sanctions and PEP lists in dir.
+// Hits are raised as screening cases; while a request has an open or
+// confirmed case it carries FlagWatchlistHit and cannot be approved.
+type Screener struct {
+	repo      *repository.KYCRepository
+	cases     *repository.ScreeningRepository
+	dir       string
+	Threshold float64
+	index     atomic.Pointer[watchlist.Index]
+}
+
+func NewScreener(repo *repository.KYCRepository, cases *repository.ScreeningRepository, dir string, threshold float64) *Screener {
+	return &Screener{repo: repo, cases: cases, dir: dir, Threshold: threshold}
+}
+
+// Reload reads the lists again. On error the lists loaded before are kept.
+func (s *Screener) Reload() error {
+	idx, err := watchlist.Load(s.dir)
+	if err != nil {
+		return err
+	}
+	s.index.Store(idx)
+	return nil
+}
+
+// Lists describes the loaded list files.
+func (s *Screener) Lists() []watchlist.ListInfo {
+	if idx := s.index.Load(); idx != nil {
+		return idx.Lists()
+	}
+	return []watchlist.ListInfo{}
+}
+
+// Entries returns the number of loaded list entries.
+func (s *Screener) Entries() int {
+	if idx := s.index.Load(); idx != nil {
+		return idx.Len()
+	}
+	return 0
+}
+
+// NewScreeningSubject describes the person of a request: the name read from
+// the document and the name on the user's profile, and the date of birth
+// given by the user or read from the document.
+func NewScreeningSubject(kyc *models.KYCRequest, profileName string) models.ScreeningSubject {
+	subject := models.ScreeningSubject{DateOfBirth: kyc.DateOfBirth, Country: kyc.Country}
+	if kyc.Extracted != nil {
+		if kyc.Extracted.FullName != "" {
+			subject.Names = append(subject.Names, kyc.Extracted.FullName)
+		}
+		if subject.DateOfBirth == "" {
+			subject.DateOfBirth = kyc.Extracted.DateOfBirth
+		}
+	}
+	if profileName != "" && !slices.Contains(subject.Names, profileName) {
+		subject.Names = append(subject.Names, profileName)
+	}
+	return subject
+}
+
+// Screen returns the hits for subject that have not already been dealt
+// with for the user. Entries a reviewer cleared are never raised again;
+// when rescreening, entries with a case in any state are skipped.
+func (s *Screener) Screen(ctx context.Context, userID string, subject models.ScreeningSubject, trigger string) ([]models.ScreeningHit, error) {
+	idx := s.index.Load()
+	if idx == nil || len(subject.Names) == 0 {
+		return nil, nil
+	}
+	hits := idx.Screen(subject, s.Threshold)
+	if len(hits) == 0 {
+		return nil, nil
+	}
+
+	cases, err := s.cases.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, sc := range cases {
+		if sc.Status != models.ScreeningCleared && trigger != models.ScreeningOnRescreen {
+			continue
+		}
+		for _, h := range sc.Hits {
+			seen[h.Key()] = true
+		}
+	}
+	return slices.DeleteFunc(hits, func(h models.ScreeningHit) bool { return seen[h.Key()] }), nil
+}
+
+// OpenCase raises hits on kyc for review.
+func (s *Screener) OpenCase(ctx context.Context, kyc *models.KYCRequest, trigger string, hits []models.ScreeningHit) (*models.ScreeningCase, error) {
+	sc := &models.ScreeningCase{
+		UserID:  kyc.UserID,
+		KYCID:   kyc.ID,
+		Trigger: trigger,
+		Subject: *kyc.ScreenedAs,
+		Hits:    hits,
+	}
+	if err := s.cases.Create(ctx, sc); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// DeleteCase removes a case whose request could not be stored or held, so
+// that the hits are raised again next time.
+func (s *Screener) DeleteCase(ctx context.Context, sc *models.ScreeningCase) error {
+	return s.cases.Delete(ctx, sc.ID)
+}
+
+// Cases lists cases oldest first, all of them if status is empty.
+func (s *Screener) Cases(ctx context.Context, status models.ScreeningStatus, limit int64) ([]models.ScreeningCase, error) {
+	return s.cases.List(ctx, status, limit)
+}
+
+// Run reloads the lists and rescreens approved users every interval until
+// ctx is cancelled.
+func (s *Screener) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Reload(); err != nil {
+			log.Printf("Failed to reload watchlists, keeping the previous ones: %v", err)
+		}
+		if n, err := s.Rescreen(ctx); err != nil {
+			log.Printf("Rescreening failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Rescreening put %d approved KYC requests on hold", n)
+		}
+	}
+}
+
+// Rescreen screens every approved request against the current lists. A new
+// hit opens a case and moves the request to FRAUD_REVIEW. It returns how
+// many requests were put on hold.
+func (s *Screener) Rescreen(ctx context.Context) (int, error) {
+	approved, err := s.repo.GetByStatus(ctx, models.StatusApproved)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, kyc := range approved {
+		if kyc.ScreenedAs == nil {
+			// Approved before screening existed.
+			subject := NewScreeningSubject(&kyc, "")
+			kyc.ScreenedAs = &subject
+		}
+		hits, err := s.Screen(ctx, kyc.UserID, *kyc.ScreenedAs, models.ScreeningOnRescreen)
+		if err != nil {
+			return n, err
+		}
+		if len(hits) == 0 {
+			continue
+		}
+
+		change := models.StatusChange{To: models.StatusFraudReview, Actor: models.ActorSystem, Reason: holdReason}
+		if err := CheckTransition(&kyc, change, time.Now()); err != nil {
+			continue
+		}
+		sc, err := s.OpenCase(ctx, &kyc, models.ScreeningOnRescreen, hits)
+		if err != nil {
+			return n, err
+		}
+		flags := append(slices.Clone(kyc.Flags), models.FlagWatchlistHit)
+		if err := s.repo.Escalate(ctx, &kyc, change, flags); err != nil {
+			// Without the hold the case must go too, so that the next run
+			// screens the request again.
+			if err := s.cases.Delete(ctx, sc.ID); err != nil {
+				log.Printf("Failed to delete screening case %s: %v", sc.ID.Hex(), err)
+			}
+			if err != repository.ErrVersionConflict {
+				log.Printf("Failed to hold KYC request %s: %v", kyc.ID.Hex(), err)
+			}
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Resolve records a reviewer's decision on an open case. CLEARED marks the
+// hits as false positives; once no open or confirmed case is left the
+// request loses FlagWatchlistHit and, if rescreening put it on hold, gets
+// its approval back. CONFIRMED keeps the request blocked for a reviewer to
+// reject.
+//
+// The release can fail after the decision is stored, and the request stays
+// blocked until it succeeds. Sending the same decision again repeats the
+// release; a different decision on a resolved case gets ErrCaseResolved.
+func (s *Screener) Resolve(ctx context.Context, id string, reviewer string, status models.ScreeningStatus, note string) (*models.ScreeningCase, error) {
+	sc, err := s.cases.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sc.UserID == reviewer {
+		return nil, ErrSelfReview
+	}
+	if note == "" {
+		return nil, ErrReasonRequired
+	}
+	if sc.Status == models.ScreeningOpen {
+		err := s.cases.Resolve(ctx, sc, status, reviewer, note)
+		if errors.Is(err, repository.ErrCaseNotFound) {
+			// Resolved by someone else in the meantime.
+			if sc, err = s.cases.GetByID(ctx, id); err != nil {
+				return nil, err
+			}
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if sc.Status != status {
+		return nil, ErrCaseResolved
+	}
+	if status == models.ScreeningCleared {
+		if err := s.release(ctx, sc.KYCID, sc.Trigger, reviewer); err != nil {
+			return sc, err
+		}
+	}
+	return sc, nil
+}
+
+func (s *Screener) release(ctx context.Context, kycID primitive.ObjectID, trigger, reviewer string) error {
+	blocked, err := s.cases.HasBlocking(ctx, kycID)
+	if err != nil || blocked {
+		return err
+	}
+	if err := s.repo.RemoveFlag(ctx, kycID, models.FlagWatchlistHit); err != nil {
+		return err
+	}
+	if trigger != models.ScreeningOnRescreen {
+		return nil
+	}
+
+	kyc, err := s.repo.GetByID(ctx, kycID.Hex())
+	if err != nil {
+		return err
+	}
+	change := models.StatusChange{To: models.StatusApproved, Actor: reviewer, Reason: "Review completed"}
+	if kyc.Status != models.StatusFraudReview || CheckTransition(kyc, change, time.Now()) != nil {
+		return nil
+	}
+	if err := s.repo.Transition(ctx, kyc, change); err != nil && err != repository.ErrVersionConflict {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"kyc/internal/doctypes"
+	"kyc/internal/models"
+	"kyc/internal/repository"
+	"kyc/internal/tiers"
+)
+
+// TierService works out users' KYC tiers from their approved requests and
+// the phone verification reported by the auth service.
+type TierService struct {
+	repo   *repository.KYCRepository
+	phones PhoneLookup
+	config *tiers.Config
+}
+
+// PhoneLookup tells whether a user has a verified phone number.
+type PhoneLookup interface {
+	PhoneVerified(ctx context.Context, userID string) (bool, error)
+}
+
+func NewTierService(repo *repository.KYCRepository, phones PhoneLookup, config *tiers.Config) *TierService {
+	return &TierService{repo: repo, phones: phones, config: config}
+}
+
+// Config returns the tier definitions.
+func (s *TierService) Config() *tiers.Config {
+	return s.config
+}
+
+// ForUser returns the tier of a user whose phone verification is known,
+// such as the caller of a request authenticated by the auth service.
+func (s *TierService) ForUser(ctx context.Context, userID string, phoneVerified bool) (tiers.Status, error) {
+	requests, err := s.repo.GetHistory(ctx, userID)
+	if err != nil {
+		return tiers.Status{}, err
+	}
+	return evaluateTier(s.config, phoneVerified, requests), nil
+}
+
+// Lookup returns a user's tier, asking the auth service for their phone
+// verification.
+func (s *TierService) Lookup(ctx context.Context, userID string) (tiers.Status, error) {
+	verified, err := s.phones.PhoneVerified(ctx, userID)
+	if err != nil {
+		return tiers.Status{}, err
+	}
+	return s.ForUser(ctx, userID, verified)
+}
+
+// evaluateTier returns the highest tier reached by a single approved
+// request. Facts from different requests are not combined: a selfie matched
+// against one document or an address approved with it says nothing about
+// another. Expired and later rejected approvals do not count. requests are
+// newest first, so of two requests reaching the same tier the newer one
+// decides what the next tier is missing.
+func evaluateTier(config *tiers.Config, phoneVerified bool, requests []models.KYCRequest) tiers.Status {
+	best := config.Evaluate(tiers.Facts{PhoneVerified: phoneVerified})
+	for i := range requests {
+		if requests[i].Status != models.StatusApproved {
+			continue
+		}
+		if status := config.Evaluate(TierFacts(phoneVerified, &requests[i])); status.Level > best.Level {
+			best = status
+		}
+	}
+	return best
+}
+
+// TierFacts collects what one approved request verifies.
+func TierFacts(phoneVerified bool, kyc *models.KYCRequest) tiers.Facts {
+	return tiers.Facts{
+		PhoneVerified: phoneVerified,
+		Documents:     []string{kyc.Type},
+		Selfie:        kyc.FaceMatch != nil && kyc.FaceMatch.Match,
+		AddressProof:  slices.Contains(kyc.ImageRoles, doctypes.RoleAddressProof),
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"kyc/internal/models"
+	"kyc/internal/tiers"
+)
+
+func TestTierFacts(t *testing.T) {
+	matched := &models.FaceMatch{Match: true, Similarity: 0.9}
+	tests := []struct {
+		name string
+		kyc  models.KYCRequest
+		want tiers.Facts
+	}{
+		{"document only", models.KYCRequest{Type: "NID", ImageRoles: []string{"front", "back"}}, tiers.Facts{Documents: []string{"NID"}}},
+		{"address proof", models.KYCRequest{Type: "NID", ImageRoles: []string{"front", "back", "address_proof"}}, tiers.Facts{Documents: []string{"NID"}, AddressProof: true}},
+		{"matched selfie", models.KYCRequest{Type: "PASSPORT", Selfie: "uploads/s.jpg", FaceMatch: matched}, tiers.Facts{Documents: []string{"PASSPORT"}, Selfie: true}},
+		{"selfie not compared", models.KYCRequest{Type: "PASSPORT", Selfie: "uploads/s.jpg"}, tiers.Facts{Documents: []string{"PASSPORT"}}},
+		{"selfie did not match", models.KYCRequest{Type: "PASSPORT", Selfie: "uploads/s.jpg", FaceMatch: &models.FaceMatch{Similarity: 0.3}}, tiers.Facts{Documents: []string{"PASSPORT"}}},
+	}
+	for _, tt := range tests {
+		got := TierFacts(false, &tt.kyc)
+		if got.PhoneVerified || got.Selfie != tt.want.Selfie || got.AddressProof != tt.want.AddressProof || !slices.Equal(got.Documents, tt.want.Documents) {
+			t.Errorf("%s: facts = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+	if !TierFacts(true, &models.KYCRequest{Type: "NID"}).PhoneVerified {
+		t.Error("phone verification not carried into the facts")
+	}
+}
+
+func TestEvaluateTier(t *testing.T) {
+	matched := &models.FaceMatch{Match: true, Similarity: 0.9}
+	full := models.KYCRequest{Type: "NID", Status: models.StatusApproved, FaceMatch: matched, ImageRoles: []string{"front", "back", "address_proof"}}
+	tests := []struct {
+		name     string
+		requests []models.KYCRequest
+		want     string
+		missing  []string
+	}{
+		{"no requests", nil, tiers.None, []string{tiers.RequireIDDocument}},
+		{"one complete request", []models.KYCRequest{full}, tiers.Full, nil},
+		{
+			// The selfie matched a passport, the address came with an NID:
+			// no single approval covers both. The newer one, listed first,
+			// decides what is missing.
+			"facts split across requests",
+			[]models.KYCRequest{
+				{Type: "PASSPORT", Status: models.StatusApproved, FaceMatch: matched, ImageRoles: []string{"data_page"}},
+				{Type: "NID", Status: models.StatusApproved, ImageRoles: []string{"front", "back", "address_proof"}},
+			},
+			tiers.BasicID, []string{tiers.RequireAddressProof},
+		},
+		{
+			"best request wins",
+			[]models.KYCRequest{{Type: "DRIVING_LICENSE", Status: models.StatusApproved, ImageRoles: []string{"front"}}, full},
+			tiers.Full, nil,
+		},
+		{
+			"only approved requests count",
+			[]models.KYCRequest{
+				{Type: "NID", Status: models.StatusExpired, FaceMatch: matched, ImageRoles: []string{"front", "back", "address_proof"}},
+				{Type: "PASSPORT", Status: models.StatusRejected, FaceMatch: matched, ImageRoles: []string{"data_page", "address_proof"}},
+				{Type: "NID", Status: models.StatusPending, FaceMatch: matched, ImageRoles: []string{"front", "back", "address_proof"}},
+			},
+			tiers.None, []string{tiers.RequireIDDocument},
+		},
+	}
+	cfg := tiers.Default()
+	for _, tt := range tests {
+		got := evaluateTier(cfg, false, tt.requests)
+		if got.Code != tt.want {
+			t.Errorf("%s: tier = %s, want %s", tt.name, got.Code, tt.want)
+			continue
+		}
+		if tt.missing == nil {
+			if got.Next != nil {
+				t.Errorf("%s: next = %+v, want none", tt.name, got.Next)
+			}
+		} else if got.Next == nil || !slices.Equal(got.Next.Missing, tt.missing) {
+			t.Errorf("%s: next = %+v, want missing %v", tt.name, got.Next, tt.missing)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"kyc/internal/models"
+	"kyc/internal/repository"
+)
+
+// VerdictKey identifies a verdict: the same image sent to the same model with
+// the same prompt gets the same answer, so the model is asked only once.
+type VerdictKey struct {
+	SHA256        string // Of the normalized image sent to the model
+	ModelID       string
+	PromptVersion string
+}
+
+func (k VerdictKey) String() string {
+	return k.SHA256 + "|" + k.ModelID + "|" + k.PromptVersion
+}
+
+// VerdictStore persists cache entries. It is implemented by
+// repository.VerdictCacheRepository.
+type VerdictStore interface {
+	Get(ctx context.Context, key string) (*repository.CachedVerdict, error)
+	Put(ctx context.Context, entry *repository.CachedVerdict) error
+	Delete(ctx context.Context, f repository.VerdictCacheFilter) (int64, error)
+	Count(ctx context.Context) (int64, error)
+}
+
+// VerdictCache stores AI verification results in Mongo. A nil cache is valid
+// and caches nothing. Cache errors are logged and treated as misses, so a
+// Mongo outage only costs model calls.
+type VerdictCache struct {
+	repo   VerdictStore
+	ttl    time.Duration
+	now    func() time.Time
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+// NewVerdictCache returns a cache whose entries are served for ttl after
+// they were stored.
+func NewVerdictCache(repo VerdictStore, ttl time.Duration) *VerdictCache {
+	return &VerdictCache{repo: repo, ttl: ttl, now: time.Now}
+}
+
+// Get returns a copy of the cached result for key, or nil on a miss. Mongo
+// removes expired entries only once a minute, so their age is checked here.
+func (c *VerdictCache) Get(ctx context.Context, key VerdictKey) *models.VerificationResult {
+	if c == nil {
+		return nil
+	}
+	entry, err := c.repo.Get(ctx, key.String())
+	if err != nil {
+		c.errors.Add(1)
+		log.Printf("Verdict cache lookup failed: %v", err)
+	}
+	if entry == nil || !c.now().Before(entry.CreatedAt.Add(c.ttl)) {
+		c.misses.Add(1)
+		return nil
+	}
+	c.hits.Add(1)
+	res := entry.Result
+	return &res
+}
+
+// Put stores res under key. Verdicts that were not reached by the model,
+// such as manual review fallbacks, are not cached.
+func (c *VerdictCache) Put(ctx context.Context, key VerdictKey, res *models.VerificationResult) {
+	if c == nil || res.Verdict == models.VerdictManualReview {
+		return
+	}
+	entry := &repository.CachedVerdict{
+		Key:           key.String(),
+		SHA256:        key.SHA256,
+		ModelID:       key.ModelID,
+		PromptVersion: key.PromptVersion,
+		Result:        *res,
+		CreatedAt:     c.now(),
+	}
+	if err := c.repo.Put(ctx, entry); err != nil {
+		c.errors.Add(1)
+		log.Printf("Verdict cache store failed: %v", err)
+	}
+}
+
+// Invalidate removes the entries matching f.
+func (c *VerdictCache) Invalidate(ctx context.Context, f repository.VerdictCacheFilter) (int64, error) {
+	return c.repo.Delete(ctx, f)
+}
+
+// VerdictCacheStats are the cache counters since the process started.
+type VerdictCacheStats struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	Errors   int64   `json:"errors"`
+	HitRatio float64 `json:"hit_ratio"`
+	Entries  int64   `json:"entries"`
+}
+
+func (c *VerdictCache) Stats(ctx context.Context) (VerdictCacheStats, error) {
+	stats := VerdictCacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Errors: c.errors.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	entries, err := c.repo.Count(ctx)
+	if err != nil {
+		return stats, err
+	}
+	stats.Entries = entries
+	return stats, nil
+}
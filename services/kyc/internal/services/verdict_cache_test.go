@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kyc/internal/models"
+	"kyc/internal/modelstub"
+	"kyc/internal/prompts"
+	"kyc/internal/repository"
+)
+
+// memoryVerdicts is an in-memory VerdictStore.
+type memoryVerdicts struct {
+	entries map[string]repository.CachedVerdict
+	err     error
+}
+
+func newMemoryVerdicts() *memoryVerdicts {
+	return &memoryVerdicts{entries: map[string]repository.CachedVerdict{}}
+}
+
+func (m *memoryVerdicts) Get(_ context.Context, key string) (*repository.CachedVerdict, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (m *memoryVerdicts) Put(_ context.Context, entry *repository.CachedVerdict) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.entries[entry.Key] = *entry
+	return nil
+}
+
+func (m *memoryVerdicts) Delete(context.Context, repository.VerdictCacheFilter) (int64, error) {
+	n := int64(len(m.entries))
+	clear(m.entries)
+	return n, nil
+}
+
+func (m *memoryVerdicts) Count(context.Context) (int64, error) {
+	return int64(len(m.entries)), nil
+}
+
+func TestVerdictCache(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryVerdicts()
+	cache := NewVerdictCache(store, time.Hour)
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	key := VerdictKey{SHA256: "abc", ModelID: "model-a", PromptVersion: "classify-v1"}
+	if got := cache.Get(ctx, key); got != nil {
+		t.Fatalf("Get on an empty cache = %+v, want a miss", got)
+	}
+	cache.Put(ctx, key, &models.VerificationResult{Verdict: models.VerdictValid, DocumentType: "NID"})
+
+	got := cache.Get(ctx, key)
+	if got == nil || got.Verdict != models.VerdictValid || got.DocumentType != "NID" {
+		t.Fatalf("Get after Put = %+v, want the stored verdict", got)
+	}
+	got.DocumentType = "PASSPORT"
+	if again := cache.Get(ctx, key); again.DocumentType != "NID" {
+		t.Errorf("changing a returned result changed the cache: %q", again.DocumentType)
+	}
+
+	for _, other := range []VerdictKey{
+		{SHA256: "abd", ModelID: "model-a", PromptVersion: "classify-v1"},
+		{SHA256: "abc", ModelID: "model-b", PromptVersion: "classify-v1"},
+		{SHA256: "abc", ModelID: "model-a", PromptVersion: "classify-v2"},
+	} {
+		if got := cache.Get(ctx, other); got != nil {
+			t.Errorf("Get(%s) = %+v, want a miss", other, got)
+		}
+	}
+
+	now = now.Add(time.Hour - time.Second)
+	if cache.Get(ctx, key) == nil {
+		t.Error("entry missed before the TTL ran out")
+	}
+	now = now.Add(time.Second)
+	if got := cache.Get(ctx, key); got != nil {
+		t.Errorf("Get after the TTL = %+v, want a miss", got)
+	}
+
+	stats, err := cache.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := VerdictCacheStats{Hits: 3, Misses: 5, HitRatio: 3.0 / 8, Entries: 1}
+	if stats != want {
+		t.Errorf("Stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestVerdictCacheSkipsManualReview(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryVerdicts()
+	cache := NewVerdictCache(store, time.Hour)
+	key := VerdictKey{SHA256: "abc", ModelID: "model-a", PromptVersion: "classify-v1"}
+
+	cache.Put(ctx, key, &models.VerificationResult{Verdict: models.VerdictManualReview})
+	if len(store.entries) != 0 {
+		t.Errorf("manual review verdict was cached: %+v", store.entries)
+	}
+}
+
+func TestVerdictCacheErrorsAreMisses(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryVerdicts()
+	store.err = errors.New("connection refused")
+	cache := NewVerdictCache(store, time.Hour)
+	key := VerdictKey{SHA256: "abc", ModelID: "model-a", PromptVersion: "classify-v1"}
+
+	cache.Put(ctx, key, &models.VerificationResult{Verdict: models.VerdictValid})
+	if got := cache.Get(ctx, key); got != nil {
+		t.Errorf("Get with a failing store = %+v, want a miss", got)
+	}
+	stats, _ := cache.Stats(ctx)
+	if stats.Errors != 2 || stats.Misses != 1 {
+		t.Errorf("Stats = %+v, want 2 errors and 1 miss", stats)
+	}
+}
+
+func TestNilVerdictCache(t *testing.T) {
+	var cache *VerdictCache
+	key := VerdictKey{SHA256: "abc", ModelID: "model-a", PromptVersion: "classify-v1"}
+	cache.Put(context.Background(), key, &models.VerificationResult{Verdict: models.VerdictValid})
+	if got := cache.Get(context.Background(), key); got != nil {
+		t.Errorf("nil cache Get = %+v, want nil", got)
+	}
+}
+
+func TestVerifyImageUsesVerdictCache(t *testing.T) {
+	server, script := modelstub.NewServer(modelstub.Answer(`{"label": "VALID_NID", "confidence": 0.9, "reason": "NID card"}`))
+	defer server.Close()
+	cache := NewVerdictCache(newMemoryVerdicts(), time.Hour)
+	image := writeTestImage(t)
+
+	verifier := func(modelID string) *VerificationService {
+		v := NewOpenAICompatibleVerifier(server.URL, "", modelID)
+		v.retry = fastRetries
+		v.cache = cache
+		return v
+	}
+	ctx := context.Background()
+
+	first, err := verifier("model-a").VerifyImage(ctx, image)
+	if err != nil || first.CacheHit {
+		t.Fatalf("first call: %+v, %v; want a model call", first, err)
+	}
+	second, err := verifier("model-a").VerifyImage(ctx, image)
+	if err != nil || !second.CacheHit || second.Verdict != models.VerdictValid || second.Image != image {
+		t.Fatalf("second call: %+v, %v; want a cache hit", second, err)
+	}
+	if got := len(script.Requests()); got != 1 {
+		t.Errorf("requests after a cache hit = %d, want 1", got)
+	}
+
+	other, err := verifier("model-b").VerifyImage(ctx, image)
+	if err != nil || other.CacheHit {
+		t.Fatalf("other model: %+v, %v; want a model call", other, err)
+	}
+
+	dir := t.TempDir()
+	prompt := "version: classify-test\ntask: classify\nlabels: VALID_NID=NID, IRRELEVANT=\n---\nClassify this image."
+	if err := os.WriteFile(filepath.Join(dir, "classify-test.txt"), []byte(prompt), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := prompts.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Activate(prompts.TaskClassify, prompts.Split{{Version: "classify-test", Weight: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	v := verifier("model-a")
+	v.prompts = registry
+	newer, err := v.VerifyImage(ctx, image)
+	if err != nil || newer.CacheHit || newer.PromptVersion != "classify-test" {
+		t.Fatalf("other prompt: %+v, %v; want a model call with classify-test", newer, err)
+	}
+	if got := len(script.Requests()); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+}
@@ -2,147 +2,403 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/base64" // Added base64
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"strings"
 	"time"
+
+	"kyc/internal/config"
+	"kyc/internal/models"
+	"kyc/internal/prompts"
+	"kyc/internal/uploads"
 )
 
+// VerificationService is a DocumentVerifier backed by an OpenAI-compatible
+// chat completions endpoint with vision support.
 type VerificationService struct {
-	apiKey   string
-	modelURL string
-	modelID  string // Added model ID
-	client   *http.Client
+	name       string
+	apiKey     string
+	modelURL   string
+	modelID    string // Added model ID
+	requireKey bool
+	client     *http.Client
+	retry      RetryPolicy
+	breaker    *CircuitBreaker
+	cache      *VerdictCache // Optional
+	prompts    *prompts.Registry
+
+	maxDimension int // longest side of the image sent to the model
+}
+
+const defaultModelMaxDimension = 1600
+
+// NewHuggingFaceVerifier returns a verifier for the Hugging Face Inference Router.
+// The router always requires an API key.
+func NewHuggingFaceVerifier(apiKey, modelURL, modelID string) *VerificationService {
+	return &VerificationService{
+		name:       "huggingface",
+		apiKey:     apiKey,
+		modelURL:   modelURL,
+		modelID:    modelID,
+		requireKey: true,
+		client:     &http.Client{},
+		retry:      defaultRetryPolicy,
+		breaker:    NewCircuitBreaker(5, 30*time.Second),
+		prompts:    prompts.Default(),
+
+		maxDimension: defaultModelMaxDimension,
+	}
 }
 
-func NewVerificationService(apiKey, modelURL, modelID string) *VerificationService {
+// NewOpenAICompatibleVerifier returns a verifier for any OpenAI-compatible
+// server, such as a local vLLM or llama.cpp instance. baseURL may be either
+// the API root (".../v1") or the full chat completions URL. The API key is optional.
+func NewOpenAICompatibleVerifier(baseURL, apiKey, modelID string) *VerificationService {
+	modelURL := strings.TrimSuffix(baseURL, "/")
+	if modelURL != "" && !strings.HasSuffix(modelURL, "/chat/completions") {
+		modelURL += "/chat/completions"
+	}
 	return &VerificationService{
+		name:     "openai",
 		apiKey:   apiKey,
 		modelURL: modelURL,
 		modelID:  modelID,
 		client:   &http.Client{},
+		retry:    defaultRetryPolicy,
+		breaker:  NewCircuitBreaker(5, 30*time.Second),
+		prompts:  prompts.Default(),
+
+		maxDimension: defaultModelMaxDimension,
 	}
 }
 
-// VerifyImage checks if the image at the given path is a valid ID document.
-// It returns true if valid, false otherwise.
-func (s *VerificationService) VerifyImage(imagePath string) (bool, error) {
-	if s.apiKey == "" {
-		// Mock behavior if no API Key provided
-		return true, nil
+func (s *VerificationService) Name() string { return s.name }
+
+// configure applies the image size, retry and circuit breaker settings.
+func (s *VerificationService) configure(cfg *config.Config) {
+	s.maxDimension = cfg.ModelMaxDimension
+	s.retry.MaxAttempts = max(cfg.AIMaxAttempts, 1)
+	s.retry.AttemptTimeout = cfg.AIAttemptTimeout
+	s.retry.CallTimeout = cfg.AICallTimeout
+	s.breaker = NewCircuitBreaker(cfg.AIBreakerThreshold, cfg.AIBreakerCooldown)
+}
+
+// VerifyImage asks the model to classify the image at the given path, using
+// the active classification prompt for the document type in ctx.
+func (s *VerificationService) VerifyImage(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+	start := time.Now()
+	if !s.configured() {
+		return nil, ErrNotConfigured
+	}
+	img, err := loadModelImage(imagePath, s.maxDimension)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := s.prompts.Select(prompts.TaskClassify, documentTypeFrom(ctx), img.sha256)
+	if err != nil {
+		return nil, err
+	}
+
+	key := VerdictKey{SHA256: img.sha256, ModelID: s.modelID, PromptVersion: prompt.ID()}
+	if cached := s.cache.Get(ctx, key); cached != nil {
+		cached.Image = imagePath
+		cached.CacheHit = true
+		cached.LatencyMS = time.Since(start).Milliseconds()
+		return cached, nil
+	}
+
+	text, err := s.chat(ctx, prompt.Text, prompt.MaxTokens, img)
+	if err != nil {
+		return nil, err
 	}
 
+	res := ParseClassification(text, prompt)
+	res.Image = imagePath
+	res.Provider = s.name
+	res.ModelID = s.modelID
+	res.PromptVersion = prompt.ID()
+	res.ImageSHA256 = img.sha256
+	res.LatencyMS = time.Since(start).Milliseconds()
+	res.VerifiedAt = time.Now()
+	s.cache.Put(ctx, key, res)
+	return res, nil
+}
+
+// ExtractFields asks the model to read the identity fields printed on the document.
+func (s *VerificationService) ExtractFields(ctx context.Context, imagePath string) (*models.ExtractedFields, error) {
+	if !s.configured() {
+		return nil, ErrNotConfigured
+	}
+	img, err := loadModelImage(imagePath, s.maxDimension)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := s.prompts.Select(prompts.TaskExtract, documentTypeFrom(ctx), img.sha256)
+	if err != nil {
+		return nil, err
+	}
+	text, err := s.chat(ctx, prompt.Text, prompt.MaxTokens, img)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := ParseExtractedFields(text)
+	if err != nil {
+		return nil, err
+	}
+	fields.Provider = s.name
+	fields.ModelID = s.modelID
+	fields.PromptVersion = prompt.ID()
+	fields.ExtractedAt = time.Now()
+	return fields, nil
+}
+
+func (s *VerificationService) configured() bool {
+	return s.modelURL != "" && s.modelID != "" && (!s.requireKey || s.apiKey != "")
+}
+
+// modelImage is an image prepared for sending to the model.
+type modelImage struct {
+	dataURI string
+	sha256  string // Of the bytes sent, which identifies the image for the verdict cache
+}
+
+func loadModelImage(imagePath string, maxDimension int) (*modelImage, error) {
 	// Read image file
 	fileBytes, err := os.ReadFile(imagePath)
 	if err != nil {
-		return false, fmt.Errorf("failed to read image: %w", err)
+		return nil, fmt.Errorf("failed to read image: %w", err)
 	}
 
-	// Detect Content Type (approximate)
-	contentType := http.DetectContentType(fileBytes)
-	base64Image := base64.StdEncoding.EncodeToString(fileBytes)
-	dataURI := fmt.Sprintf("data:%s;base64,%s", contentType, base64Image)
+	// Downscale to keep the request small; PDF and HEIC cannot be sent to
+	// the model, so the chain falls back to the next verifier.
+	fileBytes, contentType, err := uploads.ForModel(fileBytes, maxDimension)
+	if err != nil {
+		return nil, fmt.Errorf("image cannot be sent to the model: %w", err)
+	}
+	sum := sha256.Sum256(fileBytes)
+	return &modelImage{
+		dataURI: fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(fileBytes)),
+		sha256:  hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// chat sends the prompt together with the images and returns the text of the first choice.
+func (s *VerificationService) chat(ctx context.Context, prompt string, maxTokens int, images ...*modelImage) (string, error) {
+	content := []map[string]interface{}{
+		{
+			"type": "text",
+			"text": prompt,
+		},
+	}
+	for _, img := range images {
+		content = append(content, map[string]interface{}{
+			"type": "image_url",
+			"image_url": map[string]string{
+				"url": img.dataURI,
+			},
+		})
+	}
 
 	// Construct OpenAI-compatible Payload
 	payload := map[string]interface{}{
 		"model": s.modelID,
 		"messages": []map[string]interface{}{
 			{
-				"role": "user",
-				"content": []map[string]interface{}{
-					{
-						"type": "text",
-						"text": "Analyze this image. Is it a valid official identity document (National ID, Passport, Driving License, Visa)?\n\nIf YES, output strictly one of: 'VALID_NID', 'VALID_PASSPORT', 'VALID_LICENSE', 'VALID_VISA'.\nIf NO, output strictly: 'IRRELEVANT'.\n\nDo not provide any other text or explanation.",
-					},
-					{
-						"type": "image_url",
-						"image_url": map[string]string{
-							"url": dataURI,
-						},
-					},
-				},
+				"role":    "user",
+				"content": content,
 			},
 		},
-		"max_tokens": 50, // Enough for a short description
+		"max_tokens": maxTokens,
 	}
 
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
-		return false, fmt.Errorf("failed to marshal payload: %w", err)
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	// Retry logic for "Model is loading" or server errors
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		req, err := http.NewRequest("POST", s.modelURL, bytes.NewReader(jsonPayload))
-		if err != nil {
-			return false, err
-		}
+	if err := s.breaker.Allow(); err != nil {
+		return "", err
+	}
+	text, err := s.post(ctx, jsonPayload)
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		s.breaker.Success()
+	case ctx.Err() != nil, errors.As(err, &apiErr) && (!apiErr.Temporary() || apiErr.Status == http.StatusTooManyRequests):
+		// Cancelled by the caller, a request the provider refused, or one it
+		// asked us to slow down for (the retries already waited out its
+		// Retry-After); none says the provider is down.
+		s.breaker.Release()
+	default:
+		s.breaker.Failure()
+	}
+	return text, err
+}
 
-		req.Header.Set("Authorization", "Bearer "+s.apiKey)
-		req.Header.Set("Content-Type", "application/json")
+// post sends the payload, retrying temporary failures with backoff until
+// the retry policy's attempts or overall deadline run out.
+func (s *VerificationService) post(ctx context.Context, payload []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.retry.CallTimeout)
+	defer cancel()
 
-		resp, err := s.client.Do(req)
-		if err != nil {
-			return false, err
+	var lastErr error
+	for attempt := 0; attempt < s.retry.MaxAttempts; attempt++ {
+		text, err := s.attempt(ctx, payload)
+		if err == nil {
+			return text, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%w (last error: %w)", ctx.Err(), err)
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode == http.StatusOK {
-			// Parse OpenAI-style response
-			var result struct {
-				Choices []struct {
-					Message struct {
-						Content string `json:"content"`
-					} `json:"message"`
-				} `json:"choices"`
-			}
 
-			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-				return false, fmt.Errorf("failed to decode response: %w", err)
+		var retryAfter time.Duration
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			if !apiErr.Temporary() {
+				return "", err
 			}
+			retryAfter = apiErr.RetryAfter
+		}
+		if attempt == s.retry.MaxAttempts-1 {
+			break
+		}
+		delay := s.retry.Backoff(attempt, retryAfter)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break // The wait alone would exceed the deadline
+		}
+		log.Printf("%s attempt %d failed: %v. Retrying in %s", s.name, attempt+1, err, delay.Round(time.Millisecond))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", fmt.Errorf("%w (last error: %w)", ctx.Err(), err)
+		}
+	}
+	return "", fmt.Errorf("max retries exceeded for AI service: %w", lastErr)
+}
 
-			if len(result.Choices) > 0 {
-				text := strings.TrimSpace(result.Choices[0].Message.Content)
-				fmt.Printf("AI Response: %s\n", text) // Log the response
-
-				// Strict Label approach
-				// The model might still be chatty, so we look for the specific tokens at the start or end,
-				// or just presence of the positive tokens without negation.
-				// Given the prompt instruction "strictly one of", we check specific prefixes.
+// attempt makes one request with its own timeout.
+func (s *VerificationService) attempt(ctx context.Context, payload []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.retry.AttemptTimeout)
+	defer cancel()
 
-				validPrefixes := []string{"VALID_NID", "VALID_PASSPORT", "VALID_LICENSE", "VALID_VISA"}
-				upperText := strings.ToUpper(text)
+	req, err := http.NewRequestWithContext(ctx, "POST", s.modelURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
 
-				for _, prefix := range validPrefixes {
-					if strings.Contains(upperText, prefix) {
-						return true, nil
-					}
-				}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
 
-				fmt.Printf("AI Rejected: %s\n", text)
-				return false, nil
-			}
-			return false, nil // Default reject
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", &APIError{
+			Status:     resp.StatusCode,
+			Body:       string(bodyBytes),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
 		}
+	}
+
+	// Parse OpenAI-style response
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("AI response contained no choices")
+	}
+
+	text := strings.TrimSpace(result.Choices[0].Message.Content)
+	return text, nil
+}
+
+// Health reports the provider's circuit breaker.
+func (s *VerificationService) Health() []ProviderHealth {
+	return []ProviderHealth{{Name: s.name, BreakerState: s.breaker.State()}}
+}
 
-		// Handle Retry-able Errors
-		if resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests { // 429 is StatusTooManyRequests
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			errMsg := string(bodyBytes)
-			fmt.Printf("Attempt %d: API %d Error: %s. Retrying...\n", i+1, resp.StatusCode, errMsg)
-			time.Sleep(5 * time.Second)
-			continue
+// ParseClassification turns the model's answer into a verification result.
+// Only the labels of the prompt's schema are accepted. The JSON form
+// requested by the prompt is preferred; a bare label is also
+// accepted, but only when it is the first word of the answer, so that
+// "NOT VALID_NID" or "INVALID_NID" are never read as a positive verdict.
+// Anything else is treated as irrelevant.
+func ParseClassification(text string, prompt *prompts.Template) *models.VerificationResult {
+	res := &models.VerificationResult{
+		RawOutput: text,
+		Verdict:   models.VerdictInvalid,
+	}
+
+	var answer struct {
+		Label      string  `json:"label"`
+		Confidence float64 `json:"confidence"`
+		Reason     string  `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(jsonObject(text)), &answer); err == nil && answer.Label != "" {
+		res.Reason = answer.Reason
+		res.Confidence = answer.Confidence
+		applyLabel(res, prompt, strings.ToUpper(strings.TrimSpace(answer.Label)))
+		return res
+	}
+
+	fields := strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return !(r == '_' || (r >= 'A' && r <= 'Z'))
+	})
+	if len(fields) > 0 {
+		applyLabel(res, prompt, fields[0])
+		if res.Verdict == models.VerdictValid {
+			// A bare label carries no calibrated confidence.
+			res.Confidence = 0.5
 		}
+	}
+	return res
+}
 
-		// Permanent Errors
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return false, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+func applyLabel(res *models.VerificationResult, prompt *prompts.Template, label string) {
+	docType, known := prompt.LabelType(label)
+	if !known {
+		res.Confidence = 0
+		return
+	}
+	res.Label = label
+	res.DocumentType = docType
+	if docType != "" {
+		res.Verdict = models.VerdictValid
+	}
+	if res.Confidence < 0 || res.Confidence > 1 {
+		res.Confidence = 0
 	}
+}
 
-	return false, fmt.Errorf("max retries exceeded for AI service")
+// jsonObject returns the outermost {...} in text, dropping code fences or
+// chatter the model may add around it.
+func jsonObject(text string) string {
+	if i, j := strings.Index(text, "{"), strings.LastIndex(text, "}"); i >= 0 && j > i {
+		return text[i : j+1]
+	}
+	return text
 }
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kyc/internal/models"
+	"kyc/internal/modelstub"
+	"kyc/internal/prompts"
+)
+
+// writeTestImage writes a small PNG that is the same on every run.
+func writeTestImage(t *testing.T) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 64, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 4), uint8(y * 6), 120, 255})
+		}
+	}
+	path := filepath.Join(t.TempDir(), "document.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// fastRetries keeps retry tests quick while still exercising every attempt.
+var fastRetries = RetryPolicy{
+	MaxAttempts:    3,
+	AttemptTimeout: 5 * time.Second,
+	CallTimeout:    5 * time.Second,
+	BaseDelay:      time.Millisecond,
+	MaxDelay:       5 * time.Millisecond,
+}
+
+func TestVerifyImageProviderResponses(t *testing.T) {
+	tests := []struct {
+		name         string
+		replies      []modelstub.Reply
+		wantVerdict  models.Verdict
+		wantType     string
+		wantErr      string
+		wantRequests int
+	}{
+		{
+			name:         "valid document",
+			replies:      []modelstub.Reply{modelstub.Answer(`{"label": "VALID_NID", "confidence": 0.93, "reason": "Bangladesh NID card"}`)},
+			wantVerdict:  models.VerdictValid,
+			wantType:     "NID",
+			wantRequests: 1,
+		},
+		{
+			name:         "chatty answer",
+			replies:      []modelstub.Reply{modelstub.Chatty(`{"label": "VALID_PASSPORT", "confidence": 0.8, "reason": "passport data page"}`)},
+			wantVerdict:  models.VerdictValid,
+			wantType:     "PASSPORT",
+			wantRequests: 1,
+		},
+		{
+			name:         "irrelevant image",
+			replies:      []modelstub.Reply{modelstub.Answer(`{"label": "IRRELEVANT", "confidence": 0.99, "reason": "a cat"}`)},
+			wantVerdict:  models.VerdictInvalid,
+			wantRequests: 1,
+		},
+		{
+			name: "rate limited then answered",
+			replies: []modelstub.Reply{
+				modelstub.RateLimited("1"),
+				modelstub.Answer(`{"label": "VALID_LICENSE", "confidence": 0.7, "reason": "driving licence"}`),
+			},
+			wantVerdict:  models.VerdictValid,
+			wantType:     "DRIVING_LICENSE",
+			wantRequests: 2,
+		},
+		{
+			name:         "retry-after beyond the deadline",
+			replies:      []modelstub.Reply{modelstub.RateLimited("30")},
+			wantErr:      "status 429",
+			wantRequests: 1,
+		},
+		{
+			name:         "model loading",
+			replies:      []modelstub.Reply{modelstub.ModelLoading(20)},
+			wantErr:      "status 503",
+			wantRequests: 3,
+		},
+		{
+			name:         "malformed JSON",
+			replies:      []modelstub.Reply{modelstub.Malformed()},
+			wantErr:      "failed to decode response",
+			wantRequests: 3,
+		},
+		{
+			name:         "bad request is not retried",
+			replies:      []modelstub.Reply{{Status: http.StatusBadRequest, Body: `{"error": "invalid model"}`}},
+			wantErr:      "status 400",
+			wantRequests: 1,
+		},
+	}
+
+	image := writeTestImage(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, script := modelstub.NewServer(tt.replies...)
+			defer server.Close()
+
+			v := NewOpenAICompatibleVerifier(server.URL+"/v1", "test-key", "test-model")
+			v.retry = fastRetries
+			res, err := v.VerifyImage(context.Background(), image)
+
+			if got := len(script.Requests()); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Verdict != tt.wantVerdict || res.DocumentType != tt.wantType {
+				t.Errorf("got %s %q, want %s %q", res.Verdict, res.DocumentType, tt.wantVerdict, tt.wantType)
+			}
+			if res.PromptVersion != prompts.DefaultClassify || res.ModelID != "test-model" || res.ImageSHA256 == "" {
+				t.Errorf("result not attributed: prompt %q, model %q, sha %q", res.PromptVersion, res.ModelID, res.ImageSHA256)
+			}
+		})
+	}
+}
+
+func TestVerifyImageOpensCircuit(t *testing.T) {
+	server, script := modelstub.NewServer(modelstub.ModelLoading(20))
+	defer server.Close()
+
+	v := NewOpenAICompatibleVerifier(server.URL, "", "test-model")
+	v.retry = fastRetries
+	v.retry.MaxAttempts = 1
+	v.breaker = NewCircuitBreaker(2, time.Minute)
+
+	image := writeTestImage(t)
+	for range 2 {
+		if _, err := v.VerifyImage(context.Background(), image); err == nil {
+			t.Fatal("expected an error while the model is loading")
+		}
+	}
+	if _, err := v.VerifyImage(context.Background(), image); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if got := len(script.Requests()); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestVerifyImageRateLimitKeepsCircuitClosed(t *testing.T) {
+	server, script := modelstub.NewServer(modelstub.RateLimited("0"), modelstub.RateLimited("0"), modelstub.Answer("VALID_NID"))
+	defer server.Close()
+
+	v := NewOpenAICompatibleVerifier(server.URL, "", "test-model")
+	v.retry = fastRetries
+	v.retry.MaxAttempts = 1
+	v.breaker = NewCircuitBreaker(2, time.Minute)
+
+	image := writeTestImage(t)
+	for range 2 {
+		if _, err := v.VerifyImage(context.Background(), image); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("err = %v, want the rate limit error", err)
+		}
+	}
+	if state := v.breaker.State(); state.State != CircuitClosed || state.Failures != 0 {
+		t.Errorf("breaker = %+v, want closed without failures", state)
+	}
+	if _, err := v.VerifyImage(context.Background(), image); err != nil {
+		t.Fatalf("err = %v after the rate limit passed", err)
+	}
+	if got := len(script.Requests()); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+}
+
+func TestParseClassification(t *testing.T) {
+	prompt, err := prompts.Default().Select(prompts.TaskClassify, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text        string
+		wantVerdict models.Verdict
+		wantType    string
+		wantConf    float64
+	}{
+		{`{"label": "VALID_NID", "confidence": 0.9, "reason": "ok"}`, models.VerdictValid, "NID", 0.9},
+		{"```json\n{\"label\": \"valid_passport\", \"confidence\": 0.6}\n```", models.VerdictValid, "PASSPORT", 0.6},
+		{`{"label": "VALID_NID", "confidence": 7}`, models.VerdictValid, "NID", 0},
+		{`{"label": "IRRELEVANT", "confidence": 0.95}`, models.VerdictInvalid, "", 0.95},
+		{`{"label": "VALID_BILL", "confidence": 0.9}`, models.VerdictInvalid, "", 0},
+		{"VALID_LICENSE", models.VerdictValid, "DRIVING_LICENSE", 0.5},
+		{"VALID_VISA", models.VerdictInvalid, "", 0}, // Not an accepted document type
+		{"NOT VALID_NID", models.VerdictInvalid, "", 0},
+		{"INVALID_NID", models.VerdictInvalid, "", 0},
+		{"I cannot tell.", models.VerdictInvalid, "", 0},
+	}
+	for _, tt := range tests {
+		res := ParseClassification(tt.text, prompt)
+		if res.Verdict != tt.wantVerdict || res.DocumentType != tt.wantType || res.Confidence != tt.wantConf {
+			t.Errorf("ParseClassification(%q) = %s %q %v, want %s %q %v",
+				tt.text, res.Verdict, res.DocumentType, res.Confidence, tt.wantVerdict, tt.wantType, tt.wantConf)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"kyc/internal/config"
+	"kyc/internal/models"
+	"kyc/internal/prompts"
+	"kyc/internal/uploads"
+)
+
+// ErrNotConfigured is returned by a provider that is missing credentials or an endpoint.
+var ErrNotConfigured = errors.New("verifier not configured")
+
+// DocumentVerifier decides whether an uploaded image is an identity document.
+// An error means the provider could not reach a verdict; a negative verdict
+// is reported through the result. Implementations must give up when ctx is
+// done.
+type DocumentVerifier interface {
+	Name() string
+	VerifyImage(ctx context.Context, imagePath string) (*models.VerificationResult, error)
+}
+
+type documentTypeKey struct{}
+
+// WithDocumentType returns a context carrying the document type the user
+// declared, so verifiers can use a prompt written for that type.
+func WithDocumentType(ctx context.Context, docType string) context.Context {
+	return context.WithValue(ctx, documentTypeKey{}, docType)
+}
+
+func documentTypeFrom(ctx context.Context) string {
+	docType, _ := ctx.Value(documentTypeKey{}).(string)
+	return docType
+}
+
+// ChainVerifier asks each verifier in order and falls back to the next one
+// when a provider fails. A definite verdict (valid or not) stops the chain.
+type ChainVerifier struct {
+	verifiers []DocumentVerifier
+}
+
+func NewChainVerifier(verifiers ...DocumentVerifier) *ChainVerifier {
+	return &ChainVerifier{verifiers: verifiers}
+}
+
+func (c *ChainVerifier) Name() string {
+	names := make([]string, len(c.verifiers))
+	for i, v := range c.verifiers {
+		names[i] = v.Name()
+	}
+	return "chain(" + strings.Join(names, ",") + ")"
+}
+
+func (c *ChainVerifier) VerifyImage(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+	var errs []error
+	for _, v := range c.verifiers {
+		res, err := v.VerifyImage(ctx, imagePath)
+		if err == nil {
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			// The caller gave up; the next verifier would not be heard either.
+			return nil, ctx.Err()
+		}
+		log.Printf("Verifier %s failed, trying next: %v", v.Name(), err)
+		errs = append(errs, fmt.Errorf("%s: %w", v.Name(), err))
+	}
+	if len(errs) == 0 {
+		return nil, ErrNotConfigured
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ProviderHealth is the state of one verifier, for the readiness endpoint.
+type ProviderHealth struct {
+	Name string `json:"name"`
+	BreakerState
+}
+
+// HealthReporter is implemented by verifiers that can report their state.
+type HealthReporter interface {
+	Health() []ProviderHealth
+}
+
+// CircuitLocal is the state of verifiers that run in-process and cannot fail.
+const CircuitLocal = "local"
+
+// Health reports every verifier in the chain.
+func (c *ChainVerifier) Health() []ProviderHealth {
+	var out []ProviderHealth
+	for _, v := range c.verifiers {
+		if r, ok := v.(HealthReporter); ok {
+			out = append(out, r.Health()...)
+		} else {
+			out = append(out, ProviderHealth{Name: v.Name(), BreakerState: BreakerState{State: CircuitLocal}})
+		}
+	}
+	return out
+}
+
+// ManualReviewVerifier never makes an automated decision. It is used as the
+// last link of a chain so that documents still reach the review queue when
+// every AI provider is down.
+type ManualReviewVerifier struct{}
+
+func (ManualReviewVerifier) Name() string { return "manual" }
+
+func (ManualReviewVerifier) VerifyImage(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+	return &models.VerificationResult{
+		Image:      imagePath,
+		Provider:   "manual",
+		Verdict:    models.VerdictManualReview,
+		VerifiedAt: time.Now(),
+	}, nil
+}
+
+// RulesVerifier is a deterministic verifier for tests and local development.
+// It looks only at the stored image, never at its file name, which uploads
+// replace: it rejects files that are not a decodable JPEG or PNG, images too
+// small to read and blank images, and accepts everything else as the
+// document type the user declared.
+type RulesVerifier struct {
+	MinSide     int     // Shorter side, in pixels, of a readable document
+	MinContrast float64 // Standard deviation of luma below which an image is blank
+}
+
+func NewRulesVerifier() *RulesVerifier {
+	return &RulesVerifier{MinSide: 200, MinContrast: 8}
+}
+
+func (r *RulesVerifier) Name() string { return "rules" }
+
+func (r *RulesVerifier) VerifyImage(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+	start := time.Now()
+	fileBytes, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	res := &models.VerificationResult{
+		Image:      imagePath,
+		Provider:   r.Name(),
+		Verdict:    models.VerdictInvalid,
+		Confidence: 1,
+	}
+	defer func() {
+		res.LatencyMS = time.Since(start).Milliseconds()
+		res.VerifiedAt = time.Now()
+	}()
+
+	if kind, _ := uploads.Sniff(fileBytes); !kind.Decodable() {
+		res.Reason = "not a JPEG or PNG image"
+		return res, nil
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(fileBytes))
+	if err != nil {
+		res.Reason = "image cannot be decoded"
+		return res, nil
+	}
+	if min(cfg.Width, cfg.Height) < r.MinSide {
+		res.Reason = fmt.Sprintf("%dx%d is too small to read", cfg.Width, cfg.Height)
+		return res, nil
+	}
+	img, err := uploads.DecodeForAnalysis(fileBytes, livenessDimension)
+	if err != nil {
+		res.Reason = "image cannot be decoded"
+		return res, nil
+	}
+	if _, contrast := meanStdDev(lumaPlane(img)); contrast < r.MinContrast {
+		res.Reason = "blank image"
+		return res, nil
+	}
+
+	res.DocumentType = documentTypeFrom(ctx)
+	res.Verdict = models.VerdictValid
+	return res, nil
+}
+
+// NewDocumentVerifier builds the verifier chain named in cfg.VerifierChain.
+// The AI verifiers share the prompt registry and cache, which may be nil.
+func NewDocumentVerifier(cfg *config.Config, registry *prompts.Registry, cache *VerdictCache) (DocumentVerifier, error) {
+	var verifiers []DocumentVerifier
+	for _, name := range strings.Split(cfg.VerifierChain, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+			continue
+		case "huggingface", "hf":
+			v := NewHuggingFaceVerifier(cfg.HuggingFaceAPIKey, cfg.HuggingFaceModelURL, cfg.HuggingFaceModelID)
+			v.configure(cfg)
+			v.cache = cache
+			v.prompts = registry
+			verifiers = append(verifiers, v)
+		case "openai":
+			v := NewOpenAICompatibleVerifier(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.OpenAIModelID)
+			v.configure(cfg)
+			v.cache = cache
+			v.prompts = registry
+			verifiers = append(verifiers, v)
+		case "rules", "mock":
+			verifiers = append(verifiers, NewRulesVerifier())
+		case "manual":
+			verifiers = append(verifiers, ManualReviewVerifier{})
+		default:
+			return nil, fmt.Errorf("unknown verifier %q in VERIFIER_CHAIN", name)
+		}
+	}
+	if len(verifiers) == 0 {
+		return nil, errors.New("VERIFIER_CHAIN is empty")
+	}
+	return NewChainVerifier(verifiers...), nil
+}
+
+// LoadPrompts loads the prompt registry and activates the configured versions.
+func LoadPrompts(cfg *config.Config) (*prompts.Registry, error) {
+	registry, err := prompts.Load(cfg.PromptDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompts: %w", err)
+	}
+	for task, setting := range map[string]string{
+		prompts.TaskClassify:  cfg.PromptClassify,
+		prompts.TaskExtract:   cfg.PromptExtract,
+		prompts.TaskFaceMatch: cfg.PromptFaceMatch,
+	} {
+		split, err := prompts.ParseSplit(setting)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s prompt setting %q: %w", task, setting, err)
+		}
+		if err := registry.Activate(task, split); err != nil {
+			return nil, err
+		}
+		log.Printf("Prompt versions for %s: %s", task, setting)
+	}
+	return registry, nil
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kyc/internal/models"
+)
+
+// fakeVerifier returns a fixed verdict or error and counts its calls.
+type fakeVerifier struct {
+	name   string
+	res    *models.VerificationResult
+	fields *models.ExtractedFields
+	err    error
+	calls  int
+}
+
+func (f *fakeVerifier) Name() string { return f.name }
+
+func (f *fakeVerifier) VerifyImage(ctx context.Context, imagePath string) (*models.VerificationResult, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	res := *f.res
+	res.Image, res.Provider = imagePath, f.name
+	return &res, nil
+}
+
+// fakeExtractor is a fakeVerifier that also reads fields.
+type fakeExtractor struct{ *fakeVerifier }
+
+func (f fakeExtractor) ExtractFields(ctx context.Context, imagePath string) (*models.ExtractedFields, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.fields, nil
+}
+
+var (
+	errDown        = errors.New("503 service unavailable")
+	errRateLimited = errors.New("429 rate limited")
+)
+
+func TestChainVerifier(t *testing.T) {
+	valid := &models.VerificationResult{Verdict: models.VerdictValid, DocumentType: "NID"}
+	invalid := &models.VerificationResult{Verdict: models.VerdictInvalid}
+	tests := []struct {
+		name         string
+		verifiers    []*fakeVerifier
+		wantProvider string
+		wantVerdict  models.Verdict
+		wantCalls    []int
+		wantErrs     []error
+	}{
+		{
+			name:         "first answers",
+			verifiers:    []*fakeVerifier{{name: "a", res: valid}, {name: "b", res: valid}},
+			wantProvider: "a", wantVerdict: models.VerdictValid, wantCalls: []int{1, 0},
+		},
+		{
+			name:         "a rejection stops the chain",
+			verifiers:    []*fakeVerifier{{name: "a", res: invalid}, {name: "b", res: valid}},
+			wantProvider: "a", wantVerdict: models.VerdictInvalid, wantCalls: []int{1, 0},
+		},
+		{
+			name:         "falls back on error",
+			verifiers:    []*fakeVerifier{{name: "a", err: errDown}, {name: "b", err: errRateLimited}, {name: "c", res: valid}, {name: "d", res: invalid}},
+			wantProvider: "c", wantVerdict: models.VerdictValid, wantCalls: []int{1, 1, 1, 0},
+		},
+		{
+			name:      "all fail",
+			verifiers: []*fakeVerifier{{name: "a", err: errDown}, {name: "b", err: errRateLimited}},
+			wantCalls: []int{1, 1}, wantErrs: []error{errDown, errRateLimited},
+		},
+		{
+			name:     "empty chain",
+			wantErrs: []error{ErrNotConfigured},
+		},
+	}
+	for _, tt := range tests {
+		var vs []DocumentVerifier
+		for _, v := range tt.verifiers {
+			vs = append(vs, v)
+		}
+		res, err := NewChainVerifier(vs...).VerifyImage(context.Background(), "doc.png")
+		for i, v := range tt.verifiers {
+			if v.calls != tt.wantCalls[i] {
+				t.Errorf("%s: %s called %d times, want %d", tt.name, v.name, v.calls, tt.wantCalls[i])
+			}
+		}
+		if tt.wantErrs != nil {
+			if res != nil || err == nil {
+				t.Errorf("%s: got %+v, %v, want an error", tt.name, res, err)
+				continue
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("%s: error %q does not wrap %q", tt.name, err, want)
+				}
+			}
+			for _, v := range tt.verifiers {
+				if !strings.Contains(err.Error(), v.name+": ") {
+					t.Errorf("%s: error %q does not name %s", tt.name, err, v.name)
+				}
+			}
+			continue
+		}
+		if err != nil || res.Provider != tt.wantProvider || res.Verdict != tt.wantVerdict || res.Image != "doc.png" {
+			t.Errorf("%s: got %+v, %v, want %s from %s", tt.name, res, err, tt.wantVerdict, tt.wantProvider)
+		}
+	}
+}
+
+func TestChainVerifierStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a := &fakeVerifier{name: "a", err: errDown}
+	b := &fakeVerifier{name: "b", res: &models.VerificationResult{Verdict: models.VerdictValid}}
+	if _, err := NewChainVerifier(a, b).VerifyImage(ctx, "doc.png"); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if b.calls != 0 {
+		t.Error("the next verifier was asked after the caller gave up")
+	}
+}
+
+func TestChainExtractFields(t *testing.T) {
+	fields := &models.ExtractedFields{FullName: "Rahim Uddin"}
+	manual := &fakeVerifier{name: "manual", res: &models.VerificationResult{Verdict: models.VerdictManualReview}}
+	tests := []struct {
+		name      string
+		verifiers []DocumentVerifier
+		wantCalls []int
+		wantErrs  []error
+	}{
+		{
+			name:      "falls back on error",
+			verifiers: []DocumentVerifier{fakeExtractor{&fakeVerifier{name: "a", err: errDown}}, manual, fakeExtractor{&fakeVerifier{name: "b", fields: fields}}, fakeExtractor{&fakeVerifier{name: "c", fields: fields}}},
+			wantCalls: []int{1, 0, 1, 0},
+		},
+		{
+			name:      "all fail",
+			verifiers: []DocumentVerifier{fakeExtractor{&fakeVerifier{name: "a", err: errDown}}, fakeExtractor{&fakeVerifier{name: "b", err: errRateLimited}}},
+			wantCalls: []int{1, 1},
+			wantErrs:  []error{errDown, errRateLimited},
+		},
+		{
+			name:      "no extractor",
+			verifiers: []DocumentVerifier{manual},
+			wantCalls: []int{0},
+			wantErrs:  []error{ErrNotConfigured},
+		},
+	}
+	for _, tt := range tests {
+		manual.calls = 0
+		got, err := NewChainVerifier(tt.verifiers...).ExtractFields(context.Background(), "doc.png")
+		for i, v := range tt.verifiers {
+			calls := manual.calls
+			if e, ok := v.(fakeExtractor); ok {
+				calls = e.calls
+			}
+			if calls != tt.wantCalls[i] {
+				t.Errorf("%s: %s called %d times, want %d", tt.name, v.Name(), calls, tt.wantCalls[i])
+			}
+		}
+		if tt.wantErrs == nil {
+			if err != nil || got != fields {
+				t.Errorf("%s: got %+v, %v", tt.name, got, err)
+			}
+			continue
+		}
+		for _, want := range tt.wantErrs {
+			if !errors.Is(err, want) {
+				t.Errorf("%s: error %v does not wrap %q", tt.name, err, want)
+			}
+		}
+	}
+}
+
+func TestRulesVerifier(t *testing.T) {
+	dir := t.TempDir()
+	pdf := filepath.Join(dir, "passport.pdf")
+	os.WriteFile(pdf, []byte("%PDF-1.7\n"), 0o600)
+	truncated := filepath.Join(dir, "truncated.png")
+	data, _ := os.ReadFile(writePNG(t, "full.png", 400, 300, 40, 220, 1))
+	os.WriteFile(truncated, data[:len(data)/2], 0o600)
+
+	tests := []struct {
+		name     string
+		path     string
+		declared string
+		verdict  models.Verdict
+		docType  string
+	}{
+		{"document", writePNG(t, "user1_1700000000_ab12.png", 400, 300, 40, 220, 1), "NID", models.VerdictValid, "NID"},
+		{"undeclared type", writePNG(t, "user1_1700000001_cd34.png", 400, 300, 40, 220, 2), "", models.VerdictValid, ""},
+		{"keyword in name is ignored", writePNG(t, "irrelevant-selfie.png", 400, 300, 40, 220, 3), "PASSPORT", models.VerdictValid, "PASSPORT"},
+		{"too small", writePNG(t, "small.png", 400, 150, 40, 220, 4), "NID", models.VerdictInvalid, ""},
+		{"blank", writePNG(t, "blank.png", 400, 300, 128, 132, 5), "NID", models.VerdictInvalid, ""},
+		{"pdf", pdf, "PASSPORT", models.VerdictInvalid, ""},
+		{"truncated", truncated, "NID", models.VerdictInvalid, ""},
+	}
+	v := NewRulesVerifier()
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.declared != "" {
+			ctx = WithDocumentType(ctx, tt.declared)
+		}
+		res, err := v.VerifyImage(ctx, tt.path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if res.Verdict != tt.verdict || res.DocumentType != tt.docType || res.Provider != "rules" {
+			t.Errorf("%s: %s %q (%s), want %s %q", tt.name, res.Verdict, res.DocumentType, res.Reason, tt.verdict, tt.docType)
+		}
+		if res.Verdict == models.VerdictInvalid && res.Reason == "" {
+			t.Errorf("%s: rejected without a reason", tt.name)
+		}
+	}
+
+	if _, err := v.VerifyImage(context.Background(), filepath.Join(dir, "missing.png")); err == nil {
+		t.Error("missing file: no error")
+	}
+}
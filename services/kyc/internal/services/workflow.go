@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"kyc/internal/models"
+)
+
+var (
+	ErrInvalidTransition  = errors.New("status transition not allowed")
+	ErrReasonRequired     = errors.New("a reason is required for this decision")
+	ErrSelfReview         = errors.New("reviewers cannot decide their own KYC request")
+	ErrDocumentNotExpired = errors.New("document has not expired")
+	ErrNotOwner           = errors.New("only the user can answer an information request or appeal")
+	ErrNotAppealable      = errors.New("only automatic rejections can be appealed, once")
+	ErrScreeningHold      = errors.New("a watchlist match must be cleared before approval")
+)
+
+// transitions lists the statuses each status may move to.
+//
+//	PENDING    -> NEEDS_INFO | APPROVED | REJECTED | FRAUD_REVIEW
+//	NEEDS_INFO -> PENDING | REJECTED | EXPIRED | FRAUD_REVIEW (answer with duplicate or forged images)
+//	FRAUD_REVIEW -> APPROVED | REJECTED
+//	APPROVED   -> EXPIRED | FRAUD_REVIEW (watchlist hit on rescreening)
+//	REJECTED   -> PENDING | FRAUD_REVIEW (appeal of an automatic rejection)
+//
+// EXPIRED and reviewed rejections are final; the user starts a new attempt
+// instead.
+var transitions = map[models.KYCStatus][]models.KYCStatus{
+	models.StatusPending:     {models.StatusNeedsInfo, models.StatusApproved, models.StatusRejected, models.StatusFraudReview},
+	models.StatusNeedsInfo:   {models.StatusPending, models.StatusRejected, models.StatusExpired, models.StatusFraudReview},
+	models.StatusFraudReview: {models.StatusApproved, models.StatusRejected},
+	models.StatusApproved:    {models.StatusExpired, models.StatusFraudReview},
+	models.StatusRejected:    {models.StatusPending, models.StatusFraudReview},
+}
+
+// CanTransition reports whether from may move to to.
+func CanTransition(from, to models.KYCStatus) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// CheckTransition validates a status change of kyc, including the guards
+// that depend on the request and the actor. now is used for expiry checks.
+func CheckTransition(kyc *models.KYCRequest, change models.StatusChange, now time.Time) error {
+	if !CanTransition(kyc.Status, change.To) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, kyc.Status, change.To)
+	}
+	// Answering NEEDS_INFO and appealing are the user's moves; every other
+	// change is a reviewer or system decision.
+	appeal := kyc.Status == models.StatusRejected
+	if appeal && (!kyc.AutoRejected || kyc.Appeal != nil) {
+		return ErrNotAppealable
+	}
+	answer := kyc.Status == models.StatusNeedsInfo && (change.To == models.StatusPending || change.To == models.StatusFraudReview)
+	userAction := appeal || answer
+	if userAction && change.Actor != kyc.UserID && change.Actor != models.ActorSystem {
+		return ErrNotOwner
+	}
+	if !userAction && change.Actor != models.ActorSystem && change.Actor == kyc.UserID {
+		return ErrSelfReview
+	}
+
+	switch change.To {
+	case models.StatusRejected, models.StatusNeedsInfo:
+		// The user must be told why.
+		if change.Reason == "" {
+			return ErrReasonRequired
+		}
+	case models.StatusApproved:
+		if slices.Contains(kyc.Flags, models.FlagWatchlistHit) {
+			return ErrScreeningHold
+		}
+		// Overriding a fraud hold must be justified.
+		if kyc.Status == models.StatusFraudReview && change.Reason == "" {
+			return ErrReasonRequired
+		}
+	case models.StatusExpired:
+		if kyc.Status == models.StatusApproved && !documentExpired(kyc, now) {
+			return ErrDocumentNotExpired
+		}
+	}
+	return nil
+}
+
+// documentExpired reports whether the extracted expiry date is in the past.
+func documentExpired(kyc *models.KYCRequest, now time.Time) bool {
+	if kyc.Extracted == nil || kyc.Extracted.ExpiryDate == "" {
+		return false
+	}
+	expiry, err := time.Parse("2006-01-02", kyc.Extracted.ExpiryDate)
+	return err == nil && now.After(expiry.AddDate(0, 0, 1))
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"kyc/internal/models"
+)
+
+func TestCheckTransition(t *testing.T) {
+	const user, reviewer = "user-1", "reviewer-1"
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	expiring := func(date string) *models.ExtractedFields { return &models.ExtractedFields{ExpiryDate: date} }
+
+	tests := []struct {
+		name   string
+		kyc    models.KYCRequest
+		to     models.KYCStatus
+		actor  string
+		reason string
+		want   error
+	}{
+		{"approve", models.KYCRequest{Status: models.StatusPending}, models.StatusApproved, reviewer, "", nil},
+		{"not allowed", models.KYCRequest{Status: models.StatusExpired}, models.StatusPending, reviewer, "", ErrInvalidTransition},
+		{"approved to pending", models.KYCRequest{Status: models.StatusApproved}, models.StatusPending, reviewer, "", ErrInvalidTransition},
+		{"fraud review to needs info", models.KYCRequest{Status: models.StatusFraudReview}, models.StatusNeedsInfo, reviewer, "Send a clearer photo", ErrInvalidTransition},
+
+		// Self-review
+		{"own approval", models.KYCRequest{Status: models.StatusPending}, models.StatusApproved, user, "", ErrSelfReview},
+		{"own rejection", models.KYCRequest{Status: models.StatusPending}, models.StatusRejected, user, "Changed my mind", ErrSelfReview},
+		{"system decision", models.KYCRequest{Status: models.StatusPending}, models.StatusFraudReview, models.ActorSystem, "", nil},
+
+		// Reason required
+		{"reject without reason", models.KYCRequest{Status: models.StatusPending}, models.StatusRejected, reviewer, "", ErrReasonRequired},
+		{"reject with reason", models.KYCRequest{Status: models.StatusPending}, models.StatusRejected, reviewer, "Photo is blurred", nil},
+		{"needs info without reason", models.KYCRequest{Status: models.StatusPending}, models.StatusNeedsInfo, reviewer, "", ErrReasonRequired},
+		{"fraud override without reason", models.KYCRequest{Status: models.StatusFraudReview}, models.StatusApproved, reviewer, "", ErrReasonRequired},
+		{"fraud override with reason", models.KYCRequest{Status: models.StatusFraudReview}, models.StatusApproved, reviewer, "Same person, new account", nil},
+
+		// Answering an information request
+		{"user answers", models.KYCRequest{Status: models.StatusNeedsInfo}, models.StatusPending, user, "", nil},
+		{"reviewer answers", models.KYCRequest{Status: models.StatusNeedsInfo}, models.StatusPending, reviewer, "", ErrNotOwner},
+		{"answer to fraud review", models.KYCRequest{Status: models.StatusNeedsInfo}, models.StatusFraudReview, user, "Requested information provided", nil},
+		{"reviewer answers to fraud review", models.KYCRequest{Status: models.StatusNeedsInfo}, models.StatusFraudReview, reviewer, "", ErrNotOwner},
+
+		// Appeals
+		{"appeal", models.KYCRequest{Status: models.StatusRejected, AutoRejected: true}, models.StatusPending, user, "Appeal: it is my NID", nil},
+		{"appeal to fraud review", models.KYCRequest{Status: models.StatusRejected, AutoRejected: true}, models.StatusFraudReview, user, "Appeal: it is my NID", nil},
+		{"second appeal", models.KYCRequest{Status: models.StatusRejected, AutoRejected: true, Appeal: &models.Appeal{Note: "first"}}, models.StatusPending, user, "Appeal: again", ErrNotAppealable},
+		{"appeal of reviewer rejection", models.KYCRequest{Status: models.StatusRejected}, models.StatusPending, user, "Appeal: please", ErrNotAppealable},
+		{"appeal by reviewer", models.KYCRequest{Status: models.StatusRejected, AutoRejected: true}, models.StatusPending, reviewer, "Appeal", ErrNotOwner},
+		{"appeal by system", models.KYCRequest{Status: models.StatusRejected, AutoRejected: true}, models.StatusPending, models.ActorSystem, "", nil},
+
+		// Screening hold
+		{"approve watchlist hit", models.KYCRequest{Status: models.StatusPending, Flags: []string{models.FlagWatchlistHit}}, models.StatusApproved, reviewer, "", ErrScreeningHold},
+		{"override watchlist hit", models.KYCRequest{Status: models.StatusFraudReview, Flags: []string{models.FlagAppealed, models.FlagWatchlistHit}}, models.StatusApproved, reviewer, "Not the listed person", ErrScreeningHold},
+		{"reject watchlist hit", models.KYCRequest{Status: models.StatusFraudReview, Flags: []string{models.FlagWatchlistHit}}, models.StatusRejected, reviewer, "Listed person", nil},
+		{"rescreening hold", models.KYCRequest{Status: models.StatusApproved}, models.StatusFraudReview, models.ActorSystem, "Your verification needs an additional review", nil},
+
+		// Expiry
+		{"expired document", models.KYCRequest{Status: models.StatusApproved, Extracted: expiring("2025-06-01")}, models.StatusExpired, models.ActorSystem, "", nil},
+		{"expires today", models.KYCRequest{Status: models.StatusApproved, Extracted: expiring("2025-06-15")}, models.StatusExpired, models.ActorSystem, "", ErrDocumentNotExpired},
+		{"valid document", models.KYCRequest{Status: models.StatusApproved, Extracted: expiring("2030-01-01")}, models.StatusExpired, models.ActorSystem, "", ErrDocumentNotExpired},
+		{"no expiry date", models.KYCRequest{Status: models.StatusApproved}, models.StatusExpired, models.ActorSystem, "", ErrDocumentNotExpired},
+		{"unreadable expiry date", models.KYCRequest{Status: models.StatusApproved, Extracted: expiring("soon")}, models.StatusExpired, models.ActorSystem, "", ErrDocumentNotExpired},
+		{"unanswered information request", models.KYCRequest{Status: models.StatusNeedsInfo}, models.StatusExpired, models.ActorSystem, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.kyc.UserID = user
+			change := models.StatusChange{To: tt.to, Actor: tt.actor, Reason: tt.reason}
+			err := CheckTransition(&tt.kyc, change, now)
+			if !errors.Is(err, tt.want) || (tt.want == nil) != (err == nil) {
+				t.Errorf("CheckTransition(%s -> %s by %s) = %v, want %v", tt.kyc.Status, tt.to, tt.actor, err, tt.want)
+			}
+		})
+	}
+}
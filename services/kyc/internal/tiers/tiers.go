@@ -0,0 +1,197 @@
+// Package tiers defines the KYC tiers: what a user must have verified to
+// reach each one, and the credit and payment limits it unlocks. The tiers
+// are built in and can be replaced by a JSON file:
+//
+//	{
+//	  "currency": "BDT",
+//	  "tiers": [
+//	    {"code": "BASIC_ID", "name": "Basic ID", "level": 1,
+//	     "requires": {"id_document": ["NID", "PASSPORT"]},
+//	     "limits": {"credit": 50000, "daily_payments": 50000, "monthly_payments": 200000}},
+//	    ...
+//	  ]
+//	}
+package tiers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"kyc/internal/doctypes"
+)
+
+// Built-in tier codes. None is the tier of users who meet no requirements.
+// There is no built-in phone tier: the auth service has no phone
+// verification flow yet, so phone_verified is never set.
+const (
+	None    = "NONE"
+	BasicID = "BASIC_ID"
+	Full    = "FULL"
+)
+
+// Requirement names, as reported in NextTier.Missing.
+const (
+	RequirePhone        = "phone_verified"
+	RequireIDDocument   = "id_document"
+	RequireSelfie       = "selfie"
+	RequireAddressProof = "address_proof"
+)
+
+// Requirements is what a user needs for a tier. Each set field must be met.
+type Requirements struct {
+	PhoneVerified bool     `json:"phone_verified,omitempty"`
+	IDDocument    []string `json:"id_document,omitempty"` // An approved document of one of these types
+	Selfie        bool     `json:"selfie,omitempty"`      // A selfie approved with the identity document
+	AddressProof  bool     `json:"address_proof,omitempty"`
+}
+
+// Limits are amounts in the configured currency. Zero means not allowed.
+type Limits struct {
+	Credit          int64 `json:"credit"`
+	DailyPayments   int64 `json:"daily_payments"`
+	MonthlyPayments int64 `json:"monthly_payments"`
+}
+
+type Tier struct {
+	Code     string       `json:"code"`
+	Name     string       `json:"name"`
+	Level    int          `json:"level"`
+	Requires Requirements `json:"requires"`
+	Limits   Limits       `json:"limits"`
+}
+
+type Config struct {
+	Currency string `json:"currency"`
+	Tiers    []Tier `json:"tiers"` // By level, lowest first
+}
+
+// Default returns the built-in tiers.
+func Default() *Config {
+	idDocuments := []string{doctypes.NID, doctypes.Passport, doctypes.DrivingLicense}
+	return &Config{
+		Currency: "BDT",
+		Tiers: []Tier{
+			{
+				Code:     BasicID,
+				Name:     "Basic ID",
+				Level:    1,
+				Requires: Requirements{IDDocument: idDocuments},
+				Limits:   Limits{Credit: 50_000, DailyPayments: 50_000, MonthlyPayments: 200_000},
+			},
+			{
+				Code:     Full,
+				Name:     "Full",
+				Level:    2,
+				Requires: Requirements{IDDocument: idDocuments, Selfie: true, AddressProof: true},
+				Limits:   Limits{Credit: 500_000, DailyPayments: 500_000, MonthlyPayments: 2_000_000},
+			},
+		},
+	}
+}
+
+// Load reads the tiers from a JSON file, or returns the built-in ones if
+// path is empty.
+func Load(path string) (*Config, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+func (c *Config) validate() error {
+	if len(c.Tiers) == 0 {
+		return errors.New("no tiers defined")
+	}
+	sort.SliceStable(c.Tiers, func(i, j int) bool { return c.Tiers[i].Level < c.Tiers[j].Level })
+	codes := map[string]bool{None: true}
+	for i, t := range c.Tiers {
+		if t.Code == "" || codes[t.Code] {
+			return fmt.Errorf("tier %d: missing or duplicate code %q", i, t.Code)
+		}
+		codes[t.Code] = true
+		if t.Level < 1 || (i > 0 && t.Level == c.Tiers[i-1].Level) {
+			return fmt.Errorf("tier %s: levels must be distinct and at least 1", t.Code)
+		}
+		for _, code := range t.Requires.IDDocument {
+			if !doctypes.Known(code) {
+				return fmt.Errorf("tier %s: unknown document type %q", t.Code, code)
+			}
+		}
+	}
+	return nil
+}
+
+// Facts is what is known about a user from one approved request.
+type Facts struct {
+	PhoneVerified bool
+	Documents     []string // Types of approved identity documents
+	Selfie        bool
+	AddressProof  bool
+}
+
+// Status is a user's current tier and what the next one needs.
+type Status struct {
+	Code     string    `json:"code"`
+	Name     string    `json:"name"`
+	Level    int       `json:"level"`
+	Currency string    `json:"currency"`
+	Limits   Limits    `json:"limits"`
+	Next     *NextTier `json:"next,omitempty"` // Nil at the top tier
+}
+
+type NextTier struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Level   int      `json:"level"`
+	Missing []string `json:"missing"`
+}
+
+// Evaluate returns the highest tier whose requirements f meets, and the
+// lowest higher tier with what is missing for it.
+func (c *Config) Evaluate(f Facts) Status {
+	status := Status{Code: None, Name: "None", Currency: c.Currency}
+	for _, t := range c.Tiers {
+		if len(t.Requires.missing(f)) == 0 {
+			status.Code, status.Name, status.Level, status.Limits = t.Code, t.Name, t.Level, t.Limits
+		}
+	}
+	for _, t := range c.Tiers {
+		if t.Level > status.Level {
+			status.Next = &NextTier{Code: t.Code, Name: t.Name, Level: t.Level, Missing: t.Requires.missing(f)}
+			break
+		}
+	}
+	return status
+}
+
+func (r Requirements) missing(f Facts) []string {
+	missing := []string{}
+	if r.PhoneVerified && !f.PhoneVerified {
+		missing = append(missing, RequirePhone)
+	}
+	if len(r.IDDocument) > 0 && !slices.ContainsFunc(f.Documents, func(d string) bool { return slices.Contains(r.IDDocument, d) }) {
+		missing = append(missing, RequireIDDocument)
+	}
+	if r.Selfie && !f.Selfie {
+		missing = append(missing, RequireSelfie)
+	}
+	if r.AddressProof && !f.AddressProof {
+		missing = append(missing, RequireAddressProof)
+	}
+	return missing
+}
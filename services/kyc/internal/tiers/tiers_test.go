@@ -0,0 +1,84 @@
+package tiers
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	cfg := Default()
+	tests := []struct {
+		name        string
+		facts       Facts
+		want        string
+		wantNext    string
+		wantMissing []string
+	}{
+		{"nothing", Facts{}, None, BasicID, []string{RequireIDDocument}},
+		{"phone only", Facts{PhoneVerified: true}, None, BasicID, []string{RequireIDDocument}},
+		{"ID without phone", Facts{Documents: []string{"NID"}}, BasicID, Full, []string{RequireSelfie, RequireAddressProof}},
+		{"basic", Facts{PhoneVerified: true, Documents: []string{"PASSPORT"}}, BasicID, Full, []string{RequireSelfie, RequireAddressProof}},
+		{"selfie only", Facts{PhoneVerified: true, Documents: []string{"NID"}, Selfie: true}, BasicID, Full, []string{RequireAddressProof}},
+		{"full", Facts{PhoneVerified: true, Documents: []string{"NID"}, Selfie: true, AddressProof: true}, Full, "", nil},
+		{"full without phone", Facts{Documents: []string{"NID"}, Selfie: true, AddressProof: true}, Full, "", nil},
+		{"selfie without document", Facts{Selfie: true, AddressProof: true}, None, BasicID, []string{RequireIDDocument}},
+		{"unlisted document", Facts{Documents: []string{"UTILITY_BILL"}}, None, BasicID, []string{RequireIDDocument}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cfg.Evaluate(tt.facts)
+			if got.Code != tt.want {
+				t.Fatalf("tier = %s, want %s", got.Code, tt.want)
+			}
+			if tt.wantNext == "" {
+				if got.Next != nil {
+					t.Errorf("next = %+v, want none", got.Next)
+				}
+				return
+			}
+			if got.Next == nil || got.Next.Code != tt.wantNext || !slices.Equal(got.Next.Missing, tt.wantMissing) {
+				t.Errorf("next = %+v, want %s missing %v", got.Next, tt.wantNext, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cfg, err := Load(write("ok.json", `{"currency": "BDT", "tiers": [
+		{"code": "ID", "level": 2, "requires": {"id_document": ["NID"]}, "limits": {"credit": 1000}},
+		{"code": "PHONE", "level": 1, "requires": {"phone_verified": true}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Tiers[0].Code != "PHONE" || cfg.Tiers[1].Limits.Credit != 1000 {
+		t.Errorf("tiers = %+v", cfg.Tiers)
+	}
+	// Tiers do not build on each other unless configured so.
+	if got := cfg.Evaluate(Facts{Documents: []string{"NID"}}); got.Code != "ID" {
+		t.Errorf("tier = %s, want ID", got.Code)
+	}
+
+	for name, body := range map[string]string{
+		"empty":          `{"tiers": []}`,
+		"duplicate code": `{"tiers": [{"code": "A", "level": 1}, {"code": "A", "level": 2}]}`,
+		"same level":     `{"tiers": [{"code": "A", "level": 1}, {"code": "B", "level": 1}]}`,
+		"unknown type":   `{"tiers": [{"code": "A", "level": 1, "requires": {"id_document": ["LIBRARY_CARD"]}}]}`,
+	} {
+		if _, err := Load(write(strings.ReplaceAll(name, " ", "_")+".json", body)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package uploads
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// HEIC cannot be decoded with the standard library, so it is not re-encoded.
+// Instead the ISO BMFF structure is walked to blank out the EXIF item in
+// place and to read the declared image size for the decompression-bomb check.
+
+var errBadBox = errors.New("malformed HEIC box structure")
+
+type box struct {
+	typ     string
+	payload []byte
+	start   int // offset of the payload within the file
+}
+
+// boxes splits data into ISO BMFF boxes. base is the file offset of data.
+func boxes(data []byte, base int) ([]box, error) {
+	var out []box
+	for off := 0; off < len(data); {
+		if off+8 > len(data) {
+			return nil, errBadBox
+		}
+		size := int(binary.BigEndian.Uint32(data[off : off+4]))
+		typ := string(data[off+4 : off+8])
+		header := 8
+		switch size {
+		case 0:
+			size = len(data) - off
+		case 1:
+			if off+16 > len(data) {
+				return nil, errBadBox
+			}
+			large := binary.BigEndian.Uint64(data[off+8 : off+16])
+			if large > uint64(len(data)-off) {
+				return nil, errBadBox
+			}
+			size, header = int(large), 16
+		}
+		if size < header || off+size > len(data) {
+			return nil, errBadBox
+		}
+		out = append(out, box{typ: typ, payload: data[off+header : off+size], start: base + off + header})
+		off += size
+	}
+	return out, nil
+}
+
+func findBox(list []box, typ string) (box, bool) {
+	for _, b := range list {
+		if b.typ == typ {
+			return b, true
+		}
+	}
+	return box{}, false
+}
+
+// scrubHEIC zeroes the EXIF item of a HEIC file in place and returns the
+// largest image size declared by its ispe properties.
+func scrubHEIC(data []byte) (width, height int, err error) {
+	top, err := boxes(data, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	meta, ok := findBox(top, "meta")
+	if !ok || len(meta.payload) < 4 {
+		return 0, 0, errBadBox
+	}
+	// meta is a full box: skip version and flags.
+	children, err := boxes(meta.payload[4:], meta.start+4)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if iprp, ok := findBox(children, "iprp"); ok {
+		props, err := boxes(iprp.payload, iprp.start)
+		if err != nil {
+			return 0, 0, err
+		}
+		if ipco, ok := findBox(props, "ipco"); ok {
+			items, err := boxes(ipco.payload, ipco.start)
+			if err != nil {
+				return 0, 0, err
+			}
+			for _, p := range items {
+				if p.typ == "ispe" && len(p.payload) >= 12 {
+					w := int(binary.BigEndian.Uint32(p.payload[4:8]))
+					h := int(binary.BigEndian.Uint32(p.payload[8:12]))
+					if w*h > width*height {
+						width, height = w, h
+					}
+				}
+			}
+		}
+	}
+
+	iinf, ok := findBox(children, "iinf")
+	if !ok {
+		return width, height, nil
+	}
+	exifIDs, err := exifItemIDs(iinf)
+	if err != nil || len(exifIDs) == 0 {
+		return width, height, err
+	}
+	iloc, ok := findBox(children, "iloc")
+	if !ok {
+		return 0, 0, errBadBox
+	}
+	return width, height, zeroItems(data, iloc, exifIDs)
+}
+
+func exifItemIDs(iinf box) (map[uint32]bool, error) {
+	p := iinf.payload
+	if len(p) < 6 {
+		return nil, errBadBox
+	}
+	skip := 6 // version, flags, u16 entry count
+	if p[0] != 0 {
+		skip = 8 // u32 entry count
+	}
+	if len(p) < skip {
+		return nil, errBadBox
+	}
+	entries, err := boxes(p[skip:], iinf.start+skip)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[uint32]bool{}
+	for _, e := range entries {
+		if e.typ != "infe" || len(e.payload) < 4 {
+			continue
+		}
+		v := e.payload[0]
+		var id uint32
+		var typeOff int
+		switch {
+		case v == 2 && len(e.payload) >= 12:
+			id, typeOff = uint32(binary.BigEndian.Uint16(e.payload[4:6])), 8
+		case v == 3 && len(e.payload) >= 14:
+			id, typeOff = binary.BigEndian.Uint32(e.payload[4:8]), 10
+		default:
+			continue
+		}
+		if string(e.payload[typeOff:typeOff+4]) == "Exif" {
+			ids[id] = true
+		}
+	}
+	return ids, nil
+}
+
+// zeroItems overwrites the file extents of the given items with zeros.
+func zeroItems(data []byte, iloc box, ids map[uint32]bool) error {
+	p := iloc.payload
+	if len(p) < 8 {
+		return errBadBox
+	}
+	version := p[0]
+	offsetSize, lengthSize := int(p[4]>>4), int(p[4]&0x0F)
+	baseOffsetSize, indexSize := int(p[5]>>4), 0
+	if version == 1 || version == 2 {
+		indexSize = int(p[5] & 0x0F)
+	}
+
+	r := reader{buf: p, off: 6}
+	itemCount := r.uint(2)
+	if version == 2 {
+		r.off = 6
+		itemCount = r.uint(4)
+	}
+	for i := 0; i < itemCount && r.err == nil; i++ {
+		idSize := 2
+		if version == 2 {
+			idSize = 4
+		}
+		id := uint32(r.uint(idSize))
+		method := 0
+		if version == 1 || version == 2 {
+			method = r.uint(2) & 0x0F
+		}
+		r.uint(2) // data reference index
+		base := r.uint(baseOffsetSize)
+		extents := r.uint(2)
+		for e := 0; e < extents && r.err == nil; e++ {
+			r.uint(indexSize)
+			off, length := base+r.uint(offsetSize), r.uint(lengthSize)
+			if !ids[id] || method != 0 {
+				continue
+			}
+			if off < 0 || length < 0 || off+length > len(data) {
+				return errBadBox
+			}
+			clear(data[off : off+length])
+		}
+	}
+	return r.err
+}
+
+type reader struct {
+	buf []byte
+	off int
+	err error
+}
+
+// uint reads a big-endian unsigned integer of n bytes (0, 2, 4 or 8).
+func (r *reader) uint(n int) int {
+	if r.err != nil || n == 0 {
+		return 0
+	}
+	if r.off+n > len(r.buf) {
+		r.err = errBadBox
+		return 0
+	}
+	var v uint64
+	for _, b := range r.buf[r.off : r.off+n] {
+		v = v<<8 | uint64(b)
+	}
+	r.off += n
+	return int(v)
+}
@@ -0,0 +1,84 @@
+package uploads
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Kind is an allowed upload format, identified by its magic bytes.
+type Kind string
+
+const (
+	KindJPEG Kind = "jpeg"
+	KindPNG  Kind = "png"
+	KindHEIC Kind = "heic"
+	KindPDF  Kind = "pdf"
+)
+
+// Ext returns the file extension used when storing the upload.
+func (k Kind) Ext() string {
+	if k == KindJPEG {
+		return ".jpg"
+	}
+	return "." + string(k)
+}
+
+// MIME returns the content type of the format.
+func (k Kind) MIME() string {
+	switch k {
+	case KindJPEG:
+		return "image/jpeg"
+	case KindPNG:
+		return "image/png"
+	case KindHEIC:
+		return "image/heic"
+	case KindPDF:
+		return "application/pdf"
+	}
+	return "application/octet-stream"
+}
+
+// IsImage reports whether the format is a raster image.
+func (k Kind) IsImage() bool {
+	return k == KindJPEG || k == KindPNG || k == KindHEIC
+}
+
+// Decodable reports whether the format can be decoded in this service.
+// Only decodable images are sent to the model, hashed and analysed.
+func (k Kind) Decodable() bool {
+	return k == KindJPEG || k == KindPNG
+}
+
+var heicBrands = [][]byte{
+	[]byte("heic"), []byte("heix"), []byte("heim"), []byte("heis"),
+	[]byte("hevc"), []byte("hevx"), []byte("mif1"), []byte("msf1"),
+}
+
+// Sniff identifies the format from the first bytes of a file. The client's
+// file name and Content-Type are never trusted.
+func Sniff(head []byte) (Kind, bool) {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return KindJPEG, true
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return KindPNG, true
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return KindPDF, true
+	case len(head) >= 12 && bytes.Equal(head[4:8], []byte("ftyp")):
+		// ISO BMFF: the major brand follows the ftyp box header, and
+		// compatible brands follow the minor version.
+		size := int(binary.BigEndian.Uint32(head[0:4]))
+		size = min(size, len(head))
+		for off := 8; off+4 <= size; off += 4 {
+			if off == 12 {
+				continue // minor version
+			}
+			for _, brand := range heicBrands {
+				if bytes.Equal(head[off:off+4], brand) {
+					return KindHEIC, true
+				}
+			}
+		}
+	}
+	return "", false
+}
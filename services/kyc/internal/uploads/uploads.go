@@ -0,0 +1,158 @@
+// Package uploads validates and normalizes KYC document uploads before they
+// are stored: size and count limits, magic-byte allowlisting, metadata
+// stripping and decompression-bomb protection.
+package uploads
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	ErrTooLarge       = errors.New("file is too large")
+	ErrTooManyFiles   = errors.New("too many files")
+	ErrUnsupported    = errors.New("unsupported file type")
+	ErrTooManyPixels  = errors.New("image dimensions exceed the limit")
+	ErrCorrupt        = errors.New("file could not be decoded")
+	ErrDisallowedKind = errors.New("file type is not allowed here")
+)
+
+// Policy holds the upload limits.
+type Policy struct {
+	MaxFileBytes int64 // per file
+	MaxFiles     int   // per submission
+	MaxPixels    int   // width * height, checked before decoding
+	MaxDimension int   // stored images are downscaled to this longest side
+	AllowPDF     bool
+}
+
+// MaxRequestBytes is the largest multipart body the policy can accept,
+// with some room for form fields and multipart headers.
+func (p Policy) MaxRequestBytes() int64 {
+	return p.MaxFileBytes*int64(p.MaxFiles) + 1<<20
+}
+
+// CheckCount validates the number of files in a submission.
+func (p Policy) CheckCount(n int) error {
+	if n > p.MaxFiles {
+		return fmt.Errorf("%w: %d uploaded, at most %d allowed", ErrTooManyFiles, n, p.MaxFiles)
+	}
+	return nil
+}
+
+// File is an upload that passed validation and was written to disk.
+type File struct {
+	Path         string
+	Kind         Kind
+	Size         int64
+	Width        int
+	Height       int
+	OriginalName string
+}
+
+// Save validates the uploaded file, strips its metadata and writes it to dir
+// under a generated name starting with prefix. The client's file name is
+// only kept for reference and never used to build the path.
+func (p Policy) Save(fh *multipart.FileHeader, dir, prefix string) (*File, error) {
+	if fh.Size > p.MaxFileBytes {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d allowed", ErrTooLarge, fh.Size, p.MaxFileBytes)
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// Read one byte past the limit so a lying Size header is still caught.
+	data, err := io.ReadAll(io.LimitReader(src, p.MaxFileBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > p.MaxFileBytes {
+		return nil, fmt.Errorf("%w: at most %d bytes allowed", ErrTooLarge, p.MaxFileBytes)
+	}
+
+	out, err := p.normalize(data)
+	if err != nil {
+		return nil, err
+	}
+	out.OriginalName = filepath.Base(fh.Filename)
+
+	name := fmt.Sprintf("%s_%d_%s%s", prefix, time.Now().Unix(), randomHex(8), out.Kind.Ext())
+	out.Path = filepath.Join(dir, name)
+	if err := writeFile(out.Path, out.data); err != nil {
+		return nil, err
+	}
+	return &out.File, nil
+}
+
+type normalized struct {
+	File
+	data []byte
+}
+
+// normalize checks the content of an upload and returns the bytes to store.
+// JPEG and PNG are decoded and re-encoded, which drops EXIF and GPS data;
+// HEIC has its EXIF item blanked in place; PDF is kept as is.
+func (p Policy) normalize(data []byte) (*normalized, error) {
+	kind, ok := Sniff(data)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	out := &normalized{File: File{Kind: kind}}
+	switch kind {
+	case KindJPEG, KindPNG:
+		img, err := decodeImage(data, p.MaxPixels)
+		if err != nil {
+			return nil, err
+		}
+		if kind == KindJPEG {
+			img = applyOrientation(img, jpegOrientation(data))
+		}
+		img = Downscale(img, p.MaxDimension)
+		if out.data, err = encodeImage(img, kind); err != nil {
+			return nil, err
+		}
+		out.Width, out.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	case KindHEIC:
+		out.data = bytes.Clone(data)
+		w, h, err := scrubHEIC(out.data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if w*h > p.MaxPixels {
+			return nil, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, w, h)
+		}
+		out.Width, out.Height = w, h
+	case KindPDF:
+		if !p.AllowPDF {
+			return nil, ErrDisallowedKind
+		}
+		out.data = data
+	}
+	out.Size = int64(len(out.data))
+	return out, nil
+}
+
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
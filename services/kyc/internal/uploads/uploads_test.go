@@ -0,0 +1,467 @@
+package uploads
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// halves returns a w x h image, red on the left and blue on the right.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := red
+			if x >= w/2 {
+				c = blue
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// exifSegment returns an APP1 segment whose IFD0 holds the orientation
+// followed by extra bytes, such as a stand-in for GPS data.
+func exifSegment(order binary.AppendByteOrder, orientation uint16, extra string) []byte {
+	tiff := []byte("II*\x00")
+	if order == binary.BigEndian {
+		tiff = []byte("MM\x00*")
+	}
+	tiff = order.AppendUint32(tiff, 8)
+	tiff = order.AppendUint16(tiff, 1)
+	tiff = order.AppendUint16(tiff, 0x0112)
+	tiff = order.AppendUint16(tiff, 3) // SHORT
+	tiff = order.AppendUint32(tiff, 1)
+	tiff = order.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0)
+	tiff = order.AppendUint32(tiff, 0)
+	tiff = append(tiff, extra...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// withSegment inserts a segment right after the JPEG start of image marker.
+func withSegment(jpg, seg []byte) []byte {
+	return append(append(bytes.Clone(jpg[:2]), seg...), jpg[2:]...)
+}
+
+// pngChunk returns a PNG chunk with its CRC.
+func pngChunk(typ string, data []byte) []byte {
+	c := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	c = append(c, typ...)
+	c = append(c, data...)
+	return binary.BigEndian.AppendUint32(c, crc32.ChecksumIEEE(c[4:]))
+}
+
+// pngHeader returns the signature and IHDR chunk of a PNG declaring the
+// given size, without any pixel data.
+func pngHeader(w, h uint32) []byte {
+	ihdr := binary.BigEndian.AppendUint32(nil, w)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, h)
+	ihdr = append(ihdr, 8, 2, 0, 0, 0) // 8-bit RGB
+	return append([]byte("\x89PNG\r\n\x1a\n"), pngChunk("IHDR", ihdr)...)
+}
+
+func isobox(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(body)+8))
+	return append(append(b, typ...), body...)
+}
+
+func u16(v int) []byte { return binary.BigEndian.AppendUint16(nil, uint16(v)) }
+func u32(v int) []byte { return binary.BigEndian.AppendUint32(nil, uint32(v)) }
+
+var fullBoxHeader = []byte{0, 0, 0, 0}
+
+// heic builds a minimal HEIC file: an image item of the given size and an
+// Exif item stored in mdat. It returns the file and the Exif item's extent.
+func heic(width, height int, exif string) (data []byte, exifOff, exifLen int) {
+	build := func(off int) []byte {
+		ftyp := isobox("ftyp", []byte("heic"), u32(0), []byte("mif1heic"))
+		infe := func(id int, typ string) []byte {
+			return isobox("infe", []byte{2, 0, 0, 0}, u16(id), u16(0), []byte(typ), []byte{0})
+		}
+		iinf := isobox("iinf", fullBoxHeader, u16(2), infe(1, "hvc1"), infe(2, "Exif"))
+		iloc := isobox("iloc", fullBoxHeader, []byte{0x44, 0x00}, u16(1),
+			u16(2), u16(0), u16(1), u32(off), u32(len(exif)))
+		ispe := isobox("ispe", fullBoxHeader, u32(width), u32(height))
+		iprp := isobox("iprp", isobox("ipco", ispe))
+		meta := isobox("meta", fullBoxHeader, iinf, iloc, iprp)
+		mdat := isobox("mdat", []byte(exif), []byte("hevc pixel data"))
+		return bytes.Join([][]byte{ftyp, meta, mdat}, nil)
+	}
+	data = build(0)
+	exifOff = len(data) - len(exif) - len("hevc pixel data")
+	return build(exifOff), exifOff, len(exif)
+}
+
+var policy = Policy{MaxFileBytes: 1 << 20, MaxFiles: 4, MaxPixels: 1_000_000, MaxDimension: 64}
+
+func TestSniff(t *testing.T) {
+	heicFile, _, _ := heic(10, 10, "Exif")
+	tests := []struct {
+		name string
+		head []byte
+		want Kind
+		ok   bool
+	}{
+		{"jpeg", encodeJPEG(t, halves(4, 4)), KindJPEG, true},
+		{"png", encodePNG(t, halves(4, 4)), KindPNG, true},
+		{"pdf", []byte("%PDF-1.7\n"), KindPDF, true},
+		{"heic major brand", heicFile, KindHEIC, true},
+		{"heic compatible brand", isobox("ftyp", []byte("isom"), u32(0), []byte("mp41mif1")), KindHEIC, true},
+		{"mp4", isobox("ftyp", []byte("isom"), u32(0), []byte("isommp41")), "", false},
+		{"brand in minor version", isobox("ftyp", []byte("isom"), []byte("heic"), []byte("mp41")), "", false},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), "", false},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), "", false},
+		{"truncated jpeg magic", []byte{0xFF, 0xD8}, "", false},
+		{"empty", nil, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Sniff(tt.head)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: Sniff = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		kind      Kind
+		ext, mime string
+		decodable bool
+	}{
+		{KindJPEG, ".jpg", "image/jpeg", true},
+		{KindPNG, ".png", "image/png", true},
+		{KindHEIC, ".heic", "image/heic", false},
+		{KindPDF, ".pdf", "application/pdf", false},
+	}
+	for _, tt := range tests {
+		if tt.kind.Ext() != tt.ext || tt.kind.MIME() != tt.mime || tt.kind.Decodable() != tt.decodable {
+			t.Errorf("%s: Ext %q, MIME %q, Decodable %v", tt.kind, tt.kind.Ext(), tt.kind.MIME(), tt.kind.Decodable())
+		}
+	}
+}
+
+func TestNormalizeRejects(t *testing.T) {
+	bigHEIC, _, _ := heic(5000, 5000, "Exif")
+	truncatedHEIC, _, _ := heic(100, 100, "Exif")
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"png bomb", append(pngHeader(50_000, 50_000), pngChunk("IEND", nil)...), ErrTooManyPixels},
+		{"png one row too many", encodePNG(t, image.NewGray(image.Rect(0, 0, 1000, 1001))), ErrTooManyPixels},
+		{"png zero width", pngHeader(0, 10), ErrCorrupt},
+		{"heic bomb", bigHEIC, ErrTooManyPixels},
+		{"truncated jpeg", encodeJPEG(t, halves(8, 8))[:40], ErrCorrupt},
+		{"truncated heic", truncatedHEIC[:len(truncatedHEIC)-5], ErrCorrupt},
+		{"heic without meta", isobox("ftyp", []byte("heic"), u32(0)), ErrCorrupt},
+		{"pdf not allowed", []byte("%PDF-1.7\n"), ErrDisallowedKind},
+		{"text", []byte("hello"), ErrUnsupported},
+	}
+	for _, tt := range tests {
+		if _, err := policy.normalize(tt.data); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	allowPDF := policy
+	allowPDF.AllowPDF = true
+	if out, err := allowPDF.normalize([]byte("%PDF-1.7\n")); err != nil || out.Kind != KindPDF {
+		t.Errorf("pdf allowed: %+v, %v", out, err)
+	}
+}
+
+func TestNormalizeStripsMetadata(t *testing.T) {
+	const secret = "GPS 23.8103N 90.4125E"
+	jpg := withSegment(encodeJPEG(t, halves(16, 8)), exifSegment(binary.LittleEndian, 1, secret))
+
+	plain := encodePNG(t, halves(16, 8))
+	text := pngChunk("tEXt", []byte("Comment\x00"+secret))
+	pngWithText := append(append(bytes.Clone(plain[:33]), text...), plain[33:]...) // after IHDR
+
+	for name, data := range map[string][]byte{"jpeg": jpg, "png": pngWithText} {
+		if !bytes.Contains(data, []byte(secret)) {
+			t.Fatalf("%s: test file has no metadata", name)
+		}
+		out, err := policy.normalize(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if bytes.Contains(out.data, []byte(secret)) || bytes.Contains(out.data, []byte("Exif")) {
+			t.Errorf("%s: metadata survived normalization", name)
+		}
+		if out.Width != 16 || out.Height != 8 || out.Size != int64(len(out.data)) {
+			t.Errorf("%s: %dx%d, %d bytes", name, out.Width, out.Height, out.Size)
+		}
+	}
+}
+
+func TestNormalizeOrientation(t *testing.T) {
+	tests := []struct {
+		orientation   uint16
+		order         binary.AppendByteOrder
+		w, h          int
+		redAt, blueAt image.Point
+	}{
+		{1, binary.LittleEndian, 40, 20, image.Pt(5, 10), image.Pt(35, 10)},
+		{3, binary.BigEndian, 40, 20, image.Pt(35, 10), image.Pt(5, 10)},    // 180°
+		{6, binary.LittleEndian, 20, 40, image.Pt(10, 5), image.Pt(10, 35)}, // 90° clockwise
+		{8, binary.BigEndian, 20, 40, image.Pt(10, 35), image.Pt(10, 5)},    // 90° counter-clockwise
+		{9, binary.LittleEndian, 40, 20, image.Pt(5, 10), image.Pt(35, 10)}, // Invalid: ignored
+	}
+	src := encodeJPEG(t, halves(40, 20))
+	for _, tt := range tests {
+		data := withSegment(src, exifSegment(tt.order, tt.orientation, ""))
+		out, err := policy.normalize(data)
+		if err != nil {
+			t.Fatalf("orientation %d: %v", tt.orientation, err)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(out.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h || out.Width != tt.w || out.Height != tt.h {
+			t.Errorf("orientation %d: %v, want %dx%d", tt.orientation, b, tt.w, tt.h)
+		}
+		if r, _, b, _ := img.At(tt.redAt.X, tt.redAt.Y).RGBA(); r < b {
+			t.Errorf("orientation %d: %v is not red", tt.orientation, tt.redAt)
+		}
+		if r, _, b, _ := img.At(tt.blueAt.X, tt.blueAt.Y).RGBA(); b < r {
+			t.Errorf("orientation %d: %v is not blue", tt.orientation, tt.blueAt)
+		}
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	jpg := encodeJPEG(t, halves(8, 8))
+	seg := exifSegment(binary.BigEndian, 6, "")
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no exif", jpg, 1},
+		{"little endian", withSegment(jpg, exifSegment(binary.LittleEndian, 7, "")), 7},
+		{"big endian", withSegment(jpg, seg), 6},
+		{"out of range", withSegment(jpg, exifSegment(binary.LittleEndian, 0, "")), 1},
+		{"after another segment", withSegment(withSegment(jpg, seg), []byte{0xFF, 0xE0, 0, 4, 'J', 'F'}), 6},
+		{"truncated segment", withSegment(jpg, seg)[:12], 1},
+		{"bad byte order", withSegment(jpg, bytes.Replace(seg, []byte("MM"), []byte("XX"), 1)), 1},
+		{"empty", nil, 1},
+	}
+	for _, tt := range tests {
+		if got := jpegOrientation(tt.data); got != tt.want {
+			t.Errorf("%s: jpegOrientation = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// A 3x2 image with a marked top-left pixel. Each orientation moves it
+	// to a known corner of the upright image.
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.SetRGBA(0, 0, red)
+	tests := []struct {
+		orientation int
+		w, h        int
+		corner      image.Point
+	}{
+		{1, 3, 2, image.Pt(0, 0)},
+		{2, 3, 2, image.Pt(2, 0)},
+		{3, 3, 2, image.Pt(2, 1)},
+		{4, 3, 2, image.Pt(0, 1)},
+		{5, 2, 3, image.Pt(0, 0)},
+		{6, 2, 3, image.Pt(1, 0)},
+		{7, 2, 3, image.Pt(1, 2)},
+		{8, 2, 3, image.Pt(0, 2)},
+	}
+	for _, tt := range tests {
+		got := applyOrientation(src, tt.orientation)
+		if b := got.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orientation %d: size %v, want %dx%d", tt.orientation, b, tt.w, tt.h)
+			continue
+		}
+		if c := color.RGBAModel.Convert(got.At(tt.corner.X, tt.corner.Y)); c != red {
+			t.Errorf("orientation %d: %v = %v, want the marked pixel", tt.orientation, tt.corner, c)
+		}
+	}
+}
+
+func TestNormalizeScrubsHEIC(t *testing.T) {
+	const exif = "Exif\x00\x00MM\x00*GPS 23.8103N 90.4125E"
+	data, off, n := heic(800, 600, exif)
+	out, err := policy.normalize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Kind != KindHEIC || out.Width != 800 || out.Height != 600 {
+		t.Errorf("got %s %dx%d, want heic 800x600", out.Kind, out.Width, out.Height)
+	}
+	if !bytes.Equal(out.data[off:off+n], make([]byte, n)) {
+		t.Errorf("Exif item not zeroed: %q", out.data[off:off+n])
+	}
+	if !bytes.Equal(out.data[:off], data[:off]) || !bytes.Equal(out.data[off+n:], data[off+n:]) {
+		t.Error("bytes outside the Exif item changed")
+	}
+	if !bytes.Contains(data, []byte("GPS")) {
+		t.Error("normalize modified its input")
+	}
+}
+
+func TestScrubHEICErrors(t *testing.T) {
+	data, _, _ := heic(10, 10, "Exif")
+	badLength := bytes.Clone(data)
+	// Point the Exif extent past the end of the file.
+	i := bytes.Index(badLength, []byte("iloc")) + 4 + 4 + 2 + 2 + 2 + 2 + 2 + 4
+	binary.BigEndian.PutUint32(badLength[i:], 1<<20)
+
+	for name, d := range map[string][]byte{
+		"extent out of range": badLength,
+		"box size too large":  append(u32(1000), []byte("ftypheic")...),
+		"box size too small":  append(u32(4), []byte("ftypheic")...),
+	} {
+		if _, _, err := scrubHEIC(bytes.Clone(d)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestDownscale(t *testing.T) {
+	tests := []struct {
+		w, h, maxDim int
+		wantW, wantH int
+	}{
+		{100, 50, 64, 64, 32},
+		{50, 100, 64, 32, 64},
+		{40, 30, 64, 40, 30},
+		{1000, 1, 10, 10, 1},
+		{100, 50, 0, 100, 50},
+	}
+	for _, tt := range tests {
+		got := Downscale(halves(tt.w, tt.h), tt.maxDim).Bounds()
+		if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("Downscale(%dx%d, %d) = %dx%d, want %dx%d", tt.w, tt.h, tt.maxDim, got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestForModel(t *testing.T) {
+	small := encodePNG(t, halves(32, 16))
+	out, mime, err := ForModel(small, 64)
+	if err != nil || !bytes.Equal(out, small) || mime != "image/png" {
+		t.Errorf("small png: %d bytes, %q, %v", len(out), mime, err)
+	}
+
+	out, mime, err = ForModel(encodePNG(t, halves(256, 128)), 64)
+	if err != nil || mime != "image/jpeg" {
+		t.Fatalf("large png: %q, %v", mime, err)
+	}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(out)); err != nil || cfg.Width != 64 || cfg.Height != 32 {
+		t.Errorf("large png: %+v, %v", cfg, err)
+	}
+
+	heicFile, _, _ := heic(10, 10, "Exif")
+	for name, data := range map[string][]byte{"heic": heicFile, "pdf": []byte("%PDF-1.7\n")} {
+		if _, _, err := ForModel(data, 64); !errors.Is(err, errNotRaster) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+		if _, err := DecodeForAnalysis(data, 64); !errors.Is(err, errNotRaster) {
+			t.Errorf("%s: DecodeForAnalysis err = %v", name, err)
+		}
+	}
+}
+
+// fileHeader returns the header of a multipart file upload.
+func fileHeader(t *testing.T, name string, data []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", `form-data; name="front"; filename="`+name+`"`)
+	h.Set("Content-Type", "image/png")
+	part, err := w.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	w.Close()
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["front"][0]
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	saved, err := policy.Save(fileHeader(t, "../../etc/passport.png", encodeJPEG(t, halves(16, 16))), dir, "user1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(saved.Path) != dir || !strings.HasPrefix(filepath.Base(saved.Path), "user1_") || filepath.Ext(saved.Path) != ".jpg" {
+		t.Errorf("stored as %s", saved.Path)
+	}
+	if saved.Kind != KindJPEG || saved.OriginalName != "passport.png" {
+		t.Errorf("kind %s, original name %q", saved.Kind, saved.OriginalName)
+	}
+	if info, err := os.Stat(saved.Path); err != nil || info.Size() != saved.Size {
+		t.Errorf("stat: %v, %v", info, err)
+	}
+
+	small := policy
+	small.MaxFileBytes = 100
+	if _, err := small.Save(fileHeader(t, "big.jpg", make([]byte, 101)), dir, "user1"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized file: err = %v", err)
+	}
+}
+
+func TestCheckCount(t *testing.T) {
+	if err := policy.CheckCount(4); err != nil {
+		t.Errorf("CheckCount(4) = %v", err)
+	}
+	if err := policy.CheckCount(5); !errors.Is(err, ErrTooManyFiles) {
+		t.Errorf("CheckCount(5) = %v", err)
+	}
+}
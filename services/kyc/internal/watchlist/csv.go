@@ -0,0 +1,84 @@
+package watchlist
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+
+	"kyc/internal/models"
+)
+
+// parseCSV reads a CSV with a header row, or an OFAC list without one.
+func parseCSV(data []byte, file string) (string, []Entry, error) {
+	rows, err := readCSV(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(rows) == 0 || !headerHas(rows[0], "name") {
+		entries, err := parseOFACCSV(bytes.NewReader(data))
+		return ofacListName(file), entries, err
+	}
+
+	col := map[string]int{}
+	for i, h := range rows[0] {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	get := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	name := strings.ToUpper(strings.TrimSuffix(file, ".csv"))
+	defaultKind := models.WatchlistSanctions
+	if strings.Contains(name, "PEP") {
+		defaultKind = models.WatchlistPEP
+	}
+	var entries []Entry
+	for n, row := range rows[1:] {
+		e := Entry{
+			Kind:         strings.ToUpper(get(row, "kind")),
+			ID:           get(row, "id"),
+			Name:         get(row, "name"),
+			Aliases:      splitList(get(row, "aliases")),
+			DatesOfBirth: parseDates(get(row, "date_of_birth")),
+			Programs:     splitList(get(row, "programs")),
+		}
+		if e.Name == "" {
+			continue
+		}
+		switch e.Kind {
+		case "":
+			e.Kind = defaultKind
+		case models.WatchlistSanctions, models.WatchlistPEP:
+		default:
+			return "", nil, errors.New("unknown kind " + e.Kind)
+		}
+		if e.ID == "" {
+			e.ID = name + "-" + strconv.Itoa(n+1)
+		}
+		entries = append(entries, e)
+	}
+	return name, entries, nil
+}
+
+func headerHas(row []string, name string) bool {
+	for _, h := range row {
+		if strings.EqualFold(strings.TrimSpace(h), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ";") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package watchlist
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	yearOnly  = regexp.MustCompile(`^(?:circa |c\. ?|approximately )?(\d{4})$`)
+	yearSpan  = regexp.MustCompile(`^(\d{4}) ?(?:to|-) ?(\d{4})$`)
+	dateForms = []struct{ layout, out string }{
+		{"2006-01-02", "2006-01-02"},
+		{"02 Jan 2006", "2006-01-02"},
+		{"2 Jan 2006", "2006-01-02"},
+		{"02/01/2006", "2006-01-02"},
+		{"Jan 2006", "2006-01"},
+	}
+)
+
+// parseDates normalizes the ways lists write a date of birth to YYYY,
+// YYYY-MM or YYYY-MM-DD. Several dates may be separated by ";" or ","; a
+// span of years gives every year in it.
+func parseDates(s string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' }) {
+		part = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(part), "alt. DOB"))
+		if part == "" {
+			continue
+		}
+		if m := yearOnly.FindStringSubmatch(strings.ToLower(part)); m != nil {
+			out = append(out, m[1])
+			continue
+		}
+		if m := yearSpan.FindStringSubmatch(part); m != nil {
+			out = append(out, yearRange(m[1], m[2])...)
+			continue
+		}
+		for _, f := range dateForms {
+			if t, err := time.Parse(f.layout, part); err == nil {
+				out = append(out, t.Format(f.out))
+				break
+			}
+		}
+	}
+	return out
+}
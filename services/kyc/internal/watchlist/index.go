@@ -0,0 +1,151 @@
+package watchlist
+
+import (
+	"sort"
+	"strings"
+
+	"kyc/internal/matching"
+	"kyc/internal/models"
+)
+
+// Adjustments of the name score by date of birth. Listed dates are often
+// approximate, so a mismatch lowers the score without ruling the entry out.
+const (
+	dobExactBonus      = 0.05
+	dobMismatchPenalty = 0.08
+	// A single-word name matching one word of a longer name is weak
+	// evidence: many people share a first or family name.
+	singleTokenCap = 0.85
+)
+
+// Index holds the loaded entries, grouped by the first letters of their
+// normalized name tokens so that a screening only scores likely candidates.
+type Index struct {
+	entries []Entry
+	lists   []ListInfo
+	blocks  map[string][]int
+}
+
+func newIndex(entries []Entry, lists []ListInfo) *Index {
+	idx := &Index{entries: entries, lists: lists, blocks: map[string][]int{}}
+	for i, e := range entries {
+		seen := map[string]bool{}
+		for _, name := range e.names() {
+			for _, key := range blockKeys(name) {
+				if !seen[key] {
+					seen[key] = true
+					idx.blocks[key] = append(idx.blocks[key], i)
+				}
+			}
+		}
+	}
+	return idx
+}
+
+func (e *Entry) names() []string {
+	return append([]string{e.Name}, e.Aliases...)
+}
+
+func blockKeys(name string) []string {
+	var keys []string
+	for _, tok := range matching.NormalizeName(name) {
+		r := []rune(tok)
+		keys = append(keys, string(r[:min(2, len(r))]))
+	}
+	return keys
+}
+
+// Lists describes the loaded files.
+func (idx *Index) Lists() []ListInfo {
+	return idx.lists
+}
+
+// Len returns the number of loaded entries.
+func (idx *Index) Len() int {
+	return len(idx.entries)
+}
+
+// Screen returns the entries matching any of the subject's names with a
+// score of at least threshold, best first.
+func (idx *Index) Screen(subject models.ScreeningSubject, threshold float64) []models.ScreeningHit {
+	candidates := map[int]bool{}
+	for _, name := range subject.Names {
+		for _, key := range blockKeys(name) {
+			for _, i := range idx.blocks[key] {
+				candidates[i] = true
+			}
+		}
+	}
+
+	var hits []models.ScreeningHit
+	for i := range candidates {
+		e := &idx.entries[i]
+		best, matched := 0.0, ""
+		for _, listed := range e.names() {
+			for _, name := range subject.Names {
+				if s := nameScore(name, listed); s > best {
+					best, matched = s, listed
+				}
+			}
+		}
+		dob := compareDOB(subject.DateOfBirth, e.DatesOfBirth)
+		score := best
+		switch dob {
+		case models.DOBExact:
+			score = min(1, score+dobExactBonus)
+		case models.DOBMismatch:
+			score -= dobMismatchPenalty
+		}
+		if score < threshold {
+			continue
+		}
+		hits = append(hits, models.ScreeningHit{
+			List:        e.List,
+			Kind:        e.Kind,
+			EntryID:     e.ID,
+			ListedName:  e.Name,
+			MatchedName: matched,
+			NameScore:   round2(best),
+			DOBMatch:    dob,
+			Score:       round2(score),
+			Programs:    e.Programs,
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Key() < hits[j].Key()
+	})
+	return hits
+}
+
+func nameScore(a, b string) float64 {
+	s := matching.NameSimilarity(a, b)
+	ta, tb := len(matching.NormalizeName(a)), len(matching.NormalizeName(b))
+	if ta != tb && min(ta, tb) == 1 {
+		s = min(s, singleTokenCap)
+	}
+	return s
+}
+
+// compareDOB compares a YYYY-MM-DD date with listed dates of any precision.
+func compareDOB(dob string, listed []string) string {
+	if dob == "" || len(listed) == 0 {
+		return models.DOBUnknown
+	}
+	result := models.DOBMismatch
+	for _, l := range listed {
+		switch {
+		case l == dob:
+			return models.DOBExact
+		case len(l) >= 4 && strings.HasPrefix(dob, l[:4]):
+			result = models.DOBYear
+		}
+	}
+	return result
+}
+
+func round2(v float64) float64 {
+	return float64(int(v*100+0.5)) / 100
+}
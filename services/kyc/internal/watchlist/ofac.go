@@ -0,0 +1,162 @@
+package watchlist
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+
+	"kyc/internal/models"
+)
+
+// ofacList is the part of the OFAC sdnList XML format used for screening.
+// Element names are matched without their namespace, which changed between
+// publications.
+type ofacList struct {
+	Entries []struct {
+		UID       string   `xml:"uid"`
+		FirstName string   `xml:"firstName"`
+		LastName  string   `xml:"lastName"`
+		Type      string   `xml:"sdnType"`
+		Programs  []string `xml:"programList>program"`
+		Akas      []struct {
+			Category  string `xml:"category"`
+			FirstName string `xml:"firstName"`
+			LastName  string `xml:"lastName"`
+		} `xml:"akaList>aka"`
+		DatesOfBirth []string `xml:"dateOfBirthList>dateOfBirthItem>dateOfBirth"`
+	} `xml:"sdnEntry"`
+}
+
+func parseOFACXML(data []byte) ([]Entry, error) {
+	var list ofacList
+	if err := xml.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, s := range list.Entries {
+		if !strings.EqualFold(s.Type, "Individual") {
+			continue
+		}
+		e := Entry{
+			Kind:     models.WatchlistSanctions,
+			ID:       strings.TrimSpace(s.UID),
+			Name:     joinNames(s.FirstName, s.LastName),
+			Programs: s.Programs,
+		}
+		for _, a := range s.Akas {
+			// OFAC advises against screening on weak aliases.
+			if strings.EqualFold(a.Category, "weak") {
+				continue
+			}
+			if n := joinNames(a.FirstName, a.LastName); n != "" {
+				e.Aliases = append(e.Aliases, n)
+			}
+		}
+		for _, d := range s.DatesOfBirth {
+			e.DatesOfBirth = append(e.DatesOfBirth, parseDates(d)...)
+		}
+		if e.Name != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// ofacNull marks an empty field in OFAC CSV files.
+const ofacNull = "-0-"
+
+var remarksDOB = regexp.MustCompile(`DOB ([^;]+)`)
+
+// parseOFACCSV reads sdn.csv or cons_prim.csv: ent_num, SDN_Name, SDN_Type,
+// Program, Title, Call_Sign, Vess_type, Tonnage, GRT, Vess_flag,
+// Vess_owner, Remarks. Names are written "LAST, First" and dates of birth
+// are only found in the remarks.
+func parseOFACCSV(r io.Reader) ([]Entry, error) {
+	rows, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, row := range rows {
+		if len(row) < 12 || !strings.EqualFold(ofacField(row[2]), "individual") {
+			continue
+		}
+		e := Entry{
+			Kind: models.WatchlistSanctions,
+			ID:   ofacField(row[0]),
+			Name: ofacName(row[1]),
+		}
+		for _, p := range strings.Split(ofacField(row[3]), "] [") {
+			if p = strings.Trim(p, "[] "); p != "" {
+				e.Programs = append(e.Programs, p)
+			}
+		}
+		for _, m := range remarksDOB.FindAllStringSubmatch(row[11], -1) {
+			e.DatesOfBirth = append(e.DatesOfBirth, parseDates(m[1])...)
+		}
+		if e.Name != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// parseOFACAliases reads alt.csv: ent_num, alt_num, alt_type, alt_name,
+// alt_remarks. Weak aliases are left out, as in the XML.
+func parseOFACAliases(r io.Reader) (map[string][]string, error) {
+	rows, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	aliases := map[string][]string{}
+	for _, row := range rows {
+		if len(row) < 4 || strings.Contains(strings.ToLower(row[2]), "weak") {
+			continue
+		}
+		if name := ofacName(row[3]); name != "" {
+			id := ofacField(row[0])
+			aliases[id] = append(aliases[id], name)
+		}
+	}
+	return aliases, nil
+}
+
+func ofacField(s string) string {
+	s = strings.TrimSpace(s)
+	if s == ofacNull {
+		return ""
+	}
+	return s
+}
+
+// ofacName turns "LAST, First" into "First LAST".
+func ofacName(s string) string {
+	s = ofacField(s)
+	if last, first, ok := strings.Cut(s, ","); ok {
+		return joinNames(first, last)
+	}
+	return s
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	var rows [][]string
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// OFAC files end with a line holding only a control character.
+		if len(row) == 1 && strings.TrimSpace(strings.Trim(row[0], "\x1a")) == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+}
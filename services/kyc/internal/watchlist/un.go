@@ -0,0 +1,103 @@
+package watchlist
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"kyc/internal/models"
+)
+
+// unList is the part of the UN Security Council consolidated list used for
+// screening individuals.
+type unList struct {
+	Individuals []struct {
+		DataID          string `xml:"DATAID"`
+		FirstName       string `xml:"FIRST_NAME"`
+		SecondName      string `xml:"SECOND_NAME"`
+		ThirdName       string `xml:"THIRD_NAME"`
+		FourthName      string `xml:"FOURTH_NAME"`
+		ListType        string `xml:"UN_LIST_TYPE"`
+		ReferenceNumber string `xml:"REFERENCE_NUMBER"`
+		NameOriginal    string `xml:"NAME_ORIGINAL_SCRIPT"`
+		Aliases         []struct {
+			Quality string `xml:"QUALITY"`
+			Name    string `xml:"ALIAS_NAME"`
+		} `xml:"INDIVIDUAL_ALIAS"`
+		DatesOfBirth []struct {
+			Type     string `xml:"TYPE_OF_DATE"`
+			Date     string `xml:"DATE"`
+			Year     string `xml:"YEAR"`
+			FromYear string `xml:"FROM_YEAR"`
+			ToYear   string `xml:"TO_YEAR"`
+		} `xml:"INDIVIDUAL_DATE_OF_BIRTH"`
+	} `xml:"INDIVIDUALS>INDIVIDUAL"`
+}
+
+func parseUN(data []byte) ([]Entry, error) {
+	var list unList
+	if err := xml.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(list.Individuals))
+	for _, ind := range list.Individuals {
+		e := Entry{
+			Kind: models.WatchlistSanctions,
+			ID:   strings.TrimSpace(ind.ReferenceNumber),
+			Name: joinNames(ind.FirstName, ind.SecondName, ind.ThirdName, ind.FourthName),
+		}
+		if e.ID == "" {
+			e.ID = strings.TrimSpace(ind.DataID)
+		}
+		if ind.ListType != "" {
+			e.Programs = []string{strings.TrimSpace(ind.ListType)}
+		}
+		if n := strings.TrimSpace(ind.NameOriginal); n != "" {
+			e.Aliases = append(e.Aliases, n)
+		}
+		for _, a := range ind.Aliases {
+			// Low quality aliases are too vague to screen on.
+			if n := strings.TrimSpace(a.Name); n != "" && !strings.EqualFold(a.Quality, "Low") {
+				e.Aliases = append(e.Aliases, n)
+			}
+		}
+		for _, d := range ind.DatesOfBirth {
+			switch {
+			case d.Date != "":
+				e.DatesOfBirth = append(e.DatesOfBirth, parseDates(d.Date)...)
+			case d.Year != "":
+				e.DatesOfBirth = append(e.DatesOfBirth, parseDates(d.Year)...)
+			case d.FromYear != "" && d.ToYear != "":
+				e.DatesOfBirth = append(e.DatesOfBirth, yearRange(d.FromYear, d.ToYear)...)
+			}
+		}
+		if e.Name != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func joinNames(parts ...string) string {
+	var names []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			names = append(names, p)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// yearRange lists the years from..to, for dates of birth given as a range.
+func yearRange(from, to string) []string {
+	a, err1 := strconv.Atoi(strings.TrimSpace(from))
+	b, err2 := strconv.Atoi(strings.TrimSpace(to))
+	if err1 != nil || err2 != nil || b < a || b-a > 20 {
+		return nil
+	}
+	var years []string
+	for y := a; y <= b; y++ {
+		years = append(years, strconv.Itoa(y))
+	}
+	return years
+}
@@ -0,0 +1,159 @@
+// Package watchlist loads sanctions and PEP lists from local files and
+// screens people against them.
+//
+// Supported files, recognised by their content:
+//
+//   - UN Security Council consolidated list XML (CONSOLIDATED_LIST)
+//   - OFAC SDN or consolidated list XML (sdnList)
+//   - OFAC CSV files: sdn.csv with alt.csv, cons_prim.csv with cons_alt.csv
+//   - CSV with a header row: name, aliases (separated by ";"),
+//     date_of_birth, id, programs and kind (SANCTIONS or PEP)
+//
+// Only individuals are loaded; entities and vessels are skipped.
+package watchlist
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"kyc/internal/models"
+)
+
+// Entry is one listed person.
+type Entry struct {
+	List         string
+	Kind         string
+	ID           string
+	Name         string
+	Aliases      []string
+	DatesOfBirth []string // YYYY, YYYY-MM or YYYY-MM-DD
+	Programs     []string
+}
+
+// ListInfo describes a loaded file.
+type ListInfo struct {
+	Name     string    `json:"name"`
+	Kind     string    `json:"kind"`
+	File     string    `json:"file"`
+	SHA256   string    `json:"sha256"`
+	Entries  int       `json:"entries"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+// ofacAliasFiles maps OFAC alias files to the list they belong to. Address
+// files are not needed for screening.
+var ofacAliasFiles = map[string]string{"alt.csv": "sdn.csv", "cons_alt.csv": "cons_prim.csv"}
+
+var ofacSkippedFiles = map[string]bool{"add.csv": true, "cons_add.csv": true, "sdn_comments.csv": true}
+
+// Load reads every list in dir. A missing directory gives an empty index.
+func Load(dir string) (*Index, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var entries []Entry
+	var lists []ListInfo
+	aliases := map[string]map[string][]string{} // Main file -> entry ID -> aliases
+	for _, file := range files {
+		base := strings.ToLower(filepath.Base(file))
+		ext := filepath.Ext(base)
+		if (ext != ".xml" && ext != ".csv") || ofacSkippedFiles[base] {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if main, ok := ofacAliasFiles[base]; ok {
+			a, err := parseOFACAliases(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			aliases[main] = a
+			continue
+		}
+
+		var parsed []Entry
+		var name string
+		if ext == ".xml" {
+			name, parsed, err = parseXML(data, base)
+		} else {
+			name, parsed, err = parseCSV(data, base)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		kind := models.WatchlistSanctions
+		if len(parsed) > 0 {
+			kind = parsed[0].Kind
+		}
+		sum := sha256.Sum256(data)
+		lists = append(lists, ListInfo{
+			Name:     name,
+			Kind:     kind,
+			File:     filepath.Base(file),
+			SHA256:   hex.EncodeToString(sum[:]),
+			Entries:  len(parsed),
+			LoadedAt: time.Now(),
+		})
+		for i := range parsed {
+			parsed[i].List = name
+		}
+		entries = append(entries, parsed...)
+	}
+
+	// Alias files may sort before or after their list.
+	for i := range entries {
+		for main, byID := range aliases {
+			if entries[i].List == ofacListName(main) {
+				entries[i].Aliases = append(entries[i].Aliases, byID[entries[i].ID]...)
+			}
+		}
+	}
+	return newIndex(entries, lists), nil
+}
+
+// parseXML recognises the list format by its root element.
+func parseXML(data []byte, file string) (string, []Entry, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return "", nil, fmt.Errorf("empty XML document")
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "CONSOLIDATED_LIST":
+			entries, err := parseUN(data)
+			return "UN", entries, err
+		case "sdnList":
+			entries, err := parseOFACXML(data)
+			return ofacListName(file), entries, err
+		}
+		return "", nil, fmt.Errorf("unknown list format <%s>", start.Name.Local)
+	}
+}
+
+// ofacListName names an OFAC list after its file, e.g. OFAC_SDN for sdn.csv
+// or sdn.xml.
+func ofacListName(file string) string {
+	return "OFAC_" + strings.ToUpper(strings.TrimSuffix(file, filepath.Ext(file)))
+}
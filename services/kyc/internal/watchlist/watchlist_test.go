@@ -0,0 +1,102 @@
+package watchlist
+
+import (
+	"slices"
+	"testing"
+
+	"kyc/internal/models"
+)
+
+func loadTestdata(t *testing.T) *Index {
+	t.Helper()
+	idx, err := Load("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+func TestLoad(t *testing.T) {
+	idx := loadTestdata(t)
+	// Entities and vessels are skipped.
+	if idx.Len() != 5 {
+		t.Fatalf("loaded %d entries, want 5", idx.Len())
+	}
+	byID := map[string]Entry{}
+	for _, e := range idx.entries {
+		byID[e.List+":"+e.ID] = e
+	}
+
+	un := byID["UN:QDi.040"]
+	if un.Name != "ABDUL RAHMAN YASIN" || !slices.Equal(un.Aliases, []string{"Abdul Rahman S. Taha"}) || !slices.Equal(un.DatesOfBirth, []string{"1960-04-10"}) {
+		t.Errorf("UN entry = %+v", un)
+	}
+	if got := byID["UN:QDi.999"].DatesOfBirth; !slices.Equal(got, []string{"1970", "1971", "1972"}) {
+		t.Errorf("UN year range = %v", got)
+	}
+	sdn := byID["OFAC_SDN:36"]
+	if sdn.Name != "Ayman AL-ZAWAHIRI" || !slices.Equal(sdn.Aliases, []string{"Aiman AL-ZAWAHRI", "DR. AYMAN"}) || !slices.Equal(sdn.DatesOfBirth, []string{"1951-06-19"}) {
+		t.Errorf("OFAC CSV entry = %+v", sdn)
+	}
+	xmlEntry := byID["OFAC_SDN:7001"]
+	if xmlEntry.Name != "Viktor KARPENKO" || !slices.Equal(xmlEntry.Programs, []string{"UKRAINE-EO13660"}) || !slices.Equal(xmlEntry.DatesOfBirth, []string{"1966-02-12"}) {
+		t.Errorf("OFAC XML entry = %+v", xmlEntry)
+	}
+	if pep := byID["PEP:PEP-1"]; pep.Kind != models.WatchlistPEP {
+		t.Errorf("PEP entry kind = %q", pep.Kind)
+	}
+}
+
+func TestScreen(t *testing.T) {
+	idx := loadTestdata(t)
+	tests := []struct {
+		name    string
+		subject models.ScreeningSubject
+		want    string // Key of the best hit, "" for none
+		wantDOB string
+	}{
+		{"exact name and date", models.ScreeningSubject{Names: []string{"Abdul Rahman Yasin"}, DateOfBirth: "1960-04-10"}, "UN:QDi.040", models.DOBExact},
+		{"transliteration", models.ScreeningSubject{Names: []string{"Abdur Rahman Yaseen"}}, "UN:QDi.040", models.DOBUnknown},
+		{"alias", models.ScreeningSubject{Names: []string{"Aiman Zawahri"}}, "OFAC_SDN:36", models.DOBUnknown},
+		{"listed name with another date", models.ScreeningSubject{Names: []string{"Ayman al-Zawahiri"}, DateOfBirth: "1990-01-01"}, "OFAC_SDN:36", models.DOBMismatch},
+		{"Bengali script", models.ScreeningSubject{Names: []string{"রহিম উদ্দিন চৌধুরী"}}, "PEP:PEP-1", models.DOBUnknown},
+		{"year in a listed range", models.ScreeningSubject{Names: []string{"Muhammed Hussain Khan"}, DateOfBirth: "1971-05-05"}, "UN:QDi.999", models.DOBYear},
+		{"one shared word", models.ScreeningSubject{Names: []string{"Yasin"}}, "", ""},
+		{"different person", models.ScreeningSubject{Names: []string{"Rahim Ahmed"}, DateOfBirth: "1990-01-01"}, "", ""},
+		{"similar name, other date", models.ScreeningSubject{Names: []string{"Karim Hossain Khan"}, DateOfBirth: "1990-01-01"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := idx.Screen(tt.subject, 0.9)
+			if tt.want == "" {
+				if len(hits) > 0 {
+					t.Errorf("unexpected hits %+v", hits)
+				}
+				return
+			}
+			if len(hits) == 0 {
+				t.Fatal("no hit")
+			}
+			if hits[0].Key() != tt.want || hits[0].DOBMatch != tt.wantDOB {
+				t.Errorf("best hit %s (%s), want %s (%s)", hits[0].Key(), hits[0].DOBMatch, tt.want, tt.wantDOB)
+			}
+		})
+	}
+}
+
+func TestParseDates(t *testing.T) {
+	tests := map[string][]string{
+		"1961-09-30":          {"1961-09-30"},
+		"19 Jun 1951":         {"1951-06-19"},
+		"Jan 1961":            {"1961-01"},
+		"circa 1955":          {"1955"},
+		"1960 to 1962":        {"1960", "1961", "1962"},
+		"1958; alt. DOB 1959": {"1958", "1959"},
+		"unknown":             nil,
+	}
+	for in, want := range tests {
+		if got := parseDates(in); !slices.Equal(got, want) {
+			t.Errorf("parseDates(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
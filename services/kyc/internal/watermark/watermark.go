@@ -0,0 +1,128 @@
+// Package watermark stamps text over images, so that a screenshot of a
+// document can be traced back to the reviewer who opened it.
+package watermark
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+)
+
+// Apply returns a copy of img with text tiled across it in semi-transparent
+// red. Text is drawn in upper case with a built-in 5x7 bitmap font;
+// characters the font lacks are drawn as '?'.
+func Apply(img image.Image, text string) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+
+	text = strings.ToUpper(text)
+	if text == "" {
+		return dst
+	}
+	// Scale the font with the image so it stays legible after resizing.
+	scale := max(2, min(b.Dx(), b.Dy())/250)
+	advance := (glyphWidth + 1) * scale
+	lineWidth := (len(text) + 4) * advance
+	lineHeight := glyphHeight * scale * 6
+
+	for row, y := 0, lineHeight/2; y < b.Dy(); row, y = row+1, y+lineHeight {
+		// Shift every other row so that cropping cannot remove all copies.
+		for x := -(row % 2) * lineWidth / 2; x < b.Dx(); x += lineWidth {
+			drawText(dst, text, x, y, scale)
+		}
+	}
+	return dst
+}
+
+var ink = color.RGBA{R: 220, G: 20, B: 20, A: 255}
+
+const inkAlpha = 0.35
+
+func drawText(dst *image.RGBA, text string, x, y, scale int) {
+	for _, r := range text {
+		glyph, ok := font[r]
+		if !ok {
+			glyph = font['?']
+		}
+		for gy, line := range glyph {
+			for gx := 0; gx < glyphWidth; gx++ {
+				if line[gx] != '1' {
+					continue
+				}
+				for sy := 0; sy < scale; sy++ {
+					for sx := 0; sx < scale; sx++ {
+						blend(dst, x+gx*scale+sx, y+gy*scale+sy)
+					}
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+func blend(dst *image.RGBA, x, y int) {
+	if !(image.Point{X: x, Y: y}).In(dst.Rect) {
+		return
+	}
+	c := dst.RGBAAt(x, y)
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a)*(1-inkAlpha) + float64(b)*inkAlpha)
+	}
+	dst.SetRGBA(x, y, color.RGBA{R: mix(c.R, ink.R), G: mix(c.G, ink.G), B: mix(c.B, ink.B), A: 255})
+}
+
+// font is a 5x7 bitmap font covering what appears in reviewer IDs and
+// timestamps.
+var font = map[rune][glyphHeight]string{
+	'0': {"01110", "10001", "10011", "10101", "11001", "10001", "01110"},
+	'1': {"00100", "01100", "00100", "00100", "00100", "00100", "01110"},
+	'2': {"01110", "10001", "00001", "00010", "00100", "01000", "11111"},
+	'3': {"11111", "00010", "00100", "00010", "00001", "10001", "01110"},
+	'4': {"00010", "00110", "01010", "10010", "11111", "00010", "00010"},
+	'5': {"11111", "10000", "11110", "00001", "00001", "10001", "01110"},
+	'6': {"00110", "01000", "10000", "11110", "10001", "10001", "01110"},
+	'7': {"11111", "00001", "00010", "00100", "01000", "01000", "01000"},
+	'8': {"01110", "10001", "10001", "01110", "10001", "10001", "01110"},
+	'9': {"01110", "10001", "10001", "01111", "00001", "00010", "01100"},
+	'A': {"01110", "10001", "10001", "11111", "10001", "10001", "10001"},
+	'B': {"11110", "10001", "10001", "11110", "10001", "10001", "11110"},
+	'C': {"01110", "10001", "10000", "10000", "10000", "10001", "01110"},
+	'D': {"11100", "10010", "10001", "10001", "10001", "10010", "11100"},
+	'E': {"11111", "10000", "10000", "11110", "10000", "10000", "11111"},
+	'F': {"11111", "10000", "10000", "11110", "10000", "10000", "10000"},
+	'G': {"01110", "10001", "10000", "10111", "10001", "10001", "01111"},
+	'H': {"10001", "10001", "10001", "11111", "10001", "10001", "10001"},
+	'I': {"01110", "00100", "00100", "00100", "00100", "00100", "01110"},
+	'J': {"00111", "00010", "00010", "00010", "00010", "10010", "01100"},
+	'K': {"10001", "10010", "10100", "11000", "10100", "10010", "10001"},
+	'L': {"10000", "10000", "10000", "10000", "10000", "10000", "11111"},
+	'M': {"10001", "11011", "10101", "10101", "10001", "10001", "10001"},
+	'N': {"10001", "10001", "11001", "10101", "10011", "10001", "10001"},
+	'O': {"01110", "10001", "10001", "10001", "10001", "10001", "01110"},
+	'P': {"11110", "10001", "10001", "11110", "10000", "10000", "10000"},
+	'Q': {"01110", "10001", "10001", "10001", "10101", "10010", "01101"},
+	'R': {"11110", "10001", "10001", "11110", "10100", "10010", "10001"},
+	'S': {"01111", "10000", "10000", "01110", "00001", "00001", "11110"},
+	'T': {"11111", "00100", "00100", "00100", "00100", "00100", "00100"},
+	'U': {"10001", "10001", "10001", "10001", "10001", "10001", "01110"},
+	'V': {"10001", "10001", "10001", "10001", "10001", "01010", "00100"},
+	'W': {"10001", "10001", "10001", "10101", "10101", "10101", "01010"},
+	'X': {"10001", "10001", "01010", "00100", "01010", "10001", "10001"},
+	'Y': {"10001", "10001", "10001", "01010", "00100", "00100", "00100"},
+	'Z': {"11111", "00001", "00010", "00100", "01000", "10000", "11111"},
+	'-': {"00000", "00000", "00000", "11111", "00000", "00000", "00000"},
+	'_': {"00000", "00000", "00000", "00000", "00000", "00000", "11111"},
+	':': {"00000", "01100", "01100", "00000", "01100", "01100", "00000"},
+	'.': {"00000", "00000", "00000", "00000", "00000", "01100", "01100"},
+	'@': {"01110", "10001", "10111", "10101", "10111", "10000", "01110"},
+	'/': {"00000", "00001", "00010", "00100", "01000", "10000", "00000"},
+	'?': {"01110", "10001", "00001", "00010", "00100", "00000", "00100"},
+	' ': {"00000", "00000", "00000", "00000", "00000", "00000", "00000"},
+}